@@ -66,7 +66,7 @@ print_info "当前系统: $GOOS/$GOARCH"
 
 # 构建当前平台版本
 print_info "构建当前平台版本..."
-if go build -ldflags="-s -w" -o go-agent cmd/agent/main.go; then
+if go build -ldflags="-s -w" -o go-agent ./cmd/agent; then
     print_success "当前平台版本构建完成: go-agent"
     chmod +x go-agent
 else
@@ -79,7 +79,7 @@ echo ""
 print_info "开始交叉编译..."
 
 # Linux AMD64
-if GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-linux-amd64 cmd/agent/main.go; then
+if GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-linux-amd64 ./cmd/agent; then
     print_success "Linux AMD64 版本构建完成: go-agent-linux-amd64"
     chmod +x go-agent-linux-amd64
 else
@@ -87,7 +87,7 @@ else
 fi
 
 # Linux ARM64
-if GOOS=linux GOARCH=arm64 go build -ldflags="-s -w" -o go-agent-linux-arm64 cmd/agent/main.go; then
+if GOOS=linux GOARCH=arm64 go build -ldflags="-s -w" -o go-agent-linux-arm64 ./cmd/agent; then
     print_success "Linux ARM64 版本构建完成: go-agent-linux-arm64"
     chmod +x go-agent-linux-arm64
 else
@@ -95,14 +95,14 @@ else
 fi
 
 # Windows AMD64
-if GOOS=windows GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-windows-amd64.exe cmd/agent/main.go; then
+if GOOS=windows GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-windows-amd64.exe ./cmd/agent; then
     print_success "Windows AMD64 版本构建完成: go-agent-windows-amd64.exe"
 else
     print_warning "Windows AMD64 版本构建失败"
 fi
 
 # macOS AMD64
-if GOOS=darwin GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-darwin-amd64 cmd/agent/main.go; then
+if GOOS=darwin GOARCH=amd64 go build -ldflags="-s -w" -o go-agent-darwin-amd64 ./cmd/agent; then
     print_success "macOS AMD64 版本构建完成: go-agent-darwin-amd64"
     chmod +x go-agent-darwin-amd64
 else
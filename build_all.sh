@@ -105,7 +105,7 @@ for target in "${!TARGETS[@]}"; do
     
     export GOOS GOARCH
     
-    if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$RELEASE_DIR/$OUTPUT" cmd/agent/main.go; then
+    if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$RELEASE_DIR/$OUTPUT" ./cmd/agent; then
         
         # 设置可执行权限（非Windows平台）
         if [ "$GOOS" != "windows" ]; then
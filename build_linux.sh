@@ -86,7 +86,7 @@ print_step "构建 Linux x64 可执行文件..."
 export CGO_ENABLED=0
 export GOOS=linux
 export GOARCH=amd64
-if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$PACKAGE_DIR/go-agent" cmd/agent/main.go; then
+if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$PACKAGE_DIR/go-agent" ./cmd/agent; then
     print_success "Linux x64 构建完成"
     chmod +x "$PACKAGE_DIR/go-agent"
 else
@@ -97,7 +97,7 @@ fi
 # 构建程序 - Linux ARM64
 print_step "构建 Linux ARM64 可执行文件..."
 export GOARCH=arm64
-if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$PACKAGE_DIR/go-agent-arm64" cmd/agent/main.go; then
+if go build -ldflags="-s -w -X 'main.Version=$VERSION' -X 'main.BuildTime=$BUILD_TIME'" -o "$PACKAGE_DIR/go-agent-arm64" ./cmd/agent; then
     print_success "Linux ARM64 构建完成"
     chmod +x "$PACKAGE_DIR/go-agent-arm64"
 else
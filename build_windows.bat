@@ -60,7 +60,7 @@ echo [信息] 构建 Windows 64位可执行文件...
 set CGO_ENABLED=0
 set GOOS=windows
 set GOARCH=amd64
-go build -ldflags="-s -w -X 'main.Version=%VERSION%' -X 'main.BuildTime=%BUILD_TIME%'" -o %PACKAGE_DIR%\go-agent.exe cmd/agent/main.go
+go build -ldflags="-s -w -X 'main.Version=%VERSION%' -X 'main.BuildTime=%BUILD_TIME%'" -o %PACKAGE_DIR%\go-agent.exe ./cmd/agent
 if %ERRORLEVEL% neq 0 (
     echo [错误] Windows 64位构建失败
     pause
@@ -70,7 +70,7 @@ if %ERRORLEVEL% neq 0 (
 :: 构建程序 - Windows 32位
 echo [信息] 构建 Windows 32位可执行文件...
 set GOARCH=386
-go build -ldflags="-s -w -X 'main.Version=%VERSION%' -X 'main.BuildTime=%BUILD_TIME%'" -o %PACKAGE_DIR%\go-agent-x86.exe cmd/agent/main.go
+go build -ldflags="-s -w -X 'main.Version=%VERSION%' -X 'main.BuildTime=%BUILD_TIME%'" -o %PACKAGE_DIR%\go-agent-x86.exe ./cmd/agent
 if %ERRORLEVEL% neq 0 (
     echo [警告] Windows 32位构建失败，跳过...
 )
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/config"
+	"go-agent/pkg/logger"
+	"go-agent/pkg/scheduler"
+)
+
+// collectorNames 采集器类别的显示名称
+var collectorNames = map[string]string{
+	scheduler.CollectorKindCommand: "命令映射 (command_mapping.yaml)",
+	scheduler.CollectorKindBuiltin: "内置键",
+	scheduler.CollectorKindSystem:  "硬编码系统指标",
+}
+
+// initCommandLogger 子命令的日志只输出警告及以上级别到stderr，避免干扰命令输出
+func initCommandLogger() error {
+	if err := logger.InitWithConfig("warn", "text", "stderr", verbose); err != nil {
+		return fmt.Errorf("初始化日志失败: %v", err)
+	}
+	return nil
+}
+
+// loadCommandConfig 为子命令初始化日志并加载配置
+func loadCommandConfig() (*config.Config, error) {
+	if err := initCommandLogger(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %v", err)
+	}
+	return cfg, nil
+}
+
+// parseValueTypeFlag 解析 --type 参数，为空时返回nil
+func parseValueTypeFlag(valueType string) (*client.InfoType, error) {
+	if valueType == "" {
+		return nil, nil
+	}
+	infoType, err := client.ParseInfoType(valueType)
+	if err != nil {
+		return nil, err
+	}
+	return &infoType, nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go-agent/pkg/credential"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// credentialInfo credential list 输出的单条凭据（不含密码）
+type credentialInfo struct {
+	Name        string    `json:"name"`
+	Username    string    `json:"username"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// newCredentialCommand 创建credential子命令：管理加密凭据库
+func newCredentialCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credential",
+		Short: "管理加密凭据库（命令映射中通过 credential: 名称 引用）",
+	}
+	cmd.AddCommand(newCredentialAddCommand(), newCredentialListCommand(), newCredentialRemoveCommand(), newCredentialGenkeyCommand())
+	return cmd
+}
+
+// newCredentialAddCommand 创建credential add子命令，密码从标准输入读取，避免出现在命令行历史中
+func newCredentialAddCommand() *cobra.Command {
+	var username string
+	var description string
+
+	cmd := &cobra.Command{
+		Use:           "add <name>",
+		Short:         "添加或替换凭据，密码从标准输入读取",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := credential.ValidateName(name); err != nil {
+				return err
+			}
+
+			vault, err := openVault()
+			if err != nil {
+				return err
+			}
+
+			password, err := readPassword(os.Stdin)
+			if err != nil {
+				return err
+			}
+
+			if err := vault.Set(name, credential.Credential{Username: username, Password: password, Description: description}); err != nil {
+				return err
+			}
+			if err := vault.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("凭据 %s 已保存到 %s\n", name, vault.Path())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&username, "username", "u", "", "用户名")
+	cmd.Flags().StringVar(&description, "description", "", "说明")
+	return cmd
+}
+
+// newCredentialListCommand 创建credential list子命令，不输出密码
+func newCredentialListCommand() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "列出凭据（不显示密码）",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vault, err := openVault()
+			if err != nil {
+				return err
+			}
+
+			infos := make([]credentialInfo, 0)
+			for _, name := range vault.Names() {
+				cred, _ := vault.Get(name)
+				infos = append(infos, credentialInfo{
+					Name:        name,
+					Username:    cred.Username,
+					Description: cred.Description,
+					UpdatedAt:   cred.UpdatedAt,
+				})
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(infos)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tUSERNAME\tUPDATED\tDESCRIPTION")
+			for _, info := range infos {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.Name, dash(info.Username), info.UpdatedAt.Format("2006-01-02 15:04:05"), info.Description)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以JSON格式输出")
+	return cmd
+}
+
+// newCredentialRemoveCommand 创建credential remove子命令
+func newCredentialRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "remove <name>",
+		Aliases:       []string{"rm"},
+		Short:         "删除凭据",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vault, err := openVault()
+			if err != nil {
+				return err
+			}
+			if !vault.Remove(args[0]) {
+				return fmt.Errorf("凭据 %s 不存在", args[0])
+			}
+			if err := vault.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("凭据 %s 已删除\n", args[0])
+			return nil
+		},
+	}
+}
+
+// newCredentialGenkeyCommand 创建credential genkey子命令：生成随机密钥文件
+func newCredentialGenkeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "genkey <path>",
+		Short:         "生成随机密钥文件，配置到 credentials.key_file",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := credential.GenerateKeyFile(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("密钥文件已生成: %s\n请在配置中设置 credentials.key_file，并妥善备份该文件（丢失后无法解密凭据库）\n", args[0])
+			return nil
+		},
+	}
+}
+
+// openVault 按配置打开凭据库，文件不存在时返回空凭据库
+func openVault() (*credential.Vault, error) {
+	cfg, err := loadCommandConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Credentials.File == "" {
+		return nil, fmt.Errorf("未配置凭据库文件 credentials.file")
+	}
+
+	secret, err := credential.Secret(cfg.Credentials.KeyFile, cfg.Credentials.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return credential.Open(cfg.Credentials.File, secret)
+}
+
+// readPassword 读取密码：标准输入为终端时提示并关闭回显读取，否则从管道读取一行
+func readPassword(input *os.File) (string, error) {
+	var password string
+	if fd := int(input.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "输入密码: ")
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("读取密码失败: %v", err)
+		}
+		password = string(data)
+	} else {
+		line, err := bufio.NewReader(input).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("读取密码失败: %v", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return "", fmt.Errorf("密码不能为空")
+	}
+	return password, nil
+}
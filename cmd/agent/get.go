@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go-agent/pkg/scheduler"
+
+	"github.com/spf13/cobra"
+)
+
+// newGetCommand 创建get子命令：在本地临时采集一个key并打印结果，不发送任何数据
+func newGetCommand() *cobra.Command {
+	var valueType string
+
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "在本地采集指定监控项key并打印结果，不发送数据",
+		Example: `  go-agent get system.cpu.util
+  go-agent get 'vfs.fs.size[/data,pused]' --type float`,
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true, // 错误由main统一输出
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infoType, err := parseValueTypeFlag(valueType)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadCommandConfig()
+			if err != nil {
+				return err
+			}
+
+			result, err := scheduler.Evaluate(context.Background(), cfg, args[0], infoType)
+			if err != nil {
+				return fmt.Errorf("采集 %s 失败: %v", args[0], err)
+			}
+
+			collectorName := collectorNames[result.Collector]
+			if collectorName == "" {
+				collectorName = fmt.Sprintf("原生采集器 (%s)", result.Collector)
+			}
+			fmt.Printf("key:       %s\n", result.Key)
+			fmt.Printf("value:     %s\n", result.Value.String())
+			fmt.Printf("type:      %s\n", result.Value.Type)
+			fmt.Printf("collector: %s\n", collectorName)
+			fmt.Printf("duration:  %s\n", result.Duration)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&valueType, "type", "t", "", "值类型 (float/character/log/unsigned/text)，不指定时根据结果推断")
+	return cmd
+}
+
+// newTestCommand 创建test子命令：在本地采集一个key，单行输出结果，失败时返回非0退出码，便于脚本检查
+func newTestCommand() *cobra.Command {
+	var valueType string
+
+	cmd := &cobra.Command{
+		Use:   "test <key>",
+		Short: "测试指定监控项key能否在本地采集，输出 key [collector|type|value]",
+		Example: `  go-agent test system.hostname
+  go-agent test mysql.ping --type unsigned`,
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infoType, err := parseValueTypeFlag(valueType)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadCommandConfig()
+			if err != nil {
+				return err
+			}
+
+			result, err := scheduler.Evaluate(context.Background(), cfg, args[0], infoType)
+			if err != nil {
+				fmt.Printf("%-40s [error|%s]\n", args[0], err)
+				return fmt.Errorf("测试 %s 失败", args[0])
+			}
+			fmt.Printf("%-40s [%s|%s|%s]\n", result.Key, result.Collector, result.Value.Type, result.Value.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&valueType, "type", "t", "", "值类型 (float/character/log/unsigned/text)，不指定时根据结果推断")
+	return cmd
+}
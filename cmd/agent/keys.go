@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go-agent/pkg/collector"
+	"go-agent/pkg/scheduler"
+
+	"github.com/spf13/cobra"
+)
+
+// keyInfo keys子命令输出的单个监控项key
+type keyInfo struct {
+	Key         string `json:"key"`
+	Source      string `json:"source"` // builtin、command 或原生采集器名称（如 mysql）
+	Category    string `json:"category"`
+	Units       string `json:"units"`
+	ValueType   string `json:"value_type"`
+	Description string `json:"description"`
+	Overridden  bool   `json:"overridden,omitempty"` // 内置键或原生采集器的key被同名命令映射覆盖
+}
+
+// newKeysCommand 创建keys子命令：列出内置键、原生采集器和命令映射中的全部key
+func newKeysCommand() *cobra.Command {
+	var asJSON bool
+	var category string
+
+	cmd := &cobra.Command{
+		Use:           "keys",
+		Short:         "列出所有内置键、原生采集器和命令映射中的监控项key",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadCommandConfig()
+			if err != nil {
+				return err
+			}
+
+			mapping, err := collector.LoadCommandMapping(cfg.Agent.CommandMapping)
+			if err != nil {
+				return fmt.Errorf("加载命令映射失败: %v", err)
+			}
+
+			// 原生采集器未启用时同样列出，便于查看可用的key
+			services := []collector.ServiceCollector{
+				collector.NewMySQLCollector(cfg.Collect.MySQL),
+				collector.NewPostgresCollector(cfg.Collect.Postgres),
+				collector.NewRedisCollector(cfg.Collect.Redis),
+				collector.NewMemcachedCollector(cfg.Collect.Memcached),
+			}
+			keys := listKeys(collector.NewBuiltinKeyManager(), services, mapping)
+			if category != "" {
+				filtered := keys[:0]
+				for _, key := range keys {
+					if strings.EqualFold(key.Category, category) {
+						filtered = append(filtered, key)
+					}
+				}
+				keys = filtered
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(keys)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tSOURCE\tCATEGORY\tUNITS\tVALUE TYPE\tDESCRIPTION")
+			for _, key := range keys {
+				source := key.Source
+				if key.Overridden {
+					source += "(已被覆盖)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.Key, source, dash(key.Category), dash(key.Units), dash(key.ValueType), key.Description)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以JSON格式输出")
+	cmd.Flags().StringVar(&category, "category", "", "只列出指定分类的key，命令映射的分类为 command/<类型>，原生采集器的分类为采集器名称")
+	return cmd
+}
+
+// listKeys 合并内置键、原生采集器和命令映射，按key排序；命令映射优先级更高，同名的其他key标记为已覆盖
+func listKeys(builtinKeys *collector.BuiltinKeyManager, services []collector.ServiceCollector, mapping *collector.CommandMapping) []keyInfo {
+	var keys []keyInfo
+	for _, key := range builtinKeys.GetAllKeys() {
+		_, overridden := mapping.Commands[key.Key]
+		keys = append(keys, keyInfo{
+			Key:         key.Key,
+			Source:      scheduler.CollectorKindBuiltin,
+			Category:    key.Category,
+			Units:       key.Units,
+			ValueType:   key.ValueType,
+			Description: key.Description,
+			Overridden:  overridden && mapping.Settings.Enabled,
+		})
+	}
+	for _, service := range services {
+		for _, key := range service.Keys() {
+			keys = append(keys, keyInfo{
+				Key:         key.Key,
+				Source:      service.Name(),
+				Category:    service.Name(),
+				Units:       key.Units,
+				ValueType:   key.ValueType,
+				Description: key.Description,
+				Overridden:  serviceKeyOverridden(key.Key, mapping),
+			})
+		}
+	}
+	for _, key := range mapping.Keys() {
+		config := mapping.Commands[key]
+		keys = append(keys, keyInfo{
+			Key:         key,
+			Source:      scheduler.CollectorKindCommand,
+			Category:    "command/" + strings.ToLower(config.Type),
+			Units:       config.Units,
+			ValueType:   config.ValueType,
+			Description: config.Description,
+		})
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].Key != keys[j].Key {
+			return keys[i].Key < keys[j].Key
+		}
+		return keys[i].Source < keys[j].Source
+	})
+	return keys
+}
+
+// serviceKeyOverridden 原生采集器的key（去掉参数）与命令映射中的key或参数化key同名时会被覆盖
+func serviceKeyOverridden(key string, mapping *collector.CommandMapping) bool {
+	if !mapping.Settings.Enabled {
+		return false
+	}
+	name := key
+	if index := strings.IndexByte(key, '['); index > 0 {
+		name = key[:index]
+	}
+	for _, candidate := range []string{name, name + "[*]"} {
+		if _, exists := mapping.Commands[candidate]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// dash 空值显示为 -
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
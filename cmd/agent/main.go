@@ -8,7 +8,9 @@ import (
 	"runtime"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 内置时区数据库，保证Windows等缺少时区数据的主机也能解析时区
 
+	"go-agent/pkg/collector"
 	"go-agent/pkg/config"
 	"go-agent/pkg/logger"
 	"go-agent/pkg/scheduler"
@@ -23,6 +25,13 @@ var (
 )
 
 func main() {
+	// 命令执行采集器以 __exec 重新执行代理，设置资源限制后exec目标程序
+	if len(os.Args) > 1 && os.Args[1] == collector.ExecHelperArg {
+		err := collector.RunExecHelper(os.Args[2:])
+		fmt.Fprintf(os.Stderr, "%s: %v\n", collector.ExecHelperArg, err)
+		os.Exit(126)
+	}
+
 	rootCmd := &cobra.Command{
 		Use:   "go-agent",
 		Short: "Go Agent - 系统监控和指标采集代理",
@@ -35,6 +44,8 @@ func main() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "详细输出")
 	rootCmd.PersistentFlags().BoolVarP(&daemon, "daemon", "d", false, "后台运行模式")
 
+	rootCmd.AddCommand(newGetCommand(), newTestCommand(), newKeysCommand(), newValidateCommand(), newStatusCommand(), newCredentialCommand())
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "执行失败: %v\n", err)
 		os.Exit(1)
@@ -72,17 +83,26 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// 初始化调度器
 	sched := scheduler.New()
+	sched.SetConfigFile(configFile)
 	if err := sched.Start(ctx, cfg); err != nil {
 		return fmt.Errorf("启动调度器失败: %v", err)
 	}
 
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// 等待信号
 	go func() {
 		sig := <-sigChan
+		// SIGHUP 重新加载配置，不停止agent
+		for sig == syscall.SIGHUP {
+			logger.Info("接收到SIGHUP信号，重新加载配置")
+			if err := sched.Reload(); err != nil {
+				logger.Errorf("重新加载配置失败，保持当前配置: %v", err)
+			}
+			sig = <-sigChan
+		}
 		logger.Infof("接收到停止信号 %v，正在优雅关闭...", sig)
 		
 		// 取消上下文，这会通知所有服务停止
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"go-agent/pkg/scheduler"
+
+	"github.com/spf13/cobra"
+)
+
+// newStatusCommand 创建status子命令：查询运行中agent的本地状态接口
+func newStatusCommand() *cobra.Command {
+	var addr string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:           "status",
+		Short:         "查询运行中agent的状态",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if addr == "" {
+				cfg, err := loadCommandConfig()
+				if err != nil {
+					return err
+				}
+				addr = cfg.Agent.StatusListen
+			}
+			if addr == "" {
+				return fmt.Errorf("本地状态接口未启用（agent.status_listen为空），可通过 --addr 指定地址")
+			}
+
+			httpClient := &http.Client{Timeout: timeout}
+			resp, err := httpClient.Get("http://" + addr + scheduler.StatusPath)
+			if err != nil {
+				return fmt.Errorf("无法连接agent状态接口 %s，agent可能未运行: %v", addr, err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("agent状态接口返回异常，状态码: %d", resp.StatusCode)
+			}
+			_, err = io.Copy(os.Stdout, resp.Body)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "", "状态接口地址，默认使用配置中的 agent.status_listen")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "请求超时")
+	return cmd
+}
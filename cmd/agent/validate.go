@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go-agent/pkg/collector"
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+	"go-agent/pkg/scheduler"
+	"go-agent/pkg/services"
+
+	"github.com/spf13/cobra"
+)
+
+// newValidateCommand 创建validate子命令：检查配置文件、命令映射和本地监控项，报告全部问题，不启动agent
+func newValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "validate",
+		Short:         "检查配置文件、命令映射和本地监控项文件，不启动agent",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initCommandLogger(); err != nil {
+				return err
+			}
+
+			cfg, problems, err := config.Check(configFile)
+			if err != nil {
+				fmt.Printf("✗ %s: %v\n", configFile, err)
+				return fmt.Errorf("配置检查未通过")
+			}
+			problems = append(problems, checkServiceCredentials(cfg)...)
+			problems = append(problems, collector.CheckScriptConfig(configFile, cfg.Collect.Script)...)
+			count := reportProblems(configFile, "", problems)
+
+			// 命令映射中的问题与config.yaml无关，配置有误时仍继续检查
+			var mapping *collector.CommandMapping
+			mapping, problems = loadCommandMapping(cfg)
+			if mapping != nil {
+				count += reportProblems(mapping.Path, fmt.Sprintf("%d 个命令", len(mapping.Commands)), problems)
+			} else {
+				count += len(config.Errors(problems))
+			}
+
+			count += validateLocalItems(cfg, mapping)
+			if count > 0 {
+				return fmt.Errorf("配置检查未通过，共 %d 个错误", count)
+			}
+			fmt.Println("配置检查通过")
+			return nil
+		},
+	}
+}
+
+// reportProblems 按文件输出问题列表，返回错误数量（不含警告）
+func reportProblems(file, summary string, problems []config.Problem) int {
+	errCount := len(config.Errors(problems))
+	if errCount == 0 && len(problems) == 0 {
+		if summary != "" {
+			fmt.Printf("✓ %s (%s)\n", file, summary)
+		} else {
+			fmt.Printf("✓ %s\n", file)
+		}
+		return 0
+	}
+
+	mark := "✗"
+	if errCount == 0 {
+		mark = "⚠"
+	}
+	fmt.Printf("%s %s:\n", mark, file)
+	for _, problem := range problems {
+		level := "错误"
+		if problem.Warning {
+			level = "警告"
+		}
+		if problem.Key != "" {
+			fmt.Printf("    - [%s] %s: %s\n", level, problem.Key, problem.Message)
+		} else {
+			fmt.Printf("    - [%s] %s\n", level, problem.Message)
+		}
+	}
+	return errCount
+}
+
+// loadCommandMapping 加载并检查命令映射，文件无法读取时返回nil
+func loadCommandMapping(cfg *config.Config) (*collector.CommandMapping, []config.Problem) {
+	mapping, err := collector.LoadCommandMapping(cfg.Agent.CommandMapping)
+	if err != nil {
+		fmt.Printf("✗ %s: %v\n", cfg.Agent.CommandMapping, err)
+		return nil, []config.Problem{{File: cfg.Agent.CommandMapping, Message: err.Error()}}
+	}
+	problems := mapping.Validate(cfg.Agent.Timeout)
+
+	// 检查命令引用的凭据
+	vault, err := credential.Load(cfg.Credentials)
+	if err != nil {
+		return mapping, append(problems, config.Problem{File: cfg.Credentials.File, Message: err.Error()})
+	}
+	return mapping, append(problems, mapping.CheckCredentials(vault)...)
+}
+
+// checkServiceCredentials 检查原生采集器连接配置引用的凭据，凭据库无法打开时由命令映射检查报告
+func checkServiceCredentials(cfg *config.Config) []config.Problem {
+	if !cfg.Collect.MySQL.Enabled && !cfg.Collect.Postgres.Enabled && !cfg.Collect.Redis.Enabled {
+		return nil
+	}
+	vault, err := credential.Load(cfg.Credentials)
+	if err != nil {
+		return nil
+	}
+
+	var problems []config.Problem
+	if cfg.Collect.MySQL.Enabled {
+		problems = append(problems, collector.CheckProfileCredentials(configFile, "collect.mysql", cfg.Collect.MySQL.Profiles, vault)...)
+	}
+	if cfg.Collect.Postgres.Enabled {
+		problems = append(problems, collector.CheckProfileCredentials(configFile, "collect.postgres", cfg.Collect.Postgres.Profiles, vault)...)
+	}
+	if cfg.Collect.Redis.Enabled {
+		problems = append(problems, collector.CheckProfileCredentials(configFile, "collect.redis", cfg.Collect.Redis.Profiles, vault)...)
+	}
+	return problems
+}
+
+// validateLocalItems 监控项来源为file时检查本地监控项文件、预处理步骤和命令超时，返回错误数量
+func validateLocalItems(cfg *config.Config, mapping *collector.CommandMapping) int {
+	if cfg.Items.Source != config.ItemSourceFile {
+		return 0
+	}
+
+	path := cfg.Items.File
+	items, fileProblems, err := services.CheckLocalItems(path, scheduler.CheckCustomInterval)
+	if err != nil {
+		fmt.Printf("✗ %s: %v\n", path, err)
+		return 1
+	}
+
+	problems := &config.Problems{File: path}
+	for _, problem := range fileProblems {
+		problems.Add(problem.Key, "%s", problem.Message)
+	}
+	for _, item := range items {
+		itemPath := fmt.Sprintf("items[%s]", item.ItemKey)
+		if _, err := scheduler.NewPreprocessor(item.Preprocessing); err != nil {
+			problems.Add(itemPath+".preprocessing", "%v", err)
+		}
+		if mapping == nil || item.Timeout <= 0 {
+			continue
+		}
+		if command, exists := mapping.Commands[item.ItemKey]; exists {
+			timeout := command.Timeout
+			if timeout == 0 {
+				timeout = mapping.Settings.DefaultTimeout
+			}
+			if timeout > item.Timeout {
+				problems.Warn(itemPath+".timeout", "监控项超时 %s 小于命令超时 %s，命令会在超时前被取消",
+					time.Duration(item.Timeout)*time.Second, time.Duration(timeout)*time.Second)
+			}
+		}
+	}
+	return reportProblems(path, fmt.Sprintf("%d 个监控项", len(items)), problems.List())
+}
@@ -27,7 +27,7 @@ mkdir %PACKAGE_DIR%\scripts
 
 :: 构建程序
 echo [信息] 构建可执行文件...
-go build -ldflags="-s -w -X main.Version=%VERSION%" -o %PACKAGE_DIR%\go-agent.exe cmd/agent/main.go
+go build -ldflags="-s -w -X main.Version=%VERSION%" -o %PACKAGE_DIR%\go-agent.exe ./cmd/agent
 if %ERRORLEVEL% neq 0 (
     echo [错误] 构建失败
     pause
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrWatchUnsupported 数据中心不支持配置变更长轮询
+var ErrWatchUnsupported = errors.New("数据中心不支持配置变更通知")
+
+// ConfigWatchResponse 配置变更长轮询响应
+type ConfigWatchResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Changed bool   `json:"changed"`
+		Version string `json:"version"`
+	} `json:"data"`
+}
+
+// GetConfigIfChanged 条件获取采集配置：携带上次的ETag，配置未变化时返回notModified为true且不返回配置
+// 返回的etag为服务端本次下发的ETag，服务端不支持时为空
+func (c *DeviceMonitorClient) GetConfigIfChanged(ctx context.Context, etag string) (resp *ConfigResponse, newETag string, notModified bool, err error) {
+	if c.agentID == "" {
+		return nil, "", false, fmt.Errorf("agentID为空，请先注册")
+	}
+
+	var status responseStatus
+	opts := []requestOption{withStatus(&status)}
+	if etag != "" {
+		opts = append(opts, withHeader("If-None-Match", etag))
+	}
+
+	var configResp ConfigResponse
+	path := fmt.Sprintf("/deviceMonitor/agent/config/%s", c.agentID)
+	if err := c.doRequest(ctx, "GET", path, nil, &configResp, opts...); err != nil {
+		return nil, "", false, fmt.Errorf("获取配置失败: %v", err)
+	}
+	switch status.Code {
+	case http.StatusNotModified:
+		return nil, etag, true, nil
+	case http.StatusNotFound, http.StatusNoContent:
+		return nil, "", false, fmt.Errorf("获取配置失败，状态码: %d", status.Code)
+	}
+
+	return &configResp, status.Header.Get("ETag"), false, nil
+}
+
+// WatchConfig 长轮询等待配置变更，服务端在配置变化或等待超时后返回
+// version为当前配置版本（ETag或内容哈希）；服务端返回404时返回ErrWatchUnsupported
+func (c *DeviceMonitorClient) WatchConfig(ctx context.Context, version string, wait time.Duration) (bool, error) {
+	if c.agentID == "" {
+		return false, fmt.Errorf("agentID为空，请先注册")
+	}
+
+	query := url.Values{}
+	query.Set("version", version)
+	query.Set("timeout", fmt.Sprintf("%d", int(wait.Seconds())))
+	path := fmt.Sprintf("/deviceMonitor/agent/config/%s/watch?%s", c.agentID, query.Encode())
+
+	// 长轮询的等待时间超过普通请求超时，使用不限时的客户端并由ctx控制
+	watchCtx, cancel := context.WithTimeout(ctx, wait+10*time.Second)
+	defer cancel()
+	watchClient := &http.Client{Transport: c.httpClient.Transport}
+
+	var resp ConfigWatchResponse
+	var status responseStatus
+	if err := c.doRequest(watchCtx, "GET", path, nil, &resp, withHTTPClient(watchClient), withStatus(&status)); err != nil {
+		return false, err
+	}
+
+	switch status.Code {
+	case http.StatusNotModified, http.StatusNoContent:
+		return false, nil
+	case http.StatusNotFound:
+		return false, ErrWatchUnsupported
+	}
+	if resp.Code != 200 {
+		return false, fmt.Errorf("配置变更通知响应异常: %s", resp.Msg)
+	}
+	return resp.Data.Changed, nil
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 条件获取配置：携带认证和If-None-Match，304时不返回配置，500时返回错误
+func TestGetConfigIfChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Header.Get("If-None-Match") {
+		case `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		case `"broken"`:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"code":200,"msg":"ok"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewDeviceMonitorClient(&Config{BaseURL: server.URL, AgentID: "agent", Timeout: time.Second})
+	c.SetToken("token")
+	ctx := context.Background()
+
+	resp, etag, notModified, err := c.GetConfigIfChanged(ctx, "")
+	if err != nil || notModified || resp == nil || resp.Code != 200 || etag != `"v1"` {
+		t.Fatalf("first fetch = (%v, %q, %v, %v), want config with ETag \"v1\"", resp, etag, notModified, err)
+	}
+
+	resp, etag, notModified, err = c.GetConfigIfChanged(ctx, `"v1"`)
+	if err != nil || !notModified || resp != nil || etag != `"v1"` {
+		t.Errorf("conditional fetch = (%v, %q, %v, %v), want not modified", resp, etag, notModified, err)
+	}
+
+	if _, _, _, err := c.GetConfigIfChanged(ctx, `"broken"`); err == nil {
+		t.Error("GetConfigIfChanged with server error succeeded, want error")
+	}
+}
@@ -56,8 +56,23 @@ type HeartbeatRequest struct {
 
 // HeartbeatResponse 心跳响应
 type HeartbeatResponse struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data,omitempty"` // 可携带随心跳下发的任务: {"tasks": [...]}
+}
+
+// Tasks 返回随心跳下发的任务，data不是任务对象时返回空
+func (r *HeartbeatResponse) Tasks() []AgentTask {
+	if len(r.Data) == 0 {
+		return nil
+	}
+	var data struct {
+		Tasks []AgentTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return nil
+	}
+	return data.Tasks
 }
 
 // MetricsRequest 指标数据请求
@@ -65,6 +80,7 @@ type MetricsRequest struct {
 	ItemID    int64       `json:"itemId"`
 	Timestamp int64       `json:"timestamp"`
 	Value     interface{} `json:"value"`
+	InfoType  *InfoType   `json:"infoType,omitempty"` // 值类型（值已按类型转换时携带）
 }
 
 // MetricsResponse 指标数据响应
@@ -74,6 +90,8 @@ type MetricsResponse struct {
 }
 
 // ItemCustomInterval 自定义时间间隔
+// Type为空时为星期+时间窗口形式（IntervalSeconds为0表示窗口内不采集）；
+// 否则Expression按Type解析：flexible "50s/1-5,09:00-18:00"、scheduling "wd1-5h9m0"、cron "0 */5 * * * *"
 type ItemCustomInterval struct {
 	ItemID          int64  `json:"itemId"`
 	IntervalStr     string `json:"intervalStr"`
@@ -82,6 +100,15 @@ type ItemCustomInterval struct {
 	WeekStr         string `json:"weekStr"`
 	StartTime       string `json:"startTime"` // 格式: "HH:mm:ss"
 	EndTime         string `json:"endTime"`   // 格式: "HH:mm:ss"
+	Type            string `json:"type,omitempty"`
+	Expression      string `json:"expression,omitempty"`
+}
+
+// PreprocessingStep 监控项预处理步骤，按顺序作用于采集到的原始值
+// Type取值见scheduler包的预处理实现，如 multiplier、regex、jsonpath、change_per_second
+type PreprocessingStep struct {
+	Type   string   `json:"type"`
+	Params []string `json:"params,omitempty"`
 }
 
 // ConfigResponseData 配置响应数据项
@@ -94,6 +121,8 @@ type ConfigResponseData struct {
 	Timeout               int                   `json:"timeout"`
 	Description           *string               `json:"description"`
 	Intervals             []*ItemCustomInterval `json:"intervals"`
+	Timezone              string                `json:"timezone,omitempty"` // 自定义间隔所用时区（IANA名称），为空时使用agent时区
+	Preprocessing         []*PreprocessingStep  `json:"preprocessing,omitempty"`
 }
 
 // ConfigResponse 配置获取响应
@@ -198,13 +227,26 @@ func (c *DeviceMonitorClient) SendMetrics(ctx context.Context, data interface{})
 	}
 }
 
-// SendSingleMetric 发送单个指标数据
+// SendSingleMetric 发送单个指标数据（以当前时间作为采集时间）
 func (c *DeviceMonitorClient) SendSingleMetric(ctx context.Context, itemID int64, value interface{}) (*MetricsResponse, error) {
+	return c.SendMetricWithTimestamp(ctx, itemID, value, time.Now())
+}
+
+// SendMetricWithTimestamp 发送单个指标数据，使用指定的采集时间
+func (c *DeviceMonitorClient) SendMetricWithTimestamp(ctx context.Context, itemID int64, value interface{}, timestamp time.Time) (*MetricsResponse, error) {
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
 	req := &MetricsRequest{
 		ItemID:    itemID,
-		Timestamp: time.Now().UnixMilli(), // 精确到毫秒
+		Timestamp: timestamp.UnixMilli(), // 精确到毫秒
 		Value:     value,
 	}
+	if typed, ok := value.(*TypedValue); ok {
+		infoType := typed.Type
+		req.InfoType = &infoType
+	}
 
 	var resp MetricsResponse
 	err := c.doRequest(ctx, "POST", "/deviceMonitor/agent/metrics", req, &resp)
@@ -215,6 +257,27 @@ func (c *DeviceMonitorClient) SendSingleMetric(ctx context.Context, itemID int64
 	return &resp, nil
 }
 
+// SendHistoricalMetrics 批量发送带有明确采集时间的历史指标数据
+func (c *DeviceMonitorClient) SendHistoricalMetrics(ctx context.Context, metrics []MetricsRequest) (*MetricsResponse, error) {
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("历史指标数据为空")
+	}
+
+	for i := range metrics {
+		if metrics[i].Timestamp <= 0 {
+			return nil, fmt.Errorf("历史指标缺少时间戳: itemId=%d", metrics[i].ItemID)
+		}
+	}
+
+	var resp MetricsResponse
+	err := c.doRequest(ctx, "POST", "/deviceMonitor/agent/metrics", metrics, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("发送历史指标失败: %v", err)
+	}
+
+	return &resp, nil
+}
+
 // sendBatchMetrics 批量发送指标数据
 func (c *DeviceMonitorClient) sendBatchMetrics(ctx context.Context, metricsData []map[string]interface{}) error {
 	var resp MetricsResponse
@@ -272,7 +335,12 @@ func (c *DeviceMonitorClient) IsAuthenticated() bool {
 }
 
 // doRequest 执行HTTP请求
-func (c *DeviceMonitorClient) doRequest(ctx context.Context, method, path string, reqBody, respBody interface{}) error {
+func (c *DeviceMonitorClient) doRequest(ctx context.Context, method, path string, reqBody, respBody interface{}, opts ...requestOption) error {
+	options := requestOptions{httpClient: c.httpClient}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	url := c.baseURL + path
 
 	var body *bytes.Buffer
@@ -300,13 +368,24 @@ func (c *DeviceMonitorClient) doRequest(ctx context.Context, method, path string
 	if c.token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
+	for key, value := range options.headers {
+		req.Header.Set(key, value)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := options.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if options.status != nil {
+		options.status.Code = resp.StatusCode
+		options.status.Header = resp.Header
+		switch resp.StatusCode {
+		case http.StatusNotModified, http.StatusNoContent, http.StatusNotFound:
+			return nil
+		}
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
 	}
@@ -320,6 +399,46 @@ func (c *DeviceMonitorClient) doRequest(ctx context.Context, method, path string
 	return nil
 }
 
+// requestOption doRequest的可选参数
+type requestOption func(*requestOptions)
+
+// requestOptions doRequest的可选参数集合
+type requestOptions struct {
+	httpClient *http.Client
+	headers    map[string]string
+	status     *responseStatus
+}
+
+// responseStatus 请求的响应状态码和响应头
+type responseStatus struct {
+	Code   int
+	Header http.Header
+}
+
+// withHeader 设置额外的请求头
+func withHeader(key, value string) requestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+		o.headers[key] = value
+	}
+}
+
+// withHTTPClient 使用指定的HTTP客户端发送请求，如长轮询使用不限时的客户端
+func withHTTPClient(httpClient *http.Client) requestOption {
+	return func(o *requestOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+// withStatus 记录响应状态码和响应头，304/204/404作为状态返回而不是错误，此时不解析响应体
+func withStatus(status *responseStatus) requestOption {
+	return func(o *requestOptions) {
+		o.status = status
+	}
+}
+
 // getLocalIP 获取本机IP地址
 func getLocalIP() (string, error) {
 	conn, err := net.Dial("udp", "8.8.8.8:80")
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 数据中心下发的任务类型
+const (
+	TaskTypeExecuteItem   = "execute_item"   // 立即采集指定监控项并返回值
+	TaskTypeRefreshConfig = "refresh_config" // 立即刷新采集配置
+	TaskTypeFlushMetrics  = "flush_metrics"  // 立即发送缓冲区中的指标
+)
+
+// 任务执行结果状态
+const (
+	TaskStatusSuccess  = "success"
+	TaskStatusFailed   = "failed"
+	TaskStatusRejected = "rejected" // 任务类型未允许或无法识别，未执行
+)
+
+// ErrTaskPollUnsupported 数据中心不支持任务轮询接口
+var ErrTaskPollUnsupported = errors.New("数据中心不支持任务轮询")
+
+// AgentTask 数据中心下发给agent的任务
+type AgentTask struct {
+	TaskID  string `json:"taskId"`
+	Type    string `json:"type"`
+	ItemID  int64  `json:"itemId,omitempty"`  // execute_item: 监控项ID，与ItemKey二选一
+	ItemKey string `json:"itemKey,omitempty"` // execute_item: 监控项key
+	Timeout int    `json:"timeout,omitempty"` // 执行超时（秒），为0时使用默认值
+	Send    bool   `json:"send,omitempty"`    // execute_item: 是否同时作为监控数据上报
+}
+
+// TaskResult 任务执行结果
+type TaskResult struct {
+	TaskID     string      `json:"taskId"`
+	AgentID    string      `json:"agentId"`
+	Status     string      `json:"status"`
+	Value      interface{} `json:"value,omitempty"`
+	InfoType   *InfoType   `json:"infoType,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Timestamp  int64       `json:"timestamp"`  // 完成时间（毫秒）
+	DurationMs int64       `json:"durationMs"` // 执行耗时（毫秒）
+}
+
+// TaskListResponse 任务轮询响应
+type TaskListResponse struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data []AgentTask `json:"data"`
+}
+
+// PollTasks 拉取待执行的任务，没有任务时返回空列表
+func (c *DeviceMonitorClient) PollTasks(ctx context.Context) ([]AgentTask, error) {
+	if c.agentID == "" {
+		return nil, fmt.Errorf("agentID为空，请先注册")
+	}
+
+	var resp TaskListResponse
+	path := fmt.Sprintf("/deviceMonitor/agent/tasks/%s", c.agentID)
+	var status responseStatus
+	if err := c.doRequest(ctx, "GET", path, nil, &resp, withStatus(&status)); err != nil {
+		return nil, fmt.Errorf("拉取任务失败: %v", err)
+	}
+
+	switch status.Code {
+	case http.StatusNotModified, http.StatusNoContent:
+		return nil, nil
+	case http.StatusNotFound:
+		return nil, ErrTaskPollUnsupported
+	}
+	if resp.Code != 200 {
+		return nil, fmt.Errorf("拉取任务响应异常: %s", resp.Msg)
+	}
+	return resp.Data, nil
+}
+
+// ReportTaskResult 上报任务执行结果
+func (c *DeviceMonitorClient) ReportTaskResult(ctx context.Context, result *TaskResult) error {
+	if c.agentID == "" {
+		return fmt.Errorf("agentID为空，请先注册")
+	}
+	result.AgentID = c.agentID
+
+	var resp MetricsResponse
+	path := fmt.Sprintf("/deviceMonitor/agent/tasks/%s/result", c.agentID)
+	if err := c.doRequest(ctx, "POST", path, result, &resp); err != nil {
+		return fmt.Errorf("上报任务结果失败: %v", err)
+	}
+	if resp.Code != 200 {
+		return fmt.Errorf("上报任务结果响应异常: %s", resp.Msg)
+	}
+	return nil
+}
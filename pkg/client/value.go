@@ -0,0 +1,299 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// InfoType 监控项值类型，与数据中心的infoType取值保持一致
+type InfoType int
+
+const (
+	InfoTypeFloat     InfoType = 0 // 数值（浮点）
+	InfoTypeCharacter InfoType = 1 // 字符
+	InfoTypeLog       InfoType = 2 // 日志
+	InfoTypeUnsigned  InfoType = 3 // 数值（无符号整数）
+	InfoTypeText      InfoType = 4 // 文本
+)
+
+// MaxCharacterLength 字符类型值的最大长度（按字符计）
+const MaxCharacterLength = 255
+
+// String 返回值类型名称
+func (t InfoType) String() string {
+	switch t {
+	case InfoTypeFloat:
+		return "float"
+	case InfoTypeCharacter:
+		return "character"
+	case InfoTypeLog:
+		return "log"
+	case InfoTypeUnsigned:
+		return "unsigned"
+	case InfoTypeText:
+		return "text"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// IsValid 检查值类型是否受支持
+func (t InfoType) IsValid() bool {
+	return t >= InfoTypeFloat && t <= InfoTypeText
+}
+
+// IsNumeric 检查是否为数值类型
+func (t InfoType) IsNumeric() bool {
+	return t == InfoTypeFloat || t == InfoTypeUnsigned
+}
+
+// ParseInfoType 解析值类型名称或数字
+func ParseInfoType(s string) (InfoType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "float", "numeric", "numeric_float", "0":
+		return InfoTypeFloat, nil
+	case "character", "char", "1":
+		return InfoTypeCharacter, nil
+	case "log", "2":
+		return InfoTypeLog, nil
+	case "unsigned", "uint", "numeric_unsigned", "3":
+		return InfoTypeUnsigned, nil
+	case "text", "4":
+		return InfoTypeText, nil
+	default:
+		return 0, fmt.Errorf("不支持的值类型: %s", s)
+	}
+}
+
+// TypedValue 已按值类型转换并校验的监控值
+type TypedValue struct {
+	Type     InfoType
+	Float    float64
+	Unsigned uint64
+	Text     string
+}
+
+// Interface 返回按值类型取值的原始Go值
+func (v *TypedValue) Interface() interface{} {
+	switch v.Type {
+	case InfoTypeFloat:
+		return v.Float
+	case InfoTypeUnsigned:
+		return v.Unsigned
+	default:
+		return v.Text
+	}
+}
+
+// String 返回值的字符串表示
+func (v *TypedValue) String() string {
+	switch v.Type {
+	case InfoTypeFloat:
+		return strconv.FormatFloat(v.Float, 'f', -1, 64)
+	case InfoTypeUnsigned:
+		return strconv.FormatUint(v.Unsigned, 10)
+	default:
+		return v.Text
+	}
+}
+
+// MarshalJSON 数值类型编码为JSON数字，其余类型编码为JSON字符串
+func (v *TypedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Interface())
+}
+
+// ConvertValue 将采集到的原始值按声明的值类型转换并校验
+func ConvertValue(raw interface{}, infoType InfoType) (*TypedValue, error) {
+	if tv, ok := raw.(*TypedValue); ok {
+		if tv.Type == infoType {
+			return tv, nil
+		}
+		raw = tv.Interface()
+	}
+
+	if raw == nil {
+		return nil, fmt.Errorf("值为空，无法转换为%s类型", infoType)
+	}
+
+	switch infoType {
+	case InfoTypeFloat:
+		f, err := toFloat(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &TypedValue{Type: infoType, Float: f}, nil
+	case InfoTypeUnsigned:
+		u, err := toUnsigned(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &TypedValue{Type: infoType, Unsigned: u}, nil
+	case InfoTypeCharacter:
+		text := toText(raw)
+		if n := utf8.RuneCountInString(text); n > MaxCharacterLength {
+			return nil, fmt.Errorf("字符值长度 %d 超过上限 %d", n, MaxCharacterLength)
+		}
+		return &TypedValue{Type: infoType, Text: text}, nil
+	case InfoTypeLog, InfoTypeText:
+		return &TypedValue{Type: infoType, Text: toText(raw)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的值类型: %d", int(infoType))
+	}
+}
+
+// toFloat 转换为浮点数
+func toFloat(raw interface{}) (float64, error) {
+	var f float64
+	switch v := raw.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case int, int8, int16, int32, int64:
+		f = float64(reflectInt(v))
+	case uint, uint8, uint16, uint32, uint64:
+		f = float64(reflectUint(v))
+	case bool:
+		if v {
+			f = 1
+		}
+	case string, []byte:
+		s := strings.TrimSpace(toText(v))
+		parsed, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法将值 %q 转换为浮点数", s)
+		}
+		f = parsed
+	default:
+		return 0, fmt.Errorf("无法将 %T 类型的值转换为浮点数", raw)
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("浮点数值无效: %v", f)
+	}
+	return f, nil
+}
+
+// toUnsigned 转换为无符号整数
+func toUnsigned(raw interface{}) (uint64, error) {
+	switch v := raw.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		return reflectUint(v), nil
+	case int, int8, int16, int32, int64:
+		n := reflectInt(v)
+		if n < 0 {
+			return 0, fmt.Errorf("无符号整数不能为负数: %d", n)
+		}
+		return uint64(n), nil
+	case float32, float64:
+		f, _ := toFloat(v)
+		if f < 0 {
+			return 0, fmt.Errorf("无符号整数不能为负数: %v", f)
+		}
+		// float64(math.MaxUint64) 会舍入为2^64，因此与2^64比较
+		if f != math.Trunc(f) || f >= math.Exp2(64) {
+			return 0, fmt.Errorf("无法将值 %v 转换为无符号整数", f)
+		}
+		return uint64(f), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string, []byte:
+		s := strings.TrimSpace(toText(v))
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法将值 %q 转换为无符号整数", s)
+		}
+		return u, nil
+	default:
+		return 0, fmt.Errorf("无法将 %T 类型的值转换为无符号整数", raw)
+	}
+}
+
+// reflectInt 提取有符号整数值
+func reflectInt(v interface{}) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	}
+	return 0
+}
+
+// reflectUint 提取无符号整数值
+func reflectUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint:
+		return uint64(n)
+	case uint8:
+		return uint64(n)
+	case uint16:
+		return uint64(n)
+	case uint32:
+		return uint64(n)
+	case uint64:
+		return n
+	}
+	return 0
+}
+
+// toText 转换为字符串
+func toText(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// InferInfoType 根据原始值推断值类型，用于未声明值类型的临时采集
+// 非负整数推断为无符号整数，其余数值为浮点；文本超过字符类型长度或包含换行时推断为文本
+func InferInfoType(raw interface{}) InfoType {
+	if tv, ok := raw.(*TypedValue); ok {
+		return tv.Type
+	}
+
+	switch v := raw.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		return InfoTypeUnsigned
+	case int, int8, int16, int32, int64:
+		if reflectInt(v) >= 0 {
+			return InfoTypeUnsigned
+		}
+		return InfoTypeFloat
+	case float32, float64, bool:
+		return InfoTypeFloat
+	}
+
+	text := strings.TrimSpace(toText(raw))
+	if _, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return InfoTypeUnsigned
+	}
+	if _, err := toFloat(text); err == nil {
+		return InfoTypeFloat
+	}
+	if strings.ContainsAny(text, "\r\n") || utf8.RuneCountInString(text) > MaxCharacterLength {
+		return InfoTypeText
+	}
+	return InfoTypeCharacter
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      interface{}
+		infoType InfoType
+		want     string
+		wantErr  bool
+	}{
+		{name: "int8转浮点", raw: int8(-5), infoType: InfoTypeFloat, want: "-5"},
+		{name: "int16转浮点", raw: int16(-300), infoType: InfoTypeFloat, want: "-300"},
+		{name: "uint8转浮点", raw: uint8(200), infoType: InfoTypeFloat, want: "200"},
+		{name: "uint16转浮点", raw: uint16(60000), infoType: InfoTypeFloat, want: "60000"},
+		{name: "字符串转浮点", raw: " 1.5 ", infoType: InfoTypeFloat, want: "1.5"},
+		{name: "非数值转浮点", raw: "abc", infoType: InfoTypeFloat, wantErr: true},
+		{name: "NaN转浮点", raw: math.NaN(), infoType: InfoTypeFloat, wantErr: true},
+		{name: "int8转无符号", raw: int8(7), infoType: InfoTypeUnsigned, want: "7"},
+		{name: "负int8转无符号", raw: int8(-1), infoType: InfoTypeUnsigned, wantErr: true},
+		{name: "int16转无符号", raw: int16(1000), infoType: InfoTypeUnsigned, want: "1000"},
+		{name: "负int16转无符号", raw: int16(-1000), infoType: InfoTypeUnsigned, wantErr: true},
+		{name: "uint8转无符号", raw: uint8(255), infoType: InfoTypeUnsigned, want: "255"},
+		{name: "uint16转无符号", raw: uint16(65535), infoType: InfoTypeUnsigned, want: "65535"},
+		{name: "小数转无符号", raw: 1.5, infoType: InfoTypeUnsigned, wantErr: true},
+		{name: "2^64转无符号", raw: math.Exp2(64), infoType: InfoTypeUnsigned, wantErr: true},
+		{name: "最大长度字符", raw: strings.Repeat("中", MaxCharacterLength), infoType: InfoTypeCharacter, want: strings.Repeat("中", MaxCharacterLength)},
+		{name: "超长字符", raw: strings.Repeat("a", MaxCharacterLength+1), infoType: InfoTypeCharacter, wantErr: true},
+		{name: "超长文本", raw: strings.Repeat("a", MaxCharacterLength+1), infoType: InfoTypeText, want: strings.Repeat("a", MaxCharacterLength+1)},
+		{name: "空值", raw: nil, infoType: InfoTypeText, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertValue(tt.raw, tt.infoType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ConvertValue(%v, %s) = %v, want error", tt.raw, tt.infoType, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertValue(%v, %s) error: %v", tt.raw, tt.infoType, err)
+			}
+			if got.Type != tt.infoType || got.String() != tt.want {
+				t.Errorf("ConvertValue(%v, %s) = %s (%s), want %s", tt.raw, tt.infoType, got, got.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferInfoTypeSmallIntegers(t *testing.T) {
+	tests := []struct {
+		raw  interface{}
+		want InfoType
+	}{
+		{int8(-1), InfoTypeFloat},
+		{int16(1), InfoTypeUnsigned},
+		{uint8(1), InfoTypeUnsigned},
+		{uint16(1), InfoTypeUnsigned},
+	}
+	for _, tt := range tests {
+		if got := InferInfoType(tt.raw); got != tt.want {
+			t.Errorf("InferInfoType(%T %v) = %s, want %s", tt.raw, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
 )
 
 // BuiltinKey 内置指标键
@@ -17,11 +19,17 @@ type BuiltinKey struct {
 	Units       string       `json:"units"`
 	Interval    int          `json:"interval"` // 默认采集间隔(秒)
 	Extractor   KeyExtractor `json:"-"`        // 数据提取函数
+
+	// ParamExtractor 带参数键（key为 name[*] 形式）的取值函数，参数来自采集时的 name[p1,p2] 形式key
+	ParamExtractor ParamKeyExtractor `json:"-"`
 }
 
 // KeyExtractor 键值提取函数
 type KeyExtractor func(metrics *SystemMetrics) interface{}
 
+// ParamKeyExtractor 带参数键的取值函数，直接采集参数指定的对象，不依赖系统指标
+type ParamKeyExtractor func(params []string) (interface{}, error)
+
 // BuiltinKeyManager 内置键管理器
 type BuiltinKeyManager struct {
 	keys map[string]*BuiltinKey
@@ -45,10 +53,21 @@ func (m *BuiltinKeyManager) GetAllKeys() []*BuiltinKey {
 	return keys
 }
 
-// GetKey 根据键名获取内置键
+// GetKey 根据键名获取内置键，依次匹配固定键和 name[*] 形式的带参数键
 func (m *BuiltinKeyManager) GetKey(keyName string) (*BuiltinKey, bool) {
-	key, exists := m.keys[keyName]
-	return key, exists
+	if key, exists := m.keys[keyName]; exists {
+		return key, true
+	}
+
+	name, _, ok := splitItemKey(keyName)
+	if !ok {
+		return nil, false
+	}
+	key, exists := m.keys[name+keyPatternSuffix]
+	if !exists || key.ParamExtractor == nil {
+		return nil, false
+	}
+	return key, true
 }
 
 // GetKeysByCategory 根据分类获取键
@@ -76,6 +95,17 @@ func (m *BuiltinKeyManager) ExtractValue(keyName string, metrics *SystemMetrics)
 	return key.Extractor(metrics), nil
 }
 
+// ExtractParamValue 解析 name[p1,p2] 形式key的参数并采集带参数键的值
+func (m *BuiltinKeyManager) ExtractParamValue(keyName string) (interface{}, error) {
+	key, exists := m.GetKey(keyName)
+	if !exists || key.ParamExtractor == nil {
+		return nil, fmt.Errorf("未找到带参数的内置键: %s", keyName)
+	}
+
+	_, params, _ := splitItemKey(keyName)
+	return key.ParamExtractor(params)
+}
+
 // initBuiltinKeys 初始化所有内置键
 func (m *BuiltinKeyManager) initBuiltinKeys() {
 	// CPU 指标
@@ -261,6 +291,19 @@ func (m *BuiltinKeyManager) addDiskKeys() {
 		},
 	}
 
+	// 指定挂载点的磁盘空间: vfs.fs.size[路径,模式]
+	m.addKey(&BuiltinKey{
+		Key:            "vfs.fs.size" + keyPatternSuffix,
+		Name:           "文件系统空间",
+		Type:           "builtin",
+		Category:       "disk",
+		Description:    "指定路径所在文件系统的空间，参数为 [路径,模式]，模式: total（默认）、free、used、pfree、pused",
+		ValueType:      "numeric",
+		Units:          "B",
+		Interval:       60,
+		ParamExtractor: fsSize,
+	})
+
 	for _, io := range ioKeys {
 		m.addKey(&BuiltinKey{
 			Key:         io.key,
@@ -359,6 +402,40 @@ func (m *BuiltinKeyManager) addHostKeys() {
 	}
 }
 
+// fsSize 采集 vfs.fs.size[路径,模式]：total、free、used 为字节数，pfree、pused 为百分比
+func fsSize(params []string) (interface{}, error) {
+	if len(params) == 0 || params[0] == "" {
+		return nil, fmt.Errorf("vfs.fs.size 缺少路径参数")
+	}
+	if len(params) > 2 {
+		return nil, fmt.Errorf("vfs.fs.size 参数过多: %d", len(params))
+	}
+	mode := "total"
+	if len(params) == 2 && params[1] != "" {
+		mode = strings.ToLower(params[1])
+	}
+
+	usage, err := disk.Usage(params[0])
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的文件系统空间失败: %v", params[0], err)
+	}
+
+	switch mode {
+	case "total":
+		return usage.Total, nil
+	case "free":
+		return usage.Free, nil
+	case "used":
+		return usage.Used, nil
+	case "pused":
+		return usage.UsedPercent, nil
+	case "pfree":
+		return 100 - usage.UsedPercent, nil
+	default:
+		return nil, fmt.Errorf("vfs.fs.size 不支持的模式: %s", params[1])
+	}
+}
+
 // getDiskSpaceKey 获取磁盘空间键名
 func getDiskSpaceKey(mode string) string {
 	if runtime.GOOS == "windows" {
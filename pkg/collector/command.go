@@ -2,64 +2,127 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"os/exec"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"go-agent/pkg/client"
-	"go-agent/pkg/services"
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // CommandConfig 命令配置结构
 type CommandConfig struct {
-	Type        string `mapstructure:"type"`
-	Command     string `mapstructure:"command"`
-	Host        string `mapstructure:"host"`
-	Port        int    `mapstructure:"port"`
-	Username    string `mapstructure:"username"`
-	Password    string `mapstructure:"password"`
-	Database    string `mapstructure:"database"`
-	Timeout     int    `mapstructure:"timeout"`
-	Description string `mapstructure:"description"`
+	Type          string `mapstructure:"type"`
+	Command       string `mapstructure:"command"`
+	Host          string `mapstructure:"host"`
+	Port          int    `mapstructure:"port"`
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+	Credential    string `mapstructure:"credential"` // 凭据库中的凭据名称，替代 username/password
+	Database      string `mapstructure:"database"`
+	TLS           string `mapstructure:"tls"`             // MySQL TLS: false, true, skip-verify, preferred
+	SSLMode       string `mapstructure:"sslmode"`         // PostgreSQL SSL: disable, require, verify-ca, verify-full
+	TLSCA         string `mapstructure:"tls_ca"`          // CA证书文件
+	TLSCert       string `mapstructure:"tls_cert"`        // 客户端证书文件
+	TLSKey        string `mapstructure:"tls_key"`         // 客户端私钥文件
+	TLSServerName string `mapstructure:"tls_server_name"` // 校验证书时使用的服务器名称，默认为host
+	Result        string `mapstructure:"result"`          // SQL结果处理方式: value, map, row, json
+	Column        string `mapstructure:"column"`          // value取值的列，map的值列
+	KeyColumn     string `mapstructure:"key_column"`      // map的名称列，默认第一列
+	Master        string `mapstructure:"master"`          // dependent类型: 提供JSON结果的主命令key
+	Path          string `mapstructure:"path"`            // dependent类型: JSON路径，[*]形式的key中 {1} 替换为key参数
+	CacheTTL      int    `mapstructure:"cache_ttl"`       // 结果缓存时间（秒），依赖项在此时间内共用一次执行结果
+	Timeout       int    `mapstructure:"timeout"`
+	Description   string `mapstructure:"description"`
+	Units         string `mapstructure:"units"`      // 单位，仅用于展示
+	ValueType     string `mapstructure:"value_type"` // 值类型: float, character, log, unsigned, text，仅用于展示和校验
+
+	// powershell、cmd、sh、bash、script类型的执行用户、环境变量和工作目录
+	Shell             string   `mapstructure:"shell"`               // sh/bash类型的解释器路径，默认 /bin/sh 或 /bin/bash
+	Env               []string `mapstructure:"env"`                 // 显式设置的环境变量，NAME=value 形式
+	EnvAllowlist      []string `mapstructure:"env_allowlist"`       // 从代理环境继承的变量名，默认使用settings.env_allowlist，* 表示全部
+	WorkDir           string   `mapstructure:"workdir"`             // 工作目录，默认为代理的工作目录
+	RunAs             string   `mapstructure:"run_as"`              // 以指定用户执行（用户名或uid，可加 :组名 或 :gid），仅Linux/Unix
+	AllowUnsafeScript bool     `mapstructure:"allow_unsafe_script"` // sh、bash、script类型: 允许执行所有用户可写、位于不安全目录或属于其他用户的脚本
+
+	// powershell、cmd、sh、bash、script类型判断执行结果的规则
+	SuccessCodes []int `mapstructure:"success_codes"`  // 视为成功的退出码，默认只有0
+	FailOnStderr bool  `mapstructure:"fail_on_stderr"` // stderr有输出时视为失败
+	MaxOutput    int   `mapstructure:"max_output"`     // stdout和stderr各自保留的最大字节数，默认使用settings.max_output
+
+	// powershell、cmd、sh、bash、script类型的资源限制和优先级，超时时结束整个进程组
+	CPULimit      int    `mapstructure:"cpu_limit"`       // CPU时间上限（秒），仅Linux/Unix
+	MemoryLimitMB int    `mapstructure:"memory_limit_mb"` // 虚拟内存上限（MB），仅Linux/Unix
+	Nice          int    `mapstructure:"nice"`            // nice值（-20到19），仅Linux/Unix
+	IONice        string `mapstructure:"ionice"`          // IO优先级: idle、best-effort[:0-7]、realtime[:0-7]，仅Linux
+}
+
+// execOptions 命令配置的执行用户、环境变量和工作目录
+func (c CommandConfig) execOptions(settings CommandSettings) execOptions {
+	allowlist := c.EnvAllowlist
+	if len(allowlist) == 0 {
+		allowlist = settings.EnvAllowlist
+	}
+	return execOptions{
+		runAs:     c.RunAs,
+		env:       c.Env,
+		allowlist: allowlist,
+		workDir:   c.WorkDir,
+	}
+}
+
+// processLimits 命令配置的资源限制
+func (c CommandConfig) processLimits() processLimits {
+	return processLimits{
+		cpuTime:  c.CPULimit,
+		memoryMB: c.MemoryLimitMB,
+		nice:     c.Nice,
+		ionice:   c.IONice,
+	}
 }
 
 // CommandSettings 全局设置
 type CommandSettings struct {
-	DefaultTimeout int  `mapstructure:"default_timeout"`
-	Enabled        bool `mapstructure:"enabled"`
-	RetryCount     int  `mapstructure:"retry_count"`
-	RetryInterval  int  `mapstructure:"retry_interval"`
-	MaxConcurrent  int  `mapstructure:"max_concurrent"`
+	DefaultTimeout int          `mapstructure:"default_timeout"`
+	Enabled        bool         `mapstructure:"enabled"`
+	RetryCount     int          `mapstructure:"retry_count"`
+	RetryInterval  int          `mapstructure:"retry_interval"`
+	MaxConcurrent  int          `mapstructure:"max_concurrent"`
+	MaxOutput      int          `mapstructure:"max_output"`    // 命令stdout和stderr各自保留的最大字节数，默认64KB
+	EnvAllowlist   []string     `mapstructure:"env_allowlist"` // 命令从代理环境继承的变量名，未配置时使用默认列表，* 表示全部
+	Pool           PoolSettings `mapstructure:"pool"`          // 数据库连接池设置
 }
 
 // CommandCollector 命令执行采集器
 type CommandCollector struct {
-	commands      map[string]CommandConfig
-	settings      CommandSettings
-	logger        *logrus.Logger
-	deviceClient  *client.DeviceMonitorClient
-	metricsSender *services.MetricsSender
-	semaphore     chan struct{}    // 并发控制
-	monitorItems  map[string]int64 // itemKey -> itemID 映射
-	mutex         sync.RWMutex
+	configPath   string
+	commands     map[string]CommandConfig
+	settings     CommandSettings
+	logger       *logrus.Logger
+	deviceClient *client.DeviceMonitorClient
+	semaphore    chan struct{}     // 并发控制
+	monitorItems map[string]int64  // itemKey -> itemID 映射
+	credentials  *credential.Vault // 凭据库，未配置时为空
+	dbPool       *sqlPool          // 数据库连接池，跨多次执行复用连接
+	results      *resultCache      // 设置了cache_ttl的命令结果缓存
+	mutex        sync.RWMutex
 }
 
-// NewCommandCollector 创建命令执行采集器
-func NewCommandCollector(configPath string, logger *logrus.Logger, deviceClient *client.DeviceMonitorClient, metricsSender *services.MetricsSender) (*CommandCollector, error) {
+// NewCommandCollector 创建命令执行采集器，采集结果由调度器按监控项的值类型转换和发送
+func NewCommandCollector(configPath string, logger *logrus.Logger, deviceClient *client.DeviceMonitorClient) (*CommandCollector, error) {
 	collector := &CommandCollector{
-		commands:      make(map[string]CommandConfig),
-		logger:        logger,
-		deviceClient:  deviceClient,
-		metricsSender: metricsSender,
-		monitorItems:  make(map[string]int64),
+		commands:     make(map[string]CommandConfig),
+		logger:       logger,
+		deviceClient: deviceClient,
+		monitorItems: make(map[string]int64),
+		dbPool:       newSQLPool(),
+		results:      newResultCache(),
 	}
 
 	// 加载配置
@@ -67,58 +130,106 @@ func NewCommandCollector(configPath string, logger *logrus.Logger, deviceClient
 		return nil, fmt.Errorf("加载命令映射配置失败: %v", err)
 	}
 
-	// 创建并发控制信号量
-	collector.semaphore = make(chan struct{}, collector.settings.MaxConcurrent)
-
 	return collector, nil
 }
 
-// loadConfig 加载配置文件
+// Reload 重新加载命令映射配置，加载失败时保留原有命令；正在执行的命令不受影响
+func (c *CommandCollector) Reload(configPath string) error {
+	if err := c.loadConfig(configPath); err != nil {
+		return fmt.Errorf("重新加载命令映射配置失败: %v", err)
+	}
+	return nil
+}
+
+// loadConfig 加载配置文件，有问题的命令会被跳过，其余命令正常加载
 func (c *CommandCollector) loadConfig(configPath string) error {
-	v := viper.New()
-	v.SetConfigFile(configPath)
-	v.SetConfigType("yaml")
-
-	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
-	// 加载命令配置
-	commandsMap := v.GetStringMap("commands")
-	for key := range commandsMap {
-		var config CommandConfig
-		if err := v.UnmarshalKey(fmt.Sprintf("commands.%s", key), &config); err != nil {
-			c.logger.Error("解析命令配置失败", map[string]interface{}{
-				"key":   key,
-				"error": err.Error(),
-			})
-			continue
-		}
-		c.commands[key] = config
+	mapping, err := LoadCommandMapping(configPath)
+	if err != nil {
+		return err
 	}
 
-	// 加载全局设置
-	if err := v.UnmarshalKey("settings", &c.settings); err != nil {
-		c.logger.Warn("解析全局设置失败，使用默认值", map[string]interface{}{
-			"error": err.Error(),
+	for _, problem := range mapping.Problems {
+		c.logger.Error("命令映射配置有误", map[string]interface{}{
+			"problem": problem.String(),
 		})
-		c.settings = CommandSettings{
-			DefaultTimeout: 30,
-			Enabled:        true,
-			RetryCount:     2,
-			RetryInterval:  5,
-			MaxConcurrent:  10,
+	}
+
+	commands := make(map[string]CommandConfig, len(mapping.Commands))
+	for key, command := range mapping.Commands {
+		if problems := validateCommand(configPath, key, command, mapping.Settings, 0); len(problems) > 0 {
+			for _, problem := range problems {
+				c.logger.Error("命令配置无效，已跳过", map[string]interface{}{
+					"problem": problem.String(),
+				})
+			}
+			continue
 		}
+		commands[key] = command
 	}
 
+	c.mutex.Lock()
+	c.configPath = configPath
+	c.commands = commands
+	// 并发数变化时替换信号量，执行中的命令仍释放到原信号量
+	if c.semaphore == nil || cap(c.semaphore) != mapping.Settings.MaxConcurrent {
+		c.semaphore = make(chan struct{}, mapping.Settings.MaxConcurrent)
+	}
+	c.settings = mapping.Settings
+	c.mutex.Unlock()
+
+	c.dbPool.Configure(mapping.Settings.Pool)
+	c.results.Reset()
+	resetMySQLTLS()
+
 	c.logger.Info("命令映射配置加载完成", map[string]interface{}{
-		"command_count": len(c.commands),
-		"enabled":       c.settings.Enabled,
+		"command_count": len(commands),
+		"enabled":       mapping.Settings.Enabled,
 	})
 
 	return nil
 }
 
+// SetCredentials 设置凭据库，执行引用凭据的命令时从中读取用户名和密码
+func (c *CommandCollector) SetCredentials(vault *credential.Vault) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.credentials = vault
+}
+
+// resolveCredential 将命令引用的凭据填入用户名和密码
+func (c *CommandCollector) resolveCredential(config CommandConfig) (CommandConfig, error) {
+	if config.Credential == "" {
+		return config, nil
+	}
+
+	c.mutex.RLock()
+	vault := c.credentials
+	c.mutex.RUnlock()
+	if vault == nil {
+		return config, fmt.Errorf("命令引用了凭据 %s，但未加载凭据库", config.Credential)
+	}
+
+	cred, exists := vault.Get(config.Credential)
+	if !exists {
+		return config, fmt.Errorf("凭据 %s 不存在", config.Credential)
+	}
+	config.Username = cred.Username
+	config.Password = cred.Password
+	return config, nil
+}
+
+// Validate 检查已加载的命令，返回超时超过监控项采集超时等问题
+func (c *CommandCollector) Validate(itemTimeout time.Duration) []config.Problem {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var problems []config.Problem
+	for key, command := range c.commands {
+		problems = append(problems, validateCommand(c.configPath, key, command, c.settings, itemTimeout)...)
+	}
+	return problems
+}
+
 // UpdateMonitorItems 更新监控项映射
 func (c *CommandCollector) UpdateMonitorItems(items []client.ConfigResponseData) {
 	c.mutex.Lock()
@@ -134,88 +245,52 @@ func (c *CommandCollector) UpdateMonitorItems(items []client.ConfigResponseData)
 	})
 }
 
-// Collect 执行采集
-func (c *CommandCollector) Collect(ctx context.Context) {
-	if !c.settings.Enabled {
-		c.logger.Debug("命令执行采集器已禁用")
-		return
-	}
-
-	c.mutex.RLock()
-	monitorItems := make(map[string]int64)
-	for k, v := range c.monitorItems {
-		monitorItems[k] = v
-	}
-	c.mutex.RUnlock()
-
-	if len(monitorItems) == 0 {
-		c.logger.Debug("没有可执行的监控项")
-		return
-	}
-
-	// 统计执行情况
-	totalItems := len(monitorItems)
-	executedCount := 0
-	skippedCount := 0
-
-	var wg sync.WaitGroup
-	for itemKey, itemID := range monitorItems {
-		// 检查是否有对应的命令配置
-		if cmdConfig, exists := c.commands[itemKey]; exists {
-			executedCount++
-			wg.Add(1)
-			go func(key string, id int64, config CommandConfig) {
-				defer wg.Done()
-				c.executeCommand(ctx, key, id, config)
-			}(itemKey, itemID, cmdConfig)
-		} else {
-			skippedCount++
-			c.logger.Debug("跳过未配置命令的监控项", map[string]interface{}{
-				"item_key": itemKey,
-				"item_id":  itemID,
-			})
-		}
+// ExecuteCommand 执行指定itemKey对应的命令并返回原始结果和采集时间（不发送）
+func (c *CommandCollector) ExecuteCommand(ctx context.Context, itemKey string) (interface{}, time.Time, error) {
+	config, exists := c.GetCommandConfig(itemKey)
+	if !exists {
+		return nil, time.Time{}, fmt.Errorf("未找到命令配置: %s", itemKey)
 	}
 
-	wg.Wait()
-	c.logger.Info("命令执行采集完成", map[string]interface{}{
-		"total_items":    totalItems,
-		"executed_count": executedCount,
-		"skipped_count":  skippedCount,
-	})
+	return c.execute(ctx, itemKey, config)
 }
 
-// executeCommand 执行单个命令
-func (c *CommandCollector) executeCommand(ctx context.Context, itemKey string, itemID int64, config CommandConfig) {
-	// 并发控制
-	select {
-	case c.semaphore <- struct{}{}:
-		defer func() { <-c.semaphore }()
-	case <-ctx.Done():
-		return
+// runWithRetry 按重试策略执行命令，采集时间为成功的那次执行开始的时间
+func (c *CommandCollector) runWithRetry(ctx context.Context, itemKey string, config CommandConfig) (interface{}, time.Time, error) {
+	config, err := c.resolveCredential(config)
+	if err != nil {
+		return nil, time.Time{}, err
 	}
 
+	c.mutex.RLock()
+	settings := c.settings
+	c.mutex.RUnlock()
+
 	timeout := time.Duration(config.Timeout) * time.Second
 	if config.Timeout == 0 {
-		timeout = time.Duration(c.settings.DefaultTimeout) * time.Second
+		timeout = time.Duration(settings.DefaultTimeout) * time.Second
 	}
 
 	var result interface{}
-	var err error
 
 	// 重试机制
-	for i := 0; i <= c.settings.RetryCount; i++ {
+	for i := 0; i <= settings.RetryCount; i++ {
+		startedAt := time.Now()
 		cmdCtx, cancel := context.WithTimeout(ctx, timeout)
 
 		switch strings.ToLower(config.Type) {
 		case "powershell":
-			result, err = c.executePowerShell(cmdCtx, config.Command)
+			result, err = c.executePowerShell(cmdCtx, config)
 		case "cmd":
-			result, err = c.executeCmd(cmdCtx, config.Command)
+			result, err = c.executeCmd(cmdCtx, config)
+		case "sh", "bash":
+			result, err = c.executeShell(cmdCtx, config)
 		case "mysql":
 			result, err = c.executeMySQL(cmdCtx, config)
+		case "postgres":
+			result, err = c.executePostgres(cmdCtx, config)
 		case "script":
-			result, err = c.executeScript(cmdCtx, config.Command)
+			result, err = c.executeScript(cmdCtx, config)
 		default:
 			err = fmt.Errorf("不支持的命令类型: %s", config.Type)
 		}
@@ -223,220 +298,168 @@ func (c *CommandCollector) executeCommand(ctx context.Context, itemKey string, i
 		cancel()
 
 		if err == nil {
-			break
+			return result, startedAt, nil
 		}
 
-		if i < c.settings.RetryCount {
+		if i < settings.RetryCount {
 			c.logger.Warn("命令执行失败，准备重试", map[string]interface{}{
 				"item_key":    itemKey,
-				"item_id":     itemID,
 				"error":       err.Error(),
 				"retry_count": i + 1,
-				"max_retries": c.settings.RetryCount,
+				"max_retries": settings.RetryCount,
 			})
-			time.Sleep(time.Duration(c.settings.RetryInterval) * time.Second)
+			select {
+			case <-ctx.Done():
+				return nil, time.Time{}, ctx.Err()
+			case <-time.After(time.Duration(settings.RetryInterval) * time.Second):
+			}
 		}
 	}
 
-	if err != nil {
-		c.logger.Error("命令执行失败", map[string]interface{}{
-			"item_key": itemKey,
-			"item_id":  itemID,
-			"error":    err.Error(),
-			"type":     config.Type,
-			"command":  config.Command,
-		})
-		return
-	}
-
-	// 发送指标数据
-	if c.metricsSender != nil {
-		err = c.metricsSender.SendMetricImmediate(ctx, itemID, result)
-		if err != nil {
-			c.logger.Error("发送指标数据失败", map[string]interface{}{
-				"item_key": itemKey,
-				"item_id":  itemID,
-				"value":    result,
-				"error":    err.Error(),
-			})
-		} else {
-			c.logger.Debug("命令执行并发送指标成功", map[string]interface{}{
-				"item_key": itemKey,
-				"item_id":  itemID,
-				"value":    result,
-			})
-		}
-	}
+	return nil, time.Time{}, err
 }
 
 // executePowerShell 执行PowerShell命令
-func (c *CommandCollector) executePowerShell(ctx context.Context, command string) (interface{}, error) {
-	cmd := exec.CommandContext(ctx, "powershell", "-Command", command)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("PowerShell执行失败: %v", err)
-	}
-
-	result := strings.TrimSpace(string(output))
-
-	// 尝试将结果转换为数字
-	if val, err := strconv.ParseFloat(result, 64); err == nil {
-		return val, nil
-	}
-	if val, err := strconv.ParseInt(result, 10, 64); err == nil {
-		return val, nil
-	}
-
-	return result, nil
+func (c *CommandCollector) executePowerShell(ctx context.Context, config CommandConfig) (interface{}, error) {
+	cmd := exec.CommandContext(ctx, "powershell", "-Command", config.Command)
+	return c.runProcess(ctx, cmd, "PowerShell", config)
 }
 
 // executeCmd 执行CMD命令
-func (c *CommandCollector) executeCmd(ctx context.Context, command string) (interface{}, error) {
-	cmd := exec.CommandContext(ctx, "cmd", "/C", command)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("CMD执行失败: %v", err)
-	}
-
-	result := strings.TrimSpace(string(output))
+func (c *CommandCollector) executeCmd(ctx context.Context, config CommandConfig) (interface{}, error) {
+	cmd := exec.CommandContext(ctx, "cmd", "/C", config.Command)
+	return c.runProcess(ctx, cmd, "CMD", config)
+}
 
-	// 尝试将结果转换为数字
-	if val, err := strconv.ParseFloat(result, 64); err == nil {
-		return val, nil
+// executeShell 通过 shell -c 执行sh/bash命令，命令以脚本文件开头时与script类型同样检查脚本权限
+func (c *CommandCollector) executeShell(ctx context.Context, config CommandConfig) (interface{}, error) {
+	commandType := strings.ToLower(config.Type)
+	shell := config.Shell
+	if shell == "" {
+		shell = defaultShells[commandType]
 	}
-	if val, err := strconv.ParseInt(result, 10, 64); err == nil {
-		return val, nil
+
+	if !config.AllowUnsafeScript {
+		if err := checkShellScript(config.Command, config.WorkDir); err != nil {
+			return nil, err
+		}
 	}
 
-	return result, nil
+	cmd := exec.CommandContext(ctx, shell, "-c", config.Command)
+	return c.runProcess(ctx, cmd, commandType, config)
 }
 
-// executeMySQL 执行MySQL查询
+// executeMySQL 执行MySQL查询，连接池按DSN复用
 func (c *CommandCollector) executeMySQL(ctx context.Context, config CommandConfig) (interface{}, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-		config.Username, config.Password, config.Host, config.Port, config.Database)
-
-	db, err := sql.Open("mysql", dsn)
+	dsn, err := mysqlDSN(config)
 	if err != nil {
-		return nil, fmt.Errorf("连接MySQL失败: %v", err)
+		return nil, fmt.Errorf("生成MySQL连接参数失败: %v", err)
 	}
-	defer db.Close()
-
-	db.SetMaxIdleConns(2)
-	db.SetMaxOpenConns(5)
-	db.SetConnMaxLifetime(time.Minute * 3)
 
-	if err = db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("MySQL连接检查失败: %v", err)
+	db, err := c.dbPool.Get(ctx, "mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("连接MySQL失败: %v", err)
 	}
 
 	rows, err := db.QueryContext(ctx, config.Command)
 	if err != nil {
+		// 下次执行前重新检查连接池
+		c.dbPool.Invalidate("mysql", dsn)
 		return nil, fmt.Errorf("MySQL查询失败: %v", err)
 	}
 	defer rows.Close()
 
-	// 获取列信息
-	columns, err := rows.Columns()
+	return shapeRows(rows, config)
+}
+
+// executePostgres 执行PostgreSQL查询，连接按DSN复用
+func (c *CommandCollector) executePostgres(ctx context.Context, config CommandConfig) (interface{}, error) {
+	dsn := postgresDSN(config)
+
+	db, err := c.dbPool.Get(ctx, "postgres", dsn)
 	if err != nil {
-		return nil, fmt.Errorf("获取列信息失败: %v", err)
+		return nil, fmt.Errorf("连接PostgreSQL失败: %v", err)
 	}
 
-	if len(columns) == 0 {
-		return nil, fmt.Errorf("查询结果为空")
+	rows, err := db.QueryContext(ctx, config.Command)
+	if err != nil {
+		// 下次执行前重新检查连接池
+		c.dbPool.Invalidate("postgres", dsn)
+		return nil, fmt.Errorf("PostgreSQL查询失败: %v", err)
 	}
+	defer rows.Close()
 
-	// 读取第一行数据
-	if rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
+	return shapeRows(rows, config)
+}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("扫描结果失败: %v", err)
+// executeScript 执行脚本文件，未设置allow_unsafe_script时拒绝所有用户可写、位于不安全目录或属于其他用户的脚本
+func (c *CommandCollector) executeScript(ctx context.Context, config CommandConfig) (interface{}, error) {
+	cmd := exec.CommandContext(ctx, config.Command)
+	if !config.AllowUnsafeScript && cmd.Err == nil {
+		// 相对路径相对于工作目录执行
+		if err := checkScriptFile(resolvePath(cmd.Path, config.WorkDir)); err != nil {
+			return nil, err
 		}
+	}
+	return c.runProcess(ctx, cmd, "脚本", config)
+}
 
-		// 如果只有一列，直接返回值
-		if len(values) == 1 {
-			val := values[0]
-			if val == nil {
-				return 0, nil
-			}
-
-			// 尝试转换为数字
-			switch v := val.(type) {
-			case []byte:
-				str := string(v)
-				if num, err := strconv.ParseFloat(str, 64); err == nil {
-					return num, nil
-				}
-				if num, err := strconv.ParseInt(str, 10, 64); err == nil {
-					return num, nil
-				}
-				return str, nil
-			case string:
-				if num, err := strconv.ParseFloat(v, 64); err == nil {
-					return num, nil
-				}
-				if num, err := strconv.ParseInt(v, 10, 64); err == nil {
-					return num, nil
-				}
-				return v, nil
-			case int64:
-				return v, nil
-			case float64:
-				return v, nil
-			default:
-				return fmt.Sprintf("%v", v), nil
-			}
-		}
+// runProcess 按配置的执行用户、环境变量和工作目录，以及成功退出码、stderr和max_output规则执行子进程，返回去掉首尾空白的stdout
+func (c *CommandCollector) runProcess(ctx context.Context, cmd *exec.Cmd, label string, config CommandConfig) (interface{}, error) {
+	c.mutex.RLock()
+	settings := c.settings
+	c.mutex.RUnlock()
 
-		// 多列结果返回第一列的值
-		val := values[0]
-		if val == nil {
-			return 0, nil
-		}
-		return fmt.Sprintf("%v", val), nil
+	if err := config.execOptions(settings).apply(cmd); err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("查询结果为空")
-}
-
-// executeScript 执行脚本文件
-func (c *CommandCollector) executeScript(ctx context.Context, scriptPath string) (interface{}, error) {
-	cmd := exec.CommandContext(ctx, scriptPath)
-	output, err := cmd.Output()
+	result, err := runExec(ctx, cmd, label, newExecRules(config, settings))
 	if err != nil {
-		return nil, fmt.Errorf("脚本执行失败: %v", err)
+		return nil, err
+	}
+	if result.Truncated {
+		c.logger.Warn("命令输出超过max_output，已截断", map[string]interface{}{
+			"type":      config.Type,
+			"command":   config.Command,
+			"exit_code": result.ExitCode,
+		})
 	}
 
-	result := strings.TrimSpace(string(output))
+	// 返回原始输出，由监控项的值类型决定如何转换
+	return strings.TrimSpace(result.Stdout), nil
+}
 
-	// 尝试将结果转换为数字
-	if val, err := strconv.ParseFloat(result, 64); err == nil {
-		return val, nil
-	}
-	if val, err := strconv.ParseInt(result, 10, 64); err == nil {
-		return val, nil
-	}
+// Close 关闭数据库连接池，停止调度器时调用
+func (c *CommandCollector) Close() {
+	c.dbPool.Close()
+}
 
-	return result, nil
+// currentSemaphore 获取当前的并发控制信号量
+func (c *CommandCollector) currentSemaphore() chan struct{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.semaphore
 }
 
 // GetCommandCount 获取命令总数
 func (c *CommandCollector) GetCommandCount() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return len(c.commands)
 }
 
 // GetEnabledStatus 获取启用状态
 func (c *CommandCollector) GetEnabledStatus() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.settings.Enabled
 }
 
 // ListCommands 列出所有命令
 func (c *CommandCollector) ListCommands() map[string]string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	result := make(map[string]string)
 	for key, config := range c.commands {
 		result[key] = config.Description
@@ -446,6 +469,8 @@ func (c *CommandCollector) ListCommands() map[string]string {
 
 // GetSupportedItemKeys 获取所有支持的itemKey列表
 func (c *CommandCollector) GetSupportedItemKeys() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	keys := make([]string, 0, len(c.commands))
 	for key := range c.commands {
 		keys = append(keys, key)
@@ -455,14 +480,17 @@ func (c *CommandCollector) GetSupportedItemKeys() []string {
 
 // HasCommand 检查是否支持指定的itemKey
 func (c *CommandCollector) HasCommand(itemKey string) bool {
-	_, exists := c.commands[itemKey]
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	_, exists := c.lookupCommand(itemKey)
 	return exists
 }
 
 // GetCommandConfig 获取指定itemKey的命令配置
 func (c *CommandCollector) GetCommandConfig(itemKey string) (CommandConfig, bool) {
-	config, exists := c.commands[itemKey]
-	return config, exists
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.lookupCommand(itemKey)
 }
 
 // GetActiveMonitorItems 获取当前激活的监控项
@@ -0,0 +1,342 @@
+package collector
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+
+	"github.com/spf13/viper"
+)
+
+// 命令映射支持的命令类型
+var commandTypes = map[string]bool{
+	"powershell": true,
+	"cmd":        true,
+	"sh":         true,
+	"bash":       true,
+	"mysql":      true,
+	"postgres":   true,
+	"script":     true,
+	"dependent":  true,
+}
+
+// defaultShells sh/bash类型未配置shell时使用的解释器
+var defaultShells = map[string]string{
+	"sh":   "/bin/sh",
+	"bash": "/bin/bash",
+}
+
+// CommandMapping 命令映射配置文件（command_mapping.yaml）的内容
+type CommandMapping struct {
+	Path     string
+	Commands map[string]CommandConfig
+	Settings CommandSettings
+	Problems []config.Problem // 解析阶段发现的问题，无法解析或含未知字段的命令已被跳过
+}
+
+// LoadCommandMapping 严格读取命令映射配置文件：含未知字段或无法解析的命令被跳过并记录在Problems中
+func LoadCommandMapping(configPath string) (*CommandMapping, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	mapping := &CommandMapping{Path: configPath, Commands: make(map[string]CommandConfig)}
+	problems := &config.Problems{File: configPath}
+
+	for key := range v.AllSettings() {
+		if key != "commands" && key != "settings" {
+			problems.Add(key, "未知的配置项")
+		}
+	}
+
+	// 加载命令配置
+	commandsMap := v.GetStringMap("commands")
+	for key, raw := range commandsMap {
+		path := fmt.Sprintf("commands.%s", key)
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			problems.Add(path, "命令配置必须是对象")
+			continue
+		}
+		if unknown := config.UnknownKeys(fields, CommandConfig{}, path); len(unknown) > 0 {
+			for _, field := range unknown {
+				problems.Add(field, "未知的配置项，已跳过该命令")
+			}
+			continue
+		}
+
+		var command CommandConfig
+		if err := v.UnmarshalKey(path, &command); err != nil {
+			problems.Add(path, "解析失败，已跳过该命令: %v", err)
+			continue
+		}
+
+		// 替换 ${ENV_VAR} 和 ${file:/path} 引用，密码等敏感值登记到日志脱敏
+		expandProblems := &config.Problems{File: configPath}
+		config.ExpandStrings(&command, path, expandProblems)
+		if expanded := expandProblems.List(); len(expanded) > 0 {
+			for _, problem := range expanded {
+				problems.Add(problem.Key, "%s，已跳过该命令", problem.Message)
+			}
+			continue
+		}
+		mapping.Commands[key] = command
+	}
+
+	// 加载全局设置
+	if settings, ok := v.Get("settings").(map[string]interface{}); ok {
+		for _, field := range config.UnknownKeys(settings, CommandSettings{}, "settings") {
+			problems.Add(field, "未知的配置项")
+		}
+	}
+	if err := v.UnmarshalKey("settings", &mapping.Settings); err != nil {
+		problems.Add("settings", "解析失败，使用默认值: %v", err)
+		mapping.Settings = CommandSettings{
+			DefaultTimeout: 30,
+			Enabled:        true,
+			RetryCount:     2,
+			RetryInterval:  5,
+			MaxConcurrent:  10,
+		}
+	}
+
+	mapping.Problems = problems.List()
+	return mapping, nil
+}
+
+// Keys 返回排序后的全部itemKey
+func (m *CommandMapping) Keys() []string {
+	keys := make([]string, 0, len(m.Commands))
+	for key := range m.Commands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate 检查命令映射配置并返回全部问题（含解析阶段的问题），不执行任何命令
+// itemTimeout大于0时检查命令超时是否超过监控项的采集超时
+func (m *CommandMapping) Validate(itemTimeout time.Duration) []config.Problem {
+	problems := &config.Problems{File: m.Path}
+
+	if m.Settings.Enabled && m.Settings.MaxConcurrent <= 0 {
+		problems.Add("settings.max_concurrent", "必须大于0")
+	}
+	if m.Settings.DefaultTimeout < 0 {
+		problems.Add("settings.default_timeout", "不能为负数")
+	}
+	if m.Settings.RetryCount < 0 {
+		problems.Add("settings.retry_count", "不能为负数")
+	}
+	if m.Settings.RetryInterval < 0 {
+		problems.Add("settings.retry_interval", "不能为负数")
+	}
+	if m.Settings.MaxOutput < 0 {
+		problems.Add("settings.max_output", "不能为负数")
+	}
+	if err := (execOptions{allowlist: m.Settings.EnvAllowlist}).check(); err != nil {
+		problems.Add("settings.env_allowlist", "%v", err)
+	}
+	pool := m.Settings.Pool
+	for key, value := range map[string]int{
+		"max_open_conns":        pool.MaxOpenConns,
+		"max_idle_conns":        pool.MaxIdleConns,
+		"conn_max_lifetime":     pool.ConnMaxLifetime,
+		"conn_max_idle_time":    pool.ConnMaxIdleTime,
+		"health_check_interval": pool.HealthCheckInterval,
+		"idle_timeout":          pool.IdleTimeout,
+	} {
+		if value < 0 {
+			problems.Add("settings.pool."+key, "不能为负数")
+		}
+	}
+
+	// 检查依赖项引用的主命令
+	for _, key := range m.Keys() {
+		command := m.Commands[key]
+		if !strings.EqualFold(command.Type, CommandTypeDependent) || command.Master == "" {
+			continue
+		}
+		path := fmt.Sprintf("commands.%s.master", key)
+		master, exists := m.Commands[strings.ToLower(command.Master)]
+		switch {
+		case !exists:
+			problems.Add(path, "主命令 %s 不存在", command.Master)
+		case strings.EqualFold(master.Type, CommandTypeDependent):
+			problems.Add(path, "主命令 %s 不能是dependent类型", command.Master)
+		case master.CacheTTL == 0:
+			problems.Warn(path, "主命令 %s 未设置cache_ttl，每个依赖项都会单独执行一次主命令", command.Master)
+		}
+	}
+
+	result := append([]config.Problem(nil), m.Problems...)
+	result = append(result, problems.List()...)
+	for _, key := range m.Keys() {
+		result = append(result, validateCommand(m.Path, key, m.Commands[key], m.Settings, itemTimeout)...)
+	}
+	return result
+}
+
+// CheckCredentials 检查命令引用的凭据是否存在于凭据库中，vault为空表示未加载凭据库
+func (m *CommandMapping) CheckCredentials(vault *credential.Vault) []config.Problem {
+	problems := &config.Problems{File: m.Path}
+	for _, key := range m.Keys() {
+		name := m.Commands[key].Credential
+		if name == "" {
+			continue
+		}
+		path := fmt.Sprintf("commands.%s.credential", key)
+		if vault == nil {
+			problems.Add(path, "引用了凭据 %s，但未加载凭据库", name)
+		} else if _, exists := vault.Get(name); !exists {
+			problems.Add(path, "凭据 %s 不存在于 %s", name, vault.Path())
+		}
+	}
+	return problems.List()
+}
+
+// validateCommand 检查单个命令配置
+func validateCommand(file, key string, command CommandConfig, settings CommandSettings, itemTimeout time.Duration) []config.Problem {
+	problems := &config.Problems{File: file}
+	path := fmt.Sprintf("commands.%s", key)
+
+	commandType := strings.ToLower(command.Type)
+	if !commandTypes[commandType] {
+		problems.Add(path+".type", "不支持的命令类型 %q", command.Type)
+	}
+	if strings.HasSuffix(key, keyPatternSuffix) && commandType != CommandTypeDependent {
+		problems.Add(path, "%s 形式的key只支持dependent类型", keyPatternSuffix)
+	}
+	if commandType == CommandTypeDependent {
+		if command.Master == "" {
+			problems.Add(path+".master", "dependent类型必须配置master")
+		}
+		if _, err := ParseJSONPath(command.Path); err != nil {
+			problems.Add(path+".path", "%v", err)
+		}
+	} else if strings.TrimSpace(command.Command) == "" {
+		problems.Add(path+".command", "不能为空")
+	}
+	if !resultModes[strings.ToLower(command.Result)] {
+		problems.Add(path+".result", "不支持的结果处理方式 %q，可选 value, map, row, json", command.Result)
+	}
+	if command.CacheTTL < 0 {
+		problems.Add(path+".cache_ttl", "不能为负数")
+	}
+	if commandType == "mysql" {
+		if command.Host == "" {
+			problems.Add(path+".host", "mysql类型必须配置host")
+		}
+		if command.Port < 0 || command.Port > 65535 {
+			problems.Add(path+".port", "必须在0-65535范围内")
+		}
+		if !mysqlTLSModes[strings.ToLower(command.TLS)] {
+			problems.Add(path+".tls", "不支持的TLS模式 %q，可选 false, true, skip-verify, preferred", command.TLS)
+		} else if command.usesCustomTLS() && strings.EqualFold(command.TLS, "false") {
+			problems.Add(path+".tls", "已配置证书，不能设置为false")
+		}
+		if (command.TLSCert == "") != (command.TLSKey == "") {
+			problems.Add(path+".tls_cert", "tls_cert 和 tls_key 必须同时配置")
+		}
+		if command.SSLMode != "" {
+			problems.Add(path+".sslmode", "只适用于postgres类型，mysql类型使用tls")
+		}
+	}
+	if commandType == "postgres" {
+		if command.Host == "" {
+			problems.Add(path+".host", "postgres类型必须配置host")
+		}
+		if command.Port < 0 || command.Port > 65535 {
+			problems.Add(path+".port", "必须在0-65535范围内")
+		}
+		if !postgresSSLModes[strings.ToLower(command.SSLMode)] {
+			problems.Add(path+".sslmode", "不支持的SSL模式 %q，可选 disable, require, verify-ca, verify-full", command.SSLMode)
+		}
+		if command.TLS != "" {
+			problems.Add(path+".tls", "只适用于mysql类型，postgres类型使用sslmode")
+		}
+		if command.TLSServerName != "" {
+			problems.Add(path+".tls_server_name", "postgres类型不支持，verify-full按host校验证书")
+		}
+		if (command.TLSCert == "") != (command.TLSKey == "") {
+			problems.Add(path+".tls_cert", "tls_cert 和 tls_key 必须同时配置")
+		}
+	}
+	if _, isShell := defaultShells[commandType]; isShell {
+		if command.Shell != "" && !filepath.IsAbs(command.Shell) {
+			problems.Add(path+".shell", "必须是绝对路径")
+		}
+	} else if command.Shell != "" {
+		problems.Add(path+".shell", "只适用于sh、bash类型")
+	}
+	if _, isShell := defaultShells[commandType]; command.AllowUnsafeScript && !isShell && commandType != "script" {
+		problems.Add(path+".allow_unsafe_script", "只适用于sh、bash、script类型")
+	}
+	if execTypes[commandType] {
+		if command.MaxOutput < 0 {
+			problems.Add(path+".max_output", "不能为负数")
+		}
+		if err := command.processLimits().check(); err != nil {
+			problems.Add(path, "%v", err)
+		}
+		if err := command.execOptions(settings).check(); err != nil {
+			problems.Add(path, "%v", err)
+		} else if err := checkExecHelper(command.RunAs, command.processLimits()); err != nil {
+			problems.Add(path+".run_as", "%v", err)
+		}
+	} else {
+		for field, value := range map[string]bool{
+			"env":             len(command.Env) > 0,
+			"env_allowlist":   len(command.EnvAllowlist) > 0,
+			"workdir":         command.WorkDir != "",
+			"run_as":          command.RunAs != "",
+			"success_codes":   len(command.SuccessCodes) > 0,
+			"fail_on_stderr":  command.FailOnStderr,
+			"max_output":      command.MaxOutput != 0,
+			"cpu_limit":       command.CPULimit != 0,
+			"memory_limit_mb": command.MemoryLimitMB != 0,
+			"nice":            command.Nice != 0,
+			"ionice":          command.IONice != "",
+		} {
+			if value {
+				problems.Add(path+"."+field, "只适用于powershell、cmd、sh、bash、script类型")
+			}
+		}
+	}
+	if command.Credential != "" {
+		if err := credential.ValidateName(command.Credential); err != nil {
+			problems.Add(path+".credential", "%v", err)
+		}
+		if command.Username != "" || command.Password != "" {
+			problems.Add(path+".credential", "不能与 username/password 同时设置")
+		}
+	}
+	if command.ValueType != "" {
+		if _, err := client.ParseInfoType(command.ValueType); err != nil {
+			problems.Add(path+".value_type", "%v", err)
+		}
+	}
+
+	if command.Timeout < 0 {
+		problems.Add(path+".timeout", "不能为负数")
+	} else if itemTimeout > 0 && commandType != CommandTypeDependent {
+		timeout := command.Timeout
+		if timeout == 0 {
+			timeout = settings.DefaultTimeout
+		}
+		if commandTimeout := time.Duration(timeout) * time.Second; commandTimeout > itemTimeout {
+			problems.Warn(path+".timeout", "命令超时 %s 超过默认采集超时 agent.timeout=%s，未单独设置超时的监控项会在命令完成前被取消", commandTimeout, itemTimeout)
+		}
+	}
+	return problems.List()
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// writeMappingFile 在临时目录中创建命令映射配置文件
+func writeMappingFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "command_mapping.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 一次报告配置文件中的全部问题，加载时跳过有问题的命令，其余命令正常加载
+func TestCommandMappingReportsAllProblems(t *testing.T) {
+	path := writeMappingFile(t, `
+commands:
+  good:
+    type: sh
+    command: "echo 1"
+    cache_ttl: 30
+  typo:
+    type: sh
+    command: "echo 1"
+    timout: 5
+  bad_type:
+    type: perl
+    command: "print 1"
+  no_command:
+    type: sh
+  bad_path:
+    type: dependent
+    master: good
+    path: "$.a[0"
+  unset_env:
+    type: sh
+    command: "echo ${GOAGENT_TEST_UNSET_VAR}"
+  negative:
+    type: sh
+    command: "echo 1"
+    cache_ttl: -1
+    timeout: -1
+settings:
+  enabled: true
+  max_concurrent: 2
+  retry_cont: 1
+extra: true
+`)
+
+	mapping, err := LoadCommandMapping(path)
+	if err != nil {
+		t.Fatalf("LoadCommandMapping error: %v", err)
+	}
+
+	var got []string
+	for _, problem := range mapping.Validate(0) {
+		if problem.File != path {
+			t.Errorf("problem %s has file %q, want %q", problem.Key, problem.File, path)
+		}
+		got = append(got, problem.Key)
+	}
+	sort.Strings(got)
+	want := []string{
+		"commands.bad_path.path",
+		"commands.bad_type.type",
+		"commands.negative.cache_ttl",
+		"commands.negative.timeout",
+		"commands.no_command.command",
+		"commands.typo.timout",
+		"commands.unset_env.command",
+		"extra",
+		"settings.retry_cont",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("problems = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("problems = %q, want %q", got, want)
+			break
+		}
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	collector, err := NewCommandCollector(path, logger, nil)
+	if err != nil {
+		t.Fatalf("NewCommandCollector error: %v", err)
+	}
+	if keys := collector.GetSupportedItemKeys(); len(keys) != 1 || keys[0] != "good" {
+		t.Errorf("loaded commands = %q, want only good", keys)
+	}
+}
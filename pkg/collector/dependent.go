@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandTypeDependent 依赖项：不单独执行，从主命令的JSON结果中按路径取值
+const CommandTypeDependent = "dependent"
+
+// keyPatternSuffix 参数化key的后缀，如 mysql.status[*] 匹配 mysql.status[Threads_connected]
+const keyPatternSuffix = "[*]"
+
+// cachedResult 一个命令的缓存结果，mu同时保证同一命令同一时间只执行一次
+type cachedResult struct {
+	mu          sync.Mutex
+	value       interface{}
+	collectedAt time.Time
+	expires     time.Time
+}
+
+// resultCache 设置了cache_ttl的命令结果缓存
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResult
+}
+
+// newResultCache 创建结果缓存
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*cachedResult)}
+}
+
+// entry 获取命令对应的缓存项，不存在时创建
+func (r *resultCache) entry(itemKey string) *cachedResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[itemKey]
+	if !exists {
+		entry = &cachedResult{}
+		r.entries[itemKey] = entry
+	}
+	return entry
+}
+
+// Reset 清空缓存，重新加载命令映射后调用
+func (r *resultCache) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = make(map[string]*cachedResult)
+}
+
+// execute 执行命令：依赖项从主命令结果中取值，其余命令在并发限制下执行（设置了cache_ttl时使用缓存）
+func (c *CommandCollector) execute(ctx context.Context, itemKey string, config CommandConfig) (interface{}, time.Time, error) {
+	if strings.EqualFold(config.Type, CommandTypeDependent) {
+		return c.executeDependent(ctx, config)
+	}
+	return c.executeCached(ctx, itemKey, config)
+}
+
+// executeCached 缓存有效时直接返回上次结果，否则执行命令并缓存
+func (c *CommandCollector) executeCached(ctx context.Context, itemKey string, config CommandConfig) (interface{}, time.Time, error) {
+	if config.CacheTTL <= 0 {
+		return c.executeLimited(ctx, itemKey, config)
+	}
+
+	entry := c.results.entry(itemKey)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if time.Now().Before(entry.expires) {
+		return entry.value, entry.collectedAt, nil
+	}
+
+	value, collectedAt, err := c.executeLimited(ctx, itemKey, config)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	entry.value = value
+	entry.collectedAt = collectedAt
+	entry.expires = time.Now().Add(time.Duration(config.CacheTTL) * time.Second)
+	return value, collectedAt, nil
+}
+
+// executeLimited 在并发限制下按重试策略执行命令
+func (c *CommandCollector) executeLimited(ctx context.Context, itemKey string, config CommandConfig) (interface{}, time.Time, error) {
+	semaphore := c.currentSemaphore()
+	select {
+	case semaphore <- struct{}{}:
+		defer func() { <-semaphore }()
+	case <-ctx.Done():
+		return nil, time.Time{}, ctx.Err()
+	}
+
+	return c.runWithRetry(ctx, itemKey, config)
+}
+
+// executeDependent 执行（或复用缓存的）主命令，按JSON路径从结果中提取值，采集时间为主命令的采集时间
+func (c *CommandCollector) executeDependent(ctx context.Context, config CommandConfig) (interface{}, time.Time, error) {
+	master, exists := c.GetCommandConfig(config.Master)
+	if !exists {
+		return nil, time.Time{}, fmt.Errorf("主命令 %s 不存在", config.Master)
+	}
+	if strings.EqualFold(master.Type, CommandTypeDependent) {
+		return nil, time.Time{}, fmt.Errorf("主命令 %s 不能是dependent类型", config.Master)
+	}
+
+	path, err := ParseJSONPath(config.Path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	result, collectedAt, err := c.executeCached(ctx, config.Master, master)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("执行主命令 %s 失败: %w", config.Master, err)
+	}
+
+	text, ok := result.(string)
+	if !ok {
+		text = fmt.Sprintf("%v", result)
+	}
+	value, err := ExtractJSONPath(text, path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("从主命令 %s 的结果中提取 %s 失败: %v", config.Master, config.Path, err)
+	}
+	return value, collectedAt, nil
+}
+
+// lookupCommand 查找itemKey对应的命令，调用方需持有读锁
+// 依次匹配原始key、小写key（viper读取配置时key会转换为小写）和 name[*] 形式的参数化key
+func (c *CommandCollector) lookupCommand(itemKey string) (CommandConfig, bool) {
+	if config, exists := c.commands[itemKey]; exists {
+		return config, true
+	}
+	if config, exists := c.commands[strings.ToLower(itemKey)]; exists {
+		return config, true
+	}
+
+	name, params, ok := splitItemKey(itemKey)
+	if !ok {
+		return CommandConfig{}, false
+	}
+	config, exists := c.commands[strings.ToLower(name)+keyPatternSuffix]
+	if !exists {
+		return CommandConfig{}, false
+	}
+	config.Path = expandKeyParams(config.Path, params)
+	return config, true
+}
+
+// splitItemKey 拆分 name[p1,p2] 形式的key，参数可以用双引号包含逗号
+func splitItemKey(itemKey string) (string, []string, bool) {
+	start := strings.IndexByte(itemKey, '[')
+	if start <= 0 || !strings.HasSuffix(itemKey, "]") {
+		return itemKey, nil, false
+	}
+
+	var params []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range itemKey[start+1 : len(itemKey)-1] {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			params = append(params, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	params = append(params, strings.TrimSpace(current.String()))
+	return itemKey[:start], params, true
+}
+
+// expandKeyParams 将 {1}..{n} 替换为key参数
+func expandKeyParams(text string, params []string) string {
+	for i := len(params); i >= 1; i-- {
+		text = strings.ReplaceAll(text, "{"+strconv.Itoa(i)+"}", params[i-1])
+	}
+	return text
+}
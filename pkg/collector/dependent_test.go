@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitItemKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		wantName   string
+		wantParams []string
+		wantOK     bool
+	}{
+		{key: "mysql.status[Threads_connected]", wantName: "mysql.status", wantParams: []string{"Threads_connected"}, wantOK: true},
+		{key: `name["a,b",c]`, wantName: "name", wantParams: []string{"a,b", "c"}, wantOK: true},
+		{key: "name[a, b ,]", wantName: "name", wantParams: []string{"a", "b", ""}, wantOK: true},
+		{key: "name[]", wantName: "name", wantParams: []string{""}, wantOK: true},
+		{key: "system.uptime", wantName: "system.uptime"},
+		{key: "[a]", wantName: "[a]"},
+		{key: "name[a", wantName: "name[a"},
+	}
+	for _, tt := range tests {
+		name, params, ok := splitItemKey(tt.key)
+		if name != tt.wantName || ok != tt.wantOK || !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("splitItemKey(%q) = %q, %q, %v, want %q, %q, %v", tt.key, name, params, ok, tt.wantName, tt.wantParams, tt.wantOK)
+		}
+	}
+}
+
+func TestExpandKeyParams(t *testing.T) {
+	params := make([]string, 11)
+	for i := range params {
+		params[i] = string(rune('a' + i))
+	}
+	// {11}需要先于{1}替换
+	if got, want := expandKeyParams("$.{1}.{11}.{12}", params), "$.a.k.{12}"; got != want {
+		t.Errorf("expandKeyParams = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,339 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxOutput 未配置max_output时stdout和stderr各自保留的最大字节数
+const defaultMaxOutput = 64 * 1024
+
+// stderrExcerptLength 错误信息中保留的stderr最大长度
+const stderrExcerptLength = 200
+
+// processWaitDelay 结束进程组后等待输出管道关闭的最长时间，脱离进程组的后代进程仍持有管道时不再等待
+const processWaitDelay = 2 * time.Second
+
+// ExecHelperArg 代理以该参数重新执行自身时，设置资源限制和优先级后再执行目标程序
+const ExecHelperArg = "__exec"
+
+// ioniceClasses ionice调度类别，与ioprio_set的IOPRIO_CLASS_*一致
+var ioniceClasses = map[string]int{
+	"realtime":    1,
+	"best-effort": 2,
+	"idle":        3,
+}
+
+// execTypes 以子进程方式执行的命令类型，支持 success_codes、fail_on_stderr 和 max_output
+var execTypes = map[string]bool{
+	"powershell": true,
+	"cmd":        true,
+	"sh":         true,
+	"bash":       true,
+	"script":     true,
+}
+
+// ExecResult 子进程的执行结果
+type ExecResult struct {
+	Stdout    string
+	Stderr    string
+	ExitCode  int // 未能启动或被终止时为-1
+	Duration  time.Duration
+	Truncated bool // stdout或stderr超过max_output被截断
+}
+
+// ExecError 命令执行失败，保留执行结果供上报监控项错误状态
+type ExecError struct {
+	Label  string // 命令类型，如 PowerShell、sh
+	Reason string
+	Result *ExecResult
+}
+
+// Error 实现error接口，包含退出码、耗时和stderr摘要
+func (e *ExecError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s执行失败: %s (退出码: %d, 耗时: %s", e.Label, e.Reason, e.Result.ExitCode, e.Result.Duration.Round(time.Millisecond))
+	if e.Result.Truncated {
+		b.WriteString(", 输出已截断")
+	}
+	b.WriteString(")")
+	if stderr := strings.TrimSpace(e.Result.Stderr); stderr != "" {
+		if len(stderr) > stderrExcerptLength {
+			stderr = stderr[:stderrExcerptLength] + "..."
+		}
+		fmt.Fprintf(&b, ": %s", stderr)
+	}
+	return b.String()
+}
+
+// AsExecError 从错误链中取出命令执行失败的详细结果
+func AsExecError(err error) (*ExecError, bool) {
+	var execErr *ExecError
+	ok := errors.As(err, &execErr)
+	return execErr, ok
+}
+
+// defaultEnvAllowlist 未配置env_allowlist时从代理环境传递给子进程的变量，其余变量（如凭据、代理配置）不会传递
+var defaultEnvAllowlist = []string{
+	"PATH", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "TMPDIR", "HOME", "USER", "LOGNAME",
+	// Windows下PowerShell和CMD运行所需的变量
+	"SystemRoot", "SystemDrive", "windir", "ComSpec", "PATHEXT", "TEMP", "TMP",
+	"USERPROFILE", "ProgramData", "ProgramFiles", "PSModulePath",
+}
+
+// execOptions 子进程的执行用户、环境变量和工作目录
+type execOptions struct {
+	runAs     string
+	env       []string // 显式设置的 NAME=value，优先于继承的变量
+	allowlist []string // 从代理环境继承的变量名，为空时使用默认列表，* 表示全部
+	workDir   string
+}
+
+// check 检查环境变量、允许列表和执行用户的配置
+func (o execOptions) check() error {
+	for _, entry := range o.env {
+		if name, _, ok := strings.Cut(entry, "="); !ok || name == "" {
+			return fmt.Errorf("env %q 格式必须为 NAME=value", entry)
+		}
+	}
+	for _, name := range o.allowlist {
+		if name == "" || strings.Contains(name, "=") {
+			return fmt.Errorf("env_allowlist 中的 %q 不是有效的变量名", name)
+		}
+	}
+	if o.runAs != "" {
+		return checkRunAs(o.runAs)
+	}
+	return nil
+}
+
+// apply 设置子进程的工作目录、环境变量和执行用户
+func (o execOptions) apply(cmd *exec.Cmd) error {
+	cmd.Dir = o.workDir
+
+	userEnv, err := runAsEnv(o.runAs)
+	if err != nil {
+		return err
+	}
+	// exec对重复的变量保留最后一个值，显式设置的变量优先
+	env := filterEnv(os.Environ(), o.allowlist)
+	env = append(env, userEnv...)
+	cmd.Env = append(env, o.env...)
+
+	if o.runAs != "" {
+		return setRunAs(cmd, o.runAs)
+	}
+	return nil
+}
+
+// filterEnv 返回变量名在允许列表中的环境变量，允许列表为空时使用默认列表；变量名不区分大小写以兼容Windows
+func filterEnv(environ []string, allowlist []string) []string {
+	if len(allowlist) == 0 {
+		allowlist = defaultEnvAllowlist
+	}
+	if slices.Contains(allowlist, "*") {
+		return environ
+	}
+
+	var env []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, allowed := range allowlist {
+			if strings.EqualFold(name, allowed) {
+				env = append(env, entry)
+				break
+			}
+		}
+	}
+	return env
+}
+
+// processLimits 子进程的资源限制和优先级，零值表示不限制
+type processLimits struct {
+	cpuTime  int    // CPU时间上限（秒），RLIMIT_CPU
+	memoryMB int    // 虚拟内存上限（MB），RLIMIT_AS
+	nice     int    // nice值，-20到19
+	ionice   string // IO优先级，类别[:级别]，如 idle、best-effort:7
+}
+
+// empty 是否未设置任何限制
+func (l processLimits) empty() bool {
+	return l == processLimits{}
+}
+
+// helperArgs 转换为 __exec 的参数：CPU时间 内存 nice ionice
+func (l processLimits) helperArgs() []string {
+	ionice := l.ionice
+	if ionice == "" {
+		ionice = "-"
+	}
+	return []string{strconv.Itoa(l.cpuTime), strconv.Itoa(l.memoryMB), strconv.Itoa(l.nice), ionice}
+}
+
+// parseHelperLimits 解析 __exec 的限制参数
+func parseHelperLimits(args []string) (processLimits, error) {
+	var limits processLimits
+	if len(args) != 4 {
+		return limits, fmt.Errorf("资源限制参数个数错误")
+	}
+	var err error
+	if limits.cpuTime, err = strconv.Atoi(args[0]); err != nil {
+		return limits, fmt.Errorf("无效的CPU时间: %v", err)
+	}
+	if limits.memoryMB, err = strconv.Atoi(args[1]); err != nil {
+		return limits, fmt.Errorf("无效的内存上限: %v", err)
+	}
+	if limits.nice, err = strconv.Atoi(args[2]); err != nil {
+		return limits, fmt.Errorf("无效的nice值: %v", err)
+	}
+	if args[3] != "-" {
+		limits.ionice = args[3]
+	}
+	return limits, nil
+}
+
+// parseIONice 解析ionice配置（类别[:级别]），返回类别和级别；best-effort和realtime默认级别为4
+func parseIONice(value string) (int, int, error) {
+	name, levelText, hasLevel := strings.Cut(strings.ToLower(value), ":")
+	class, exists := ioniceClasses[name]
+	if !exists {
+		return 0, 0, fmt.Errorf("不支持的ionice类别 %q，可选 realtime, best-effort, idle", name)
+	}
+	level := 4
+	if class == ioniceClasses["idle"] {
+		level = 0
+	}
+	if hasLevel {
+		var err error
+		if level, err = strconv.Atoi(levelText); err != nil || level < 0 || level > 7 {
+			return 0, 0, fmt.Errorf("ionice级别必须是0-7")
+		}
+		if class == ioniceClasses["idle"] {
+			return 0, 0, fmt.Errorf("idle类别不支持指定级别")
+		}
+	}
+	return class, level, nil
+}
+
+// check 检查资源限制的取值范围和当前平台是否支持
+func (l processLimits) check() error {
+	switch {
+	case l.cpuTime < 0:
+		return fmt.Errorf("cpu_limit不能为负数")
+	case l.memoryMB < 0:
+		return fmt.Errorf("memory_limit_mb不能为负数")
+	case l.nice < -20 || l.nice > 19:
+		return fmt.Errorf("nice必须在-20到19之间")
+	}
+	if l.ionice != "" {
+		if _, _, err := parseIONice(l.ionice); err != nil {
+			return err
+		}
+	}
+	return checkPlatformLimits(l)
+}
+
+// execRules 判断执行是否成功的规则，以及子进程的资源限制
+type execRules struct {
+	successCodes []int // 视为成功的退出码，为空时只有0
+	failOnStderr bool  // stderr有输出时视为失败
+	maxOutput    int   // stdout和stderr各自保留的最大字节数
+	limits       processLimits
+}
+
+// newExecRules 按命令配置和全局设置生成执行规则
+func newExecRules(config CommandConfig, settings CommandSettings) execRules {
+	rules := execRules{
+		successCodes: config.SuccessCodes,
+		failOnStderr: config.FailOnStderr,
+		maxOutput:    config.MaxOutput,
+		limits:       config.processLimits(),
+	}
+	if rules.maxOutput == 0 {
+		rules.maxOutput = settings.MaxOutput
+	}
+	if rules.maxOutput == 0 {
+		rules.maxOutput = defaultMaxOutput
+	}
+	return rules
+}
+
+// limitedBuffer 只保留前limit个字节的输出缓冲区，超出部分丢弃并标记截断
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write 实现io.Writer，始终返回完整长度以免子进程因管道写入失败而退出
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); len(p) > remaining {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// runExec 在独立的进程组中执行子进程并按规则判断结果，超时或取消时结束整个进程组，失败时返回 *ExecError
+func runExec(ctx context.Context, cmd *exec.Cmd, label string, rules execRules) (*ExecResult, error) {
+	if err := prepareProcess(cmd, rules.limits); err != nil {
+		result := &ExecResult{ExitCode: -1}
+		return result, &ExecError{Label: label, Reason: err.Error(), Result: result}
+	}
+
+	stdout := &limitedBuffer{limit: rules.maxOutput}
+	stderr := &limitedBuffer{limit: rules.maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &ExecResult{
+		Stdout:    stdout.buf.String(),
+		Stderr:    stderr.buf.String(),
+		ExitCode:  -1,
+		Duration:  time.Since(start),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return result, &ExecError{Label: label, Reason: "执行超时", Result: result}
+	case ctx.Err() != nil:
+		return result, &ExecError{Label: label, Reason: "执行被取消", Result: result}
+	case err != nil && !errors.As(err, &exitErr):
+		return result, &ExecError{Label: label, Reason: err.Error(), Result: result}
+	case result.ExitCode == -1 && cmd.ProcessState != nil:
+		// 被信号终止，如超出cpu_limit
+		return result, &ExecError{Label: label, Reason: cmd.ProcessState.String(), Result: result}
+	case !rules.succeeded(result.ExitCode):
+		return result, &ExecError{Label: label, Reason: "非成功退出码", Result: result}
+	case rules.failOnStderr && strings.TrimSpace(result.Stderr) != "":
+		return result, &ExecError{Label: label, Reason: "stderr有输出", Result: result}
+	}
+	return result, nil
+}
+
+// succeeded 退出码是否视为成功
+func (r execRules) succeeded(exitCode int) bool {
+	if len(r.successCodes) == 0 {
+		return exitCode == 0
+	}
+	return slices.Contains(r.successCodes, exitCode)
+}
@@ -0,0 +1,299 @@
+//go:build !windows
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// runAsCredential 解析run_as（用户名或uid，可加 :组名 或 :gid），未指定组时使用用户的主组和附加组
+func runAsCredential(runAs string) (*syscall.Credential, error) {
+	name, group, hasGroup := strings.Cut(runAs, ":")
+	if name == "" || (hasGroup && group == "") {
+		return nil, fmt.Errorf("无效的run_as %q，格式为 用户[:组]", runAs)
+	}
+
+	var uid, gid uint64
+	var groups []uint32
+	u, err := lookupUser(name)
+	if err != nil {
+		return nil, err
+	}
+	if u != nil {
+		uid, _ = strconv.ParseUint(u.Uid, 10, 32)
+		gid, _ = strconv.ParseUint(u.Gid, 10, 32)
+		if ids, err := u.GroupIds(); err == nil {
+			for _, id := range ids {
+				if value, err := strconv.ParseUint(id, 10, 32); err == nil {
+					groups = append(groups, uint32(value))
+				}
+			}
+		}
+	} else {
+		// 本机不存在的数字uid，组默认与uid相同
+		uid, _ = strconv.ParseUint(name, 10, 32)
+		gid = uid
+	}
+
+	if hasGroup {
+		if gid, err = lookupGroupID(group); err != nil {
+			return nil, err
+		}
+		groups = nil
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}, nil
+}
+
+// lookupUser 按用户名或uid查找用户，数字uid在本机不存在时返回nil
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		u, err := user.LookupId(name)
+		if err != nil {
+			return nil, nil
+		}
+		return u, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("查找用户 %s 失败: %v", name, err)
+	}
+	return u, nil
+}
+
+// lookupGroupID 按组名或gid返回gid
+func lookupGroupID(group string) (uint64, error) {
+	if gid, err := strconv.ParseUint(group, 10, 32); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("查找组 %s 失败: %v", group, err)
+	}
+	return strconv.ParseUint(g.Gid, 10, 32)
+}
+
+// checkRunAs 检查run_as指定的用户和组是否存在
+func checkRunAs(runAs string) error {
+	_, err := runAsCredential(runAs)
+	return err
+}
+
+// setRunAs 设置命令以run_as指定的用户执行，与代理当前用户相同时不切换
+func setRunAs(cmd *exec.Cmd, runAs string) error {
+	credential, err := runAsCredential(runAs)
+	if err != nil {
+		return err
+	}
+	if int(credential.Uid) == os.Getuid() && int(credential.Gid) == os.Getgid() {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("以 %s 执行需要代理以root运行", runAs)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = credential
+	return nil
+}
+
+// checkExecHelper 配置了资源限制且以其他用户执行时，__exec 以run_as用户的身份执行代理程序本身，
+// 检查该用户能否进入代理程序所在的各级目录并执行代理程序，否则命令会以退出码126失败
+func checkExecHelper(runAs string, limits processLimits) error {
+	if runAs == "" || limits.empty() {
+		return nil
+	}
+	credential, err := runAsCredential(runAs)
+	if err != nil {
+		return err
+	}
+	if int(credential.Uid) == os.Getuid() && int(credential.Gid) == os.Getgid() {
+		return nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取代理程序路径失败: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+	if err := checkExecutableBy(self, credential); err != nil {
+		return fmt.Errorf("配置了资源限制时以 %s 执行需要该用户能执行代理程序: %v", runAs, err)
+	}
+	return nil
+}
+
+// checkExecutableBy 按权限位检查credential指定的用户能否进入path的各级父目录并执行path（不考虑ACL）
+func checkExecutableBy(path string, credential *syscall.Credential) error {
+	if credential.Uid == 0 {
+		return nil
+	}
+
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !permittedTo(info, credential, 0o1) {
+			return fmt.Errorf("目录 %s (%s) 对该用户不可进入", dir, info.Mode().Perm())
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !permittedTo(info, credential, 0o1) {
+		return fmt.Errorf("%s (%s) 对该用户不可执行", path, info.Mode().Perm())
+	}
+	return nil
+}
+
+// permittedTo 按文件所有者、所属组和其他用户的权限位判断credential是否具有bit（4读、2写、1执行）权限
+func permittedTo(info os.FileInfo, credential *syscall.Credential, bit os.FileMode) bool {
+	perm := info.Mode().Perm()
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return perm&bit != 0
+	}
+	if stat.Uid == credential.Uid {
+		return perm&(bit<<6) != 0
+	}
+	inGroup := stat.Gid == credential.Gid
+	for _, gid := range credential.Groups {
+		inGroup = inGroup || stat.Gid == gid
+	}
+	if inGroup {
+		return perm&(bit<<3) != 0
+	}
+	return perm&bit != 0
+}
+
+// runAsEnv 返回run_as用户的HOME、USER和LOGNAME，本机不存在的数字uid不设置
+func runAsEnv(runAs string) ([]string, error) {
+	if runAs == "" {
+		return nil, nil
+	}
+	name, _, _ := strings.Cut(runAs, ":")
+	u, err := lookupUser(name)
+	if err != nil || u == nil {
+		return nil, err
+	}
+	return []string{"HOME=" + u.HomeDir, "USER=" + u.Username, "LOGNAME=" + u.Username}, nil
+}
+
+// checkScriptFile 拒绝所有用户可写、或不属于root和代理运行用户的脚本，以及所在目录所有用户可写且未设置粘滞位的脚本
+// （其他用户可删除后替换该脚本），防止其他用户借代理的权限执行代码
+func checkScriptFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o002 != 0 {
+		return fmt.Errorf("脚本 %s 对所有用户可写 (%s)，拒绝执行", path, info.Mode().Perm())
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("脚本 %s 属于其他用户 (uid %d)，拒绝执行", path, stat.Uid)
+	}
+
+	dir := filepath.Dir(path)
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if dirInfo.Mode().Perm()&0o002 != 0 && dirInfo.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("脚本所在目录 %s 对所有用户可写且未设置粘滞位 (%s)，拒绝执行", dir, dirInfo.Mode())
+	}
+	return nil
+}
+
+// checkPlatformLimits Linux/Unix支持全部资源限制，ionice仅Linux支持
+func checkPlatformLimits(limits processLimits) error {
+	if limits.ionice != "" && !ioPrioritySupported {
+		return fmt.Errorf("当前系统不支持ionice")
+	}
+	return nil
+}
+
+// prepareProcess 设置子进程为新进程组的组长，超时或取消时向整个进程组发送SIGKILL；
+// 配置了资源限制时改为执行代理自身的 __exec，由其设置限制后再exec目标程序
+func prepareProcess(cmd *exec.Cmd, limits processLimits) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	if !limits.empty() {
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("获取代理程序路径失败: %v", err)
+		}
+		args := append([]string{self, ExecHelperArg}, limits.helperArgs()...)
+		args = append(args, cmd.Path)
+		cmd.Args = append(args, cmd.Args...)
+		cmd.Path = self
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = processWaitDelay
+	return nil
+}
+
+// RunExecHelper 处理 go-agent __exec <CPU时间> <内存MB> <nice> <ionice> <程序> <argv...>：
+// 设置资源限制和优先级后exec目标程序，成功时不返回
+func RunExecHelper(args []string) error {
+	if len(args) < 6 {
+		return fmt.Errorf("用法: %s <CPU时间> <内存MB> <nice> <ionice> <程序> <参数...>", ExecHelperArg)
+	}
+	limits, err := parseHelperLimits(args[:4])
+	if err != nil {
+		return err
+	}
+
+	if limits.cpuTime > 0 {
+		if err := setRlimit(syscall.RLIMIT_CPU, uint64(limits.cpuTime)); err != nil {
+			return fmt.Errorf("设置CPU时间上限失败: %v", err)
+		}
+	}
+	if limits.memoryMB > 0 {
+		if err := setRlimit(syscall.RLIMIT_AS, uint64(limits.memoryMB)*1024*1024); err != nil {
+			return fmt.Errorf("设置内存上限失败: %v", err)
+		}
+	}
+	if limits.nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, limits.nice); err != nil {
+			return fmt.Errorf("设置nice值失败: %v", err)
+		}
+	}
+	if limits.ionice != "" {
+		class, level, err := parseIONice(limits.ionice)
+		if err != nil {
+			return err
+		}
+		if err := setIOPriority(class, level); err != nil {
+			return fmt.Errorf("设置ionice失败: %v", err)
+		}
+	}
+
+	return syscall.Exec(args[4], args[5:], os.Environ())
+}
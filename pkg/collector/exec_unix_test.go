@@ -0,0 +1,279 @@
+//go:build !windows
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// writeScript 在dir下创建脚本文件并设置权限
+func writeScript(t *testing.T, dir, name string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho 1\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// chmodDir 设置目录权限（包括粘滞位），测试结束时恢复
+func chmodDir(t *testing.T, dir string, mode os.FileMode) {
+	t.Helper()
+	if err := os.Chmod(dir, mode); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0o700) })
+}
+
+func TestCheckScriptFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		dirMode  os.FileMode
+		fileMode os.FileMode
+		wantErr  string
+	}{
+		{name: "安全", dirMode: 0o755, fileMode: 0o755},
+		{name: "脚本所有用户可写", dirMode: 0o755, fileMode: 0o777, wantErr: "对所有用户可写"},
+		{name: "目录所有用户可写", dirMode: 0o777, fileMode: 0o755, wantErr: "未设置粘滞位"},
+		{name: "目录设置了粘滞位", dirMode: 0o777 | os.ModeSticky, fileMode: 0o755},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			script := writeScript(t, dir, "check.sh", tt.fileMode)
+			chmodDir(t, dir, tt.dirMode)
+
+			err := checkScriptFile(script)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkScriptFile error = %v, want nil", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkScriptFile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckShellScript(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "unsafe.sh", 0o777)
+	writeScript(t, dir, "safe.sh", 0o755)
+
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{command: filepath.Join(dir, "unsafe.sh") + " arg", wantErr: true},
+		{command: "./unsafe.sh", wantErr: true},
+		{command: "sh -e unsafe.sh", wantErr: true},
+		{command: "bash " + filepath.Join(dir, "unsafe.sh"), wantErr: true},
+		{command: filepath.Join(dir, "safe.sh")},
+		{command: "sh safe.sh"},
+		{command: "echo unsafe.sh"},
+		{command: "cat /proc/loadavg | awk '{print $1}'"},
+		{command: "./missing.sh"},
+		{command: ""},
+	}
+	for _, tt := range tests {
+		err := checkShellScript(tt.command, dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkShellScript(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+		}
+	}
+}
+
+// 采集时间为成功的那次执行开始的时间，不受命令耗时和失败重试的影响
+func TestRunWithRetryCollectedAt(t *testing.T) {
+	c := &CommandCollector{
+		logger:   logrus.New(),
+		settings: CommandSettings{DefaultTimeout: 5, RetryCount: 1},
+	}
+	// 第一次执行失败，重试时耗时300毫秒后成功
+	config := CommandConfig{
+		Type:    "sh",
+		Command: "if [ -f marker ]; then sleep 0.3; echo 1; else touch marker; sleep 0.2; exit 1; fi",
+		WorkDir: t.TempDir(),
+	}
+
+	before := time.Now()
+	result, collectedAt, err := c.runWithRetry(context.Background(), "delayed", config)
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("runWithRetry error: %v", err)
+	}
+	if result != "1" {
+		t.Errorf("result = %v, want 1", result)
+	}
+	if collectedAt.Before(before.Add(200 * time.Millisecond)) {
+		t.Errorf("collectedAt %v is before the successful attempt started", collectedAt.Sub(before))
+	}
+	if collectedAt.After(after.Add(-300 * time.Millisecond)) {
+		t.Errorf("collectedAt %v includes the command duration (total %v)", collectedAt.Sub(before), after.Sub(before))
+	}
+}
+
+// 设置了cache_ttl时，同时采集的多个依赖项只执行一次主命令
+func TestDependentItemsShareCachedMaster(t *testing.T) {
+	dir := t.TempDir()
+	commands := map[string]CommandConfig{
+		"app.stats": {
+			Type:     "sh",
+			Command:  `echo run >> count; sleep 0.1; echo '{"a":1,"b":{"c":"x"},"d":[3]}'`,
+			WorkDir:  dir,
+			CacheTTL: 60,
+		},
+		"app.a":    {Type: CommandTypeDependent, Master: "app.stats", Path: "$.a"},
+		"app.c":    {Type: CommandTypeDependent, Master: "app.stats", Path: "$.b.c"},
+		"app.d":    {Type: CommandTypeDependent, Master: "app.stats", Path: "$.d[0]"},
+		"app.f[*]": {Type: CommandTypeDependent, Master: "app.stats", Path: "$.{1}"},
+	}
+	c := &CommandCollector{
+		commands:  commands,
+		logger:    logrus.New(),
+		settings:  CommandSettings{DefaultTimeout: 5},
+		semaphore: make(chan struct{}, 4),
+		results:   newResultCache(),
+	}
+
+	keys := []string{"app.a", "app.c", "app.d", "app.f[a]", "app.a", "app.c"}
+	want := map[string]interface{}{"app.a": 1.0, "app.c": "x", "app.d": 3.0, "app.f[a]": 1.0}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			config, exists := c.GetCommandConfig(key)
+			if !exists {
+				t.Errorf("GetCommandConfig(%q) not found", key)
+				return
+			}
+			value, _, err := c.execute(context.Background(), key, config)
+			if err != nil {
+				t.Errorf("execute(%q) error: %v", key, err)
+				return
+			}
+			if value != want[key] {
+				t.Errorf("execute(%q) = %v, want %v", key, value, want[key])
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, "count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("master command ran %d times, want 1", runs)
+	}
+}
+
+// run_as用户需要能进入代理程序所在的各级目录并执行代理程序，__exec 才能设置资源限制
+func TestCheckExecutableBy(t *testing.T) {
+	other := &syscall.Credential{Uid: 54321, Gid: 54321}
+	if err := checkExecutableBy(os.TempDir(), other); err != nil {
+		t.Skipf("临时目录对其他用户不可进入: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		dirMode  os.FileMode
+		fileMode os.FileMode
+		cred     *syscall.Credential
+		wantErr  string
+	}{
+		{name: "其他用户可执行", dirMode: 0o755, fileMode: 0o755, cred: other},
+		{name: "目录0700", dirMode: 0o700, fileMode: 0o755, cred: other, wantErr: "不可进入"},
+		{name: "程序0700", dirMode: 0o755, fileMode: 0o700, cred: other, wantErr: "不可执行"},
+		{name: "同组可执行", dirMode: 0o750, fileMode: 0o750, cred: &syscall.Credential{Uid: 54321, Gid: 54321, Groups: []uint32{uint32(os.Getgid())}}},
+		{name: "root不受限制", dirMode: 0o700, fileMode: 0o700, cred: &syscall.Credential{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			chmodDir(t, root, 0o755)
+			dir := filepath.Join(root, "bin")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			program := writeScript(t, dir, "go-agent", tt.fileMode)
+			chmodDir(t, dir, tt.dirMode)
+			// t.TempDir 的上级目录权限为0700
+			if err := os.Chmod(filepath.Dir(root), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := checkExecutableBy(program, tt.cred)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkExecutableBy error = %v, want nil", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkExecutableBy error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunExecKillsProcessGroupOnTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 60 & echo $! > "+pidFile+"; sleep 60")
+	start := time.Now()
+	if _, err := runExec(ctx, cmd, "sleep", execRules{maxOutput: 1024}); err == nil {
+		t.Fatal("超时的命令应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("命令超时后 %v 才返回", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 后台子进程被重新挂到init下，回收前可能短暂处于僵尸状态
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("超时后后台子进程 %d 仍在运行", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// processAlive 进程是否存在且不是僵尸进程
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// 格式为 pid (comm) state ...，comm可能包含空格
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
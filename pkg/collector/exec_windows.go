@@ -0,0 +1,75 @@
+//go:build windows
+
+package collector
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// checkRunAs Windows不支持run_as
+func checkRunAs(runAs string) error {
+	return fmt.Errorf("Windows不支持run_as")
+}
+
+// setRunAs Windows不支持以其他用户执行命令
+func setRunAs(cmd *exec.Cmd, runAs string) error {
+	return fmt.Errorf("Windows不支持run_as")
+}
+
+// checkExecHelper Windows不支持run_as和资源限制，由checkRunAs和checkPlatformLimits报告
+func checkExecHelper(runAs string, limits processLimits) error {
+	return nil
+}
+
+// runAsEnv Windows不支持run_as
+func runAsEnv(runAs string) ([]string, error) {
+	if runAs != "" {
+		return nil, fmt.Errorf("Windows不支持run_as")
+	}
+	return nil, nil
+}
+
+// checkScriptFile Windows的脚本权限由ACL控制，不做检查
+func checkScriptFile(path string) error {
+	return nil
+}
+
+// checkPlatformLimits Windows不支持资源限制和优先级设置
+func checkPlatformLimits(limits processLimits) error {
+	if !limits.empty() {
+		return fmt.Errorf("Windows不支持cpu_limit、memory_limit_mb、nice和ionice")
+	}
+	return nil
+}
+
+// prepareProcess 在新的进程组中启动子进程，超时或取消时用taskkill结束整个进程树
+func prepareProcess(cmd *exec.Cmd, limits processLimits) error {
+	if err := checkPlatformLimits(limits); err != nil {
+		return err
+	}
+	if cmd.Err != nil {
+		return nil
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = processWaitDelay
+	return nil
+}
+
+// RunExecHelper Windows不支持 __exec
+func RunExecHelper(args []string) error {
+	return fmt.Errorf("Windows不支持%s", ExecHelperArg)
+}
@@ -0,0 +1,17 @@
+package collector
+
+import "syscall"
+
+// ioPrioritySupported 当前系统是否支持ionice
+const ioPrioritySupported = true
+
+// setIOPriority 通过ioprio_set设置当前进程的IO调度类别和级别
+func setIOPriority(class, level int) error {
+	const ioprioWhoProcess = 1
+	const ioprioClassShift = 13
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(class<<ioprioClassShift|level))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
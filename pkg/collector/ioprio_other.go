@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+
+package collector
+
+import "fmt"
+
+// ioPrioritySupported 当前系统是否支持ionice
+const ioPrioritySupported = false
+
+// setIOPriority 只有Linux支持ionice
+func setIOPriority(class, level int) error {
+	return fmt.Errorf("当前系统不支持ionice")
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExtractJSONPath 按ParseJSONPath解析的路径提取JSON中的值，标量直接返回，对象和数组返回JSON文本
+func ExtractJSONPath(text string, path []string) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		return nil, fmt.Errorf("值不是有效的JSON: %v", err)
+	}
+
+	current := doc
+	for _, segment := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, exists := node[segment]
+			if !exists {
+				return nil, fmt.Errorf("JSON中不存在字段 %s", segment)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("JSON数组下标 %s 无效", segment)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("无法在JSON标量上访问 %s", segment)
+		}
+	}
+
+	switch v := current.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case nil:
+		return nil, fmt.Errorf("JSON值为null")
+	default:
+		return v, nil
+	}
+}
+
+// ParseJSONPath 解析 $.a.b[0] 或 $['name'] 形式的路径
+func ParseJSONPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSON路径必须以$开头: %s", path)
+	}
+
+	var segments []string
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("JSON路径 %s 中存在空字段名", path)
+			}
+			segments = append(segments, name)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSON路径 %s 缺少 ]", path)
+			}
+			segments = append(segments, strings.Trim(rest[1:end], `'"`))
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSON路径 %s 格式无效", path)
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "$.a[0].b", want: []string{"a", "0", "b"}},
+		{path: "$['x.y']", want: []string{"x.y"}},
+		{path: `$["x.y"].z`, want: []string{"x.y", "z"}},
+		{path: "$", want: nil},
+		{path: "$.a[0", wantErr: true},
+		{path: "$.a..b", wantErr: true},
+		{path: "a.b", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseJSONPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseJSONPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	const doc = `{"a":[{"b":1.5},{"b":"two"}],"x.y":{"z":true},"n":null}`
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "$.a[0].b", want: 1.5},
+		{path: "$.a[1].b", want: "two"},
+		{path: "$['x.y'].z", want: true},
+		{path: "$['x.y']", want: `{"z":true}`},
+		{path: "$.a[2].b", wantErr: true},
+		{path: "$.missing", wantErr: true},
+		{path: "$.a[0].b.c", wantErr: true},
+		{path: "$.n", wantErr: true},
+	}
+	for _, tt := range tests {
+		path, err := ParseJSONPath(tt.path)
+		if err != nil {
+			t.Fatalf("ParseJSONPath(%q) error: %v", tt.path, err)
+		}
+		got, err := ExtractJSONPath(doc, path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ExtractJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ExtractJSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := ExtractJSONPath("not json", nil); err == nil {
+		t.Error("ExtractJSONPath on invalid JSON error = nil")
+	}
+}
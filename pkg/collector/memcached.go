@@ -0,0 +1,290 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+)
+
+// memcachedDefaultPort Memcached默认端口
+const memcachedDefaultPort = 11211
+
+// memcachedHandler 采集一个Memcached key，params不含连接配置名称
+type memcachedHandler func(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error)
+
+// memcachedKey Memcached key定义
+type memcachedKey struct {
+	serviceKey
+	collect memcachedHandler
+}
+
+// memcachedKeys Memcached原生采集器支持的key，可在参数前加连接配置名称（默认default）
+var memcachedKeys = map[string]memcachedKey{
+	"memcached.get_stats": {serviceKey{"[profile]", "stats全部内容（JSON，一次查询）", "", "text"}, memcachedStatsJSON},
+	"memcached.stats": {serviceKey{"[profile,field]", "stats中的指定字段，如 memcached.stats[curr_connections]", "", ""},
+		memcachedStatsParam},
+	"memcached.version":     {serviceKey{"[profile]", "Memcached版本", "", "character"}, memcachedStat("version")},
+	"memcached.uptime":      {serviceKey{"[profile]", "运行时间", "s", "unsigned"}, memcachedStat("uptime")},
+	"memcached.hit_ratio":   {serviceKey{"[profile]", "get命中率（启动以来）", "%", "float"}, memcachedHitRatio},
+	"memcached.memory.util": {serviceKey{"[profile]", "已用内存占limit_maxbytes的比例", "%", "float"}, memcachedMemoryUtil},
+}
+
+// memcachedPingKey 连接检查key，连接失败时返回0而不是错误
+const memcachedPingKey = "memcached.ping"
+
+// memcachedPing 连接检查key的定义
+var memcachedPing = serviceKey{"[profile]", "连接检查（1 可用，0 不可用）", "", "unsigned"}
+
+// MemcachedCollector 原生Memcached采集器，使用文本协议的stats命令
+type MemcachedCollector struct {
+	enabled  bool
+	profiles map[string]config.DatabaseProfile
+	cacheTTL time.Duration
+	pool     *connPool
+	stats    *resultCache // 每个连接配置的stats结果缓存
+}
+
+// NewMemcachedCollector 创建Memcached采集器
+func NewMemcachedCollector(cfg config.MemcachedConfig) *MemcachedCollector {
+	return &MemcachedCollector{
+		enabled:  cfg.Enabled,
+		profiles: cfg.Profiles,
+		cacheTTL: cfg.CacheTTL,
+		pool:     newConnPool(),
+		stats:    newResultCache(),
+	}
+}
+
+// Name 采集器名称
+func (m *MemcachedCollector) Name() string {
+	return "memcached"
+}
+
+// IsEnabled 检查是否启用
+func (m *MemcachedCollector) IsEnabled() bool {
+	return m.enabled
+}
+
+// HasKey 是否支持该key
+func (m *MemcachedCollector) HasKey(itemKey string) bool {
+	name, _ := splitServiceKey(itemKey)
+	if name == memcachedPingKey {
+		return true
+	}
+	_, exists := memcachedKeys[name]
+	return exists
+}
+
+// Keys 返回支持的全部key说明
+func (m *MemcachedCollector) Keys() []ServiceKey {
+	defs := make(map[string]serviceKey, len(memcachedKeys)+1)
+	for name, key := range memcachedKeys {
+		defs[name] = key.serviceKey
+	}
+	defs[memcachedPingKey] = memcachedPing
+	return serviceKeyList(defs)
+}
+
+// SetCredentials Memcached文本协议没有认证，连接配置不使用凭据
+func (m *MemcachedCollector) SetCredentials(vault *credential.Vault) {}
+
+// Collect 采集key对应的原始值
+func (m *MemcachedCollector) Collect(ctx context.Context, itemKey string) (interface{}, error) {
+	name, params := splitServiceKey(itemKey)
+	if name == memcachedPingKey {
+		profile, _ := memcachedPing.profileParams(params)
+		if _, err := m.request(ctx, profile, "version", readMemcachedVersion); err != nil {
+			return 0, nil
+		}
+		return 1, nil
+	}
+
+	key, exists := memcachedKeys[name]
+	if !exists {
+		return nil, fmt.Errorf("不支持的Memcached监控项: %s", name)
+	}
+	profile, params := key.profileParams(params)
+	return key.collect(m, ctx, profile, params)
+}
+
+// Close 关闭全部空闲连接
+func (m *MemcachedCollector) Close() {
+	m.pool.Close()
+}
+
+// request 在连接配置对应的连接上发送一条命令并读取回复
+func (m *MemcachedCollector) request(ctx context.Context, profileName, command string, read func(conn *serviceConn) (interface{}, error)) (interface{}, error) {
+	profile, err := resolveProfile(m.profiles, profileName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dial := func(ctx context.Context) (*serviceConn, error) {
+		return dialService(ctx, profile, memcachedDefaultPort)
+	}
+	reply, err := m.pool.do(ctx, profileName, dial, func(conn *serviceConn) (interface{}, error) {
+		if _, err := io.WriteString(conn, command+"\r\n"); err != nil {
+			return nil, err
+		}
+		return read(conn)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Memcached %s 失败 (%s): %v", command, profileName, err)
+	}
+	return reply, nil
+}
+
+// statsValues 返回stats结果，cache_ttl内同一连接配置共用一次查询
+func (m *MemcachedCollector) statsValues(ctx context.Context, profile string) (map[string]string, error) {
+	entry := m.stats.entry(profile)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if stats, ok := entry.value.(map[string]string); ok && time.Now().Before(entry.expires) {
+		return stats, nil
+	}
+
+	reply, err := m.request(ctx, profile, "stats", readMemcachedStats)
+	if err != nil {
+		return nil, err
+	}
+	stats := reply.(map[string]string)
+	entry.value = stats
+	entry.collectedAt = time.Now()
+	entry.expires = entry.collectedAt.Add(m.cacheTTL)
+	return stats, nil
+}
+
+// statFloat 读取数值型stats字段
+func (m *MemcachedCollector) statFloat(ctx context.Context, profile, name string) (float64, error) {
+	stats, err := m.statsValues(ctx, profile)
+	if err != nil {
+		return 0, err
+	}
+	value, exists := stats[name]
+	if !exists {
+		return 0, fmt.Errorf("stats中不存在字段 %s", name)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("stats字段 %s 不是数值: %s", name, value)
+	}
+	return number, nil
+}
+
+// memcachedReplyError 识别 ERROR、CLIENT_ERROR 和 SERVER_ERROR 回复
+func memcachedReplyError(line string) error {
+	if line == "ERROR" || strings.HasPrefix(line, "CLIENT_ERROR") || strings.HasPrefix(line, "SERVER_ERROR") {
+		return serverError(line)
+	}
+	return nil
+}
+
+// readMemcachedStats 读取 STAT name value 行直到 END
+func readMemcachedStats(conn *serviceConn) (interface{}, error) {
+	stats := make(map[string]string)
+	for {
+		line, err := conn.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END" {
+			return stats, nil
+		}
+		if err := memcachedReplyError(line); err != nil {
+			return nil, err
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			return nil, fmt.Errorf("无效的stats回复: %s", line)
+		}
+		stats[fields[1]] = fields[2]
+	}
+}
+
+// readMemcachedVersion 读取 VERSION x.y.z 回复
+func readMemcachedVersion(conn *serviceConn) (interface{}, error) {
+	line, err := conn.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if err := memcachedReplyError(line); err != nil {
+		return nil, err
+	}
+	version, ok := strings.CutPrefix(line, "VERSION ")
+	if !ok {
+		return nil, fmt.Errorf("无效的version回复: %s", line)
+	}
+	return version, nil
+}
+
+// memcachedStat 返回读取指定stats字段的处理函数
+func memcachedStat(field string) memcachedHandler {
+	return func(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+		stats, err := m.statsValues(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		value, exists := stats[field]
+		if !exists {
+			return nil, fmt.Errorf("stats中不存在字段 %s", field)
+		}
+		return value, nil
+	}
+}
+
+// memcachedStatsParam memcached.stats[profile,field]
+func memcachedStatsParam(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	field, err := requireParam(params, 0, "field")
+	if err != nil {
+		return nil, err
+	}
+	return memcachedStat(field)(m, ctx, profile, params)
+}
+
+// memcachedStatsJSON memcached.get_stats
+func memcachedStatsJSON(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	stats, err := m.statsValues(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return marshalResult(stats)
+}
+
+// memcachedHitRatio memcached.hit_ratio，由get_hits和get_misses计算
+func memcachedHitRatio(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	hits, err := m.statFloat(ctx, profile, "get_hits")
+	if err != nil {
+		return nil, err
+	}
+	misses, err := m.statFloat(ctx, profile, "get_misses")
+	if err != nil {
+		return nil, err
+	}
+	if hits+misses == 0 {
+		return 100.0, nil
+	}
+	return hits / (hits + misses) * 100, nil
+}
+
+// memcachedMemoryUtil memcached.memory.util
+func memcachedMemoryUtil(m *MemcachedCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	used, err := m.statFloat(ctx, profile, "bytes")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := m.statFloat(ctx, profile, "limit_maxbytes")
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		return 0.0, nil
+	}
+	return used / limit * 100, nil
+}
@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-agent/pkg/config"
+)
+
+// memcachedStatsReply 模拟服务返回的stats内容
+const memcachedStatsReply = "STAT pid 1\r\nSTAT uptime 7200\r\nSTAT version 1.6.21\r\n" +
+	"STAT curr_connections 8\r\nSTAT get_hits 75\r\nSTAT get_misses 25\r\n" +
+	"STAT bytes 16777216\r\nSTAT limit_maxbytes 67108864\r\nEND\r\n"
+
+// startMockMemcached 启动模拟Memcached服务，busy为true时stats返回SERVER_ERROR
+func startMockMemcached(t *testing.T, busy *atomic.Bool) *mockServer {
+	return startMockServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var reply string
+			switch strings.TrimSpace(line) {
+			case "version":
+				reply = "VERSION 1.6.21\r\n"
+			case "stats":
+				if busy != nil && busy.Load() {
+					reply = "SERVER_ERROR busy\r\n"
+				} else {
+					reply = memcachedStatsReply
+				}
+			default:
+				reply = "ERROR\r\n"
+			}
+			if _, err := io.WriteString(conn, reply); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// newTestMemcachedCollector 创建连接到模拟服务的Memcached采集器
+func newTestMemcachedCollector(t *testing.T, profile config.DatabaseProfile) *MemcachedCollector {
+	collector := NewMemcachedCollector(config.MemcachedConfig{
+		Enabled:  true,
+		Profiles: map[string]config.DatabaseProfile{DefaultProfile: profile},
+	})
+	t.Cleanup(collector.Close)
+	return collector
+}
+
+func TestReadMemcachedStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      interface{}
+		wantErr   bool
+		serverErr bool
+	}{
+		{name: "stats", input: "STAT pid 1\r\nSTAT version 1.6.21\r\nEND\r\n", want: map[string]string{"pid": "1", "version": "1.6.21"}},
+		{name: "值包含空格", input: "STAT libevent 2.1.12 stable\r\nEND\r\n", want: map[string]string{"libevent": "2.1.12 stable"}},
+		{name: "空结果", input: "END\r\n", want: map[string]string{}},
+		{name: "ERROR", input: "ERROR\r\n", wantErr: true, serverErr: true},
+		{name: "SERVER_ERROR", input: "SERVER_ERROR busy\r\n", wantErr: true, serverErr: true},
+		{name: "CLIENT_ERROR", input: "CLIENT_ERROR bad command\r\n", wantErr: true, serverErr: true},
+		{name: "无效行", input: "STAT pid\r\nEND\r\n", wantErr: true},
+		{name: "缺少END", input: "STAT pid 1\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &serviceConn{reader: bufio.NewReader(strings.NewReader(tt.input))}
+			got, err := readMemcachedStats(conn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readMemcachedStats(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if _, ok := err.(serverError); ok != tt.serverErr {
+				t.Errorf("readMemcachedStats(%q) error = %T, want serverError %v", tt.input, err, tt.serverErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readMemcachedStats(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMemcachedVersion(t *testing.T) {
+	conn := &serviceConn{reader: bufio.NewReader(strings.NewReader("VERSION 1.6.21\r\n"))}
+	if got, err := readMemcachedVersion(conn); err != nil || got != "1.6.21" {
+		t.Errorf("readMemcachedVersion = %v, %v, want 1.6.21", got, err)
+	}
+
+	conn = &serviceConn{reader: bufio.NewReader(strings.NewReader("OK\r\n"))}
+	if _, err := readMemcachedVersion(conn); err == nil {
+		t.Error("readMemcachedVersion(OK) error = nil, want error")
+	}
+}
+
+func TestMemcachedCollectorKeys(t *testing.T) {
+	server := startMockMemcached(t, nil)
+	collector := newTestMemcachedCollector(t, server.profile())
+
+	tests := []struct {
+		key     string
+		want    interface{}
+		wantErr bool
+	}{
+		{key: "memcached.ping", want: 1},
+		{key: "memcached.version", want: "1.6.21"},
+		{key: "memcached.uptime[default]", want: "7200"},
+		{key: "memcached.stats[curr_connections]", want: "8"},
+		{key: "memcached.stats[default,get_hits]", want: "75"},
+		{key: "memcached.stats[nosuch]", wantErr: true},
+		{key: "memcached.hit_ratio", want: 75.0},
+		{key: "memcached.memory.util", want: 25.0},
+		{key: "memcached.version[other]", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := collector.Collect(context.Background(), tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Collect(%s) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Collect(%s) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+
+	if n := server.accepts.Load(); n != 1 {
+		t.Errorf("建立了 %d 个连接，want 1（连接应被复用）", n)
+	}
+}
+
+// 服务端返回错误回复后连接仍然可用，应归还连接池继续使用
+func TestMemcachedPoolReuseAfterServerError(t *testing.T) {
+	var busy atomic.Bool
+	busy.Store(true)
+	server := startMockMemcached(t, &busy)
+	collector := newTestMemcachedCollector(t, server.profile())
+
+	ctx := context.Background()
+	_, err := collector.Collect(ctx, "memcached.uptime")
+	if err == nil || !strings.Contains(err.Error(), "SERVER_ERROR busy") {
+		t.Fatalf("Collect(memcached.uptime) error = %v, want SERVER_ERROR", err)
+	}
+
+	busy.Store(false)
+	if got, err := collector.Collect(ctx, "memcached.uptime"); err != nil || got != "7200" {
+		t.Fatalf("Collect(memcached.uptime) = %v, %v, want 7200", got, err)
+	}
+	if n := server.accepts.Load(); n != 1 {
+		t.Errorf("建立了 %d 个连接，want 1", n)
+	}
+}
+
+func TestMemcachedPingUnavailable(t *testing.T) {
+	server := startMockMemcached(t, nil)
+	profile := server.profile()
+	server.Close()
+
+	collector := newTestMemcachedCollector(t, profile)
+	if got, err := collector.Collect(context.Background(), "memcached.ping"); err != nil || got != 0 {
+		t.Errorf("Collect(memcached.ping) = %v, %v, want 0", got, err)
+	}
+}
@@ -0,0 +1,459 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+)
+
+// mysqlHandler 采集一个MySQL key，params不含连接配置名称
+type mysqlHandler func(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error)
+
+// mysqlKey MySQL key定义
+type mysqlKey struct {
+	serviceKey
+	collect mysqlHandler
+}
+
+// mysqlKeys MySQL原生采集器支持的key，可在参数前加连接配置名称（默认default）
+var mysqlKeys = map[string]mysqlKey{
+	"mysql.version": {serviceKey{"[profile]", "MySQL版本", "", "character"}, mysqlVersion},
+	"mysql.uptime":  {serviceKey{"[profile]", "运行时间", "s", "unsigned"}, mysqlStatusVar("Uptime")},
+	"mysql.get_status_variables": {serviceKey{"[profile]", "全部全局状态变量（JSON，一次查询）", "", "text"},
+		mysqlStatusJSON},
+	"mysql.status_var": {serviceKey{"[profile,name]", "指定的全局状态变量", "", ""}, mysqlStatusVarParam},
+
+	"mysql.threads.connected": {serviceKey{"[profile]", "当前连接数", "", "unsigned"}, mysqlStatusVar("Threads_connected")},
+	"mysql.threads.running":   {serviceKey{"[profile]", "正在执行的线程数", "", "unsigned"}, mysqlStatusVar("Threads_running")},
+	"mysql.connections":       {serviceKey{"[profile]", "累计连接次数", "", "unsigned"}, mysqlStatusVar("Connections")},
+	"mysql.aborted_connects":  {serviceKey{"[profile]", "累计失败的连接次数", "", "unsigned"}, mysqlStatusVar("Aborted_connects")},
+	"mysql.questions":         {serviceKey{"[profile]", "累计执行的语句数", "", "unsigned"}, mysqlStatusVar("Questions")},
+	"mysql.slow_queries":      {serviceKey{"[profile]", "累计慢查询数", "", "unsigned"}, mysqlStatusVar("Slow_queries")},
+	"mysql.bytes_received":    {serviceKey{"[profile]", "累计接收字节数", "B", "unsigned"}, mysqlStatusVar("Bytes_received")},
+	"mysql.bytes_sent":        {serviceKey{"[profile]", "累计发送字节数", "B", "unsigned"}, mysqlStatusVar("Bytes_sent")},
+	"mysql.innodb.deadlocks":  {serviceKey{"[profile]", "累计InnoDB死锁数", "", "unsigned"}, mysqlDeadlocks},
+
+	"mysql.innodb.buffer_pool.pages_total":   {serviceKey{"[profile]", "缓冲池总页数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_pages_total")},
+	"mysql.innodb.buffer_pool.pages_free":    {serviceKey{"[profile]", "缓冲池空闲页数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_pages_free")},
+	"mysql.innodb.buffer_pool.pages_data":    {serviceKey{"[profile]", "缓冲池数据页数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_pages_data")},
+	"mysql.innodb.buffer_pool.pages_dirty":   {serviceKey{"[profile]", "缓冲池脏页数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_pages_dirty")},
+	"mysql.innodb.buffer_pool.read_requests": {serviceKey{"[profile]", "累计逻辑读次数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_read_requests")},
+	"mysql.innodb.buffer_pool.reads":         {serviceKey{"[profile]", "累计物理读次数", "", "unsigned"}, mysqlStatusVar("Innodb_buffer_pool_reads")},
+	"mysql.innodb.buffer_pool.utilization":   {serviceKey{"[profile]", "缓冲池使用率", "%", "float"}, mysqlBufferPoolUtilization},
+	"mysql.innodb.buffer_pool.hit_ratio":     {serviceKey{"[profile]", "缓冲池命中率（启动以来）", "%", "float"}, mysqlBufferPoolHitRatio},
+
+	"mysql.replication.get_slave_status": {serviceKey{"[profile]", "主从复制状态（JSON）", "", "text"}, mysqlReplicaStatusJSON},
+	"mysql.replication.lag":              {serviceKey{"[profile]", "主从复制延迟", "s", "unsigned"}, mysqlReplicationLag},
+	"mysql.replication.io_running":       {serviceKey{"[profile]", "IO线程是否运行（1/0）", "", "unsigned"}, mysqlReplicaRunning("Replica_IO_Running", "Slave_IO_Running")},
+	"mysql.replication.sql_running":      {serviceKey{"[profile]", "SQL线程是否运行（1/0）", "", "unsigned"}, mysqlReplicaRunning("Replica_SQL_Running", "Slave_SQL_Running")},
+
+	"mysql.db.discovery": {serviceKey{"[profile]", "用户数据库列表（JSON）", "", "text"}, mysqlDatabases},
+	"mysql.db.size":      {serviceKey{"[profile,schema]", "数据库大小（数据+索引）", "B", "unsigned"}, mysqlDatabaseSize},
+}
+
+// mysqlPingKey 连接检查key，连接失败时返回0而不是错误
+const mysqlPingKey = "mysql.ping"
+
+// mysqlPing 连接检查key的定义
+var mysqlPing = serviceKey{"[profile]", "连接检查（1 可用，0 不可用）", "", "unsigned"}
+
+// MySQLCollector 原生MySQL/MariaDB采集器，按命名的连接配置复用连接池
+type MySQLCollector struct {
+	enabled     bool
+	profiles    map[string]config.DatabaseProfile
+	cacheTTL    time.Duration
+	pool        *sqlPool
+	status      *resultCache // 每个连接配置的全局状态变量缓存
+	credentials *credential.Vault
+	mu          sync.RWMutex
+}
+
+// NewMySQLCollector 创建MySQL采集器
+func NewMySQLCollector(cfg config.MySQLConfig) *MySQLCollector {
+	return &MySQLCollector{
+		enabled:  cfg.Enabled,
+		profiles: cfg.Profiles,
+		cacheTTL: cfg.CacheTTL,
+		pool:     newSQLPool(),
+		status:   newResultCache(),
+	}
+}
+
+// Name 采集器名称
+func (m *MySQLCollector) Name() string {
+	return "mysql"
+}
+
+// IsEnabled 检查是否启用
+func (m *MySQLCollector) IsEnabled() bool {
+	return m.enabled
+}
+
+// HasKey 是否支持该key
+func (m *MySQLCollector) HasKey(itemKey string) bool {
+	name, _ := splitServiceKey(itemKey)
+	if name == mysqlPingKey {
+		return true
+	}
+	_, exists := mysqlKeys[name]
+	return exists
+}
+
+// Keys 返回支持的全部key说明
+func (m *MySQLCollector) Keys() []ServiceKey {
+	defs := make(map[string]serviceKey, len(mysqlKeys)+1)
+	for name, key := range mysqlKeys {
+		defs[name] = key.serviceKey
+	}
+	defs[mysqlPingKey] = mysqlPing
+	return serviceKeyList(defs)
+}
+
+// SetCredentials 设置凭据库
+func (m *MySQLCollector) SetCredentials(vault *credential.Vault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credentials = vault
+}
+
+// Collect 采集key对应的原始值
+func (m *MySQLCollector) Collect(ctx context.Context, itemKey string) (interface{}, error) {
+	name, params := splitServiceKey(itemKey)
+	if name == mysqlPingKey {
+		profile, _ := mysqlPing.profileParams(params)
+		if _, err := m.connect(ctx, profile); err != nil {
+			return 0, nil
+		}
+		return 1, nil
+	}
+
+	key, exists := mysqlKeys[name]
+	if !exists {
+		return nil, fmt.Errorf("不支持的MySQL监控项: %s", name)
+	}
+	profile, params := key.profileParams(params)
+	conn, err := m.connect(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	value, err := key.collect(m, ctx, conn, params)
+	if err != nil {
+		// 下次采集前重新检查连接
+		m.pool.Invalidate("mysql", conn.dsn)
+		return nil, err
+	}
+	return value, nil
+}
+
+// Close 关闭全部连接池
+func (m *MySQLCollector) Close() {
+	m.pool.Close()
+}
+
+// connect 获取连接配置对应的连接池
+func (m *MySQLCollector) connect(ctx context.Context, profileName string) (*sqlConn, error) {
+	m.mu.RLock()
+	vault := m.credentials
+	m.mu.RUnlock()
+
+	profile, err := resolveProfile(m.profiles, profileName, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn, err := mysqlDSN(CommandConfig{
+		Host:          profile.Host,
+		Port:          profile.Port,
+		Username:      profile.Username,
+		Password:      profile.Password,
+		Database:      profile.Database,
+		TLS:           profile.TLS,
+		TLSCA:         profile.TLSCA,
+		TLSCert:       profile.TLSCert,
+		TLSKey:        profile.TLSKey,
+		TLSServerName: profile.TLSServerName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成MySQL连接参数失败: %v", err)
+	}
+
+	db, err := m.pool.Get(ctx, "mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("连接MySQL失败 (%s): %v", profileName, err)
+	}
+	return &sqlConn{profile: profileName, dsn: dsn, db: db}, nil
+}
+
+// statusVariables 返回全局状态变量，cache_ttl内同一连接配置共用一次 SHOW GLOBAL STATUS
+func (m *MySQLCollector) statusVariables(ctx context.Context, conn *sqlConn) (map[string]string, error) {
+	entry := m.status.entry(conn.profile)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if vars, ok := entry.value.(map[string]string); ok && time.Now().Before(entry.expires) {
+		return vars, nil
+	}
+
+	rows, err := conn.db.QueryContext(ctx, "SHOW GLOBAL STATUS")
+	if err != nil {
+		return nil, fmt.Errorf("查询全局状态失败: %v", err)
+	}
+	defer rows.Close()
+
+	vars := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("读取全局状态失败: %v", err)
+		}
+		vars[name] = value.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取全局状态失败: %v", err)
+	}
+
+	entry.value = vars
+	entry.collectedAt = time.Now()
+	entry.expires = entry.collectedAt.Add(m.cacheTTL)
+	return vars, nil
+}
+
+// statusFloat 读取数值型状态变量
+func (m *MySQLCollector) statusFloat(ctx context.Context, conn *sqlConn, name string) (float64, error) {
+	vars, err := m.statusVariables(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	value, exists := vars[name]
+	if !exists {
+		return 0, fmt.Errorf("状态变量 %s 不存在", name)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("状态变量 %s 不是数值: %s", name, value)
+	}
+	return number, nil
+}
+
+// replicaStatus 查询主从复制状态，未配置复制时返回nil
+func (m *MySQLCollector) replicaStatus(ctx context.Context, conn *sqlConn) (map[string]interface{}, error) {
+	// MySQL 8.0.22起使用 SHOW REPLICA STATUS，旧版本和MariaDB使用 SHOW SLAVE STATUS
+	rows, err := conn.db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = conn.db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return nil, fmt.Errorf("查询主从复制状态失败: %v", err)
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	records, err := scanRows(rows, columns, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return rowObject(columns, records[0]), nil
+}
+
+// mysqlStatusVar 返回读取指定状态变量的处理函数
+func mysqlStatusVar(name string) mysqlHandler {
+	return func(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		vars, err := m.statusVariables(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		value, exists := vars[name]
+		if !exists {
+			return nil, fmt.Errorf("状态变量 %s 不存在", name)
+		}
+		return value, nil
+	}
+}
+
+// mysqlStatusVarParam mysql.status_var[profile,name]
+func mysqlStatusVarParam(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	name, err := requireParam(params, 0, "name")
+	if err != nil {
+		return nil, err
+	}
+	return mysqlStatusVar(name)(m, ctx, conn, params)
+}
+
+// mysqlStatusJSON mysql.get_status_variables
+func mysqlStatusJSON(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	vars, err := m.statusVariables(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return nil, fmt.Errorf("序列化状态变量失败: %v", err)
+	}
+	return string(data), nil
+}
+
+// mysqlVersion mysql.version
+func mysqlVersion(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	var version string
+	if err := conn.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return nil, fmt.Errorf("查询版本失败: %v", err)
+	}
+	return version, nil
+}
+
+// mysqlDeadlocks mysql.innodb.deadlocks，读取 INNODB_METRICS 中的 lock_deadlocks
+func mysqlDeadlocks(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	var count int64
+	err := conn.db.QueryRowContext(ctx, "SELECT `COUNT` FROM information_schema.INNODB_METRICS WHERE NAME = 'lock_deadlocks'").Scan(&count)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("INNODB_METRICS 中没有 lock_deadlocks 计数器")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询死锁计数失败: %v", err)
+	}
+	return count, nil
+}
+
+// mysqlBufferPoolUtilization mysql.innodb.buffer_pool.utilization
+func mysqlBufferPoolUtilization(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	total, err := m.statusFloat(ctx, conn, "Innodb_buffer_pool_pages_total")
+	if err != nil {
+		return nil, err
+	}
+	free, err := m.statusFloat(ctx, conn, "Innodb_buffer_pool_pages_free")
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return 0.0, nil
+	}
+	return (total - free) / total * 100, nil
+}
+
+// mysqlBufferPoolHitRatio mysql.innodb.buffer_pool.hit_ratio
+func mysqlBufferPoolHitRatio(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	requests, err := m.statusFloat(ctx, conn, "Innodb_buffer_pool_read_requests")
+	if err != nil {
+		return nil, err
+	}
+	reads, err := m.statusFloat(ctx, conn, "Innodb_buffer_pool_reads")
+	if err != nil {
+		return nil, err
+	}
+	if requests == 0 {
+		return 100.0, nil
+	}
+	return (1 - reads/requests) * 100, nil
+}
+
+// mysqlReplicaStatusJSON mysql.replication.get_slave_status
+func mysqlReplicaStatusJSON(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	status, err := m.replicaStatus(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return "{}", nil
+	}
+	return marshalResult(status)
+}
+
+// mysqlReplicationLag mysql.replication.lag
+func mysqlReplicationLag(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	status, err := m.replicaStatus(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("未配置主从复制")
+	}
+
+	for _, column := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		value, exists := status[column]
+		if !exists {
+			continue
+		}
+		if value == nil {
+			return nil, fmt.Errorf("复制线程未运行（%s 为 NULL）", column)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("复制状态中没有复制延迟字段")
+}
+
+// mysqlReplicaRunning 返回检查复制线程状态的处理函数，依次尝试新旧两种列名
+func mysqlReplicaRunning(columns ...string) mysqlHandler {
+	return func(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		status, err := m.replicaStatus(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		if status == nil {
+			return nil, fmt.Errorf("未配置主从复制")
+		}
+		for _, column := range columns {
+			if value, exists := status[column]; exists {
+				if value == "Yes" {
+					return 1, nil
+				}
+				return 0, nil
+			}
+		}
+		return nil, fmt.Errorf("复制状态中没有 %s 字段", columns[len(columns)-1])
+	}
+}
+
+// mysqlDatabases mysql.db.discovery，不含系统库
+func mysqlDatabases(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, "SELECT schema_name FROM information_schema.schemata "+
+		"WHERE schema_name NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys') ORDER BY schema_name")
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("读取数据库列表失败: %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取数据库列表失败: %v", err)
+	}
+	return marshalResult(names)
+}
+
+// mysqlDatabaseSize mysql.db.size[profile,schema]
+func mysqlDatabaseSize(m *MySQLCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	schema, err := requireParam(params, 0, "schema")
+	if err != nil {
+		return nil, err
+	}
+
+	var size sql.NullString
+	err = conn.db.QueryRowContext(ctx, "SELECT SUM(data_length + index_length) FROM information_schema.tables WHERE table_schema = ?", schema).Scan(&size)
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库大小失败: %v", err)
+	}
+	if !size.Valid {
+		return nil, fmt.Errorf("数据库 %s 不存在或没有表", schema)
+	}
+	return size.String, nil
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL支持的tls取值，与go-sql-driver的tls参数一致
+var mysqlTLSModes = map[string]bool{
+	"":            true,
+	"false":       true,
+	"true":        true,
+	"skip-verify": true,
+	"preferred":   true,
+}
+
+var (
+	// mysqlTLSMu 保护已注册的自定义TLS配置
+	mysqlTLSMu sync.Mutex
+	// mysqlTLSRegistered 已注册到驱动的自定义TLS配置名称
+	mysqlTLSRegistered = make(map[string]bool)
+)
+
+// mysqlDSN 根据命令配置生成MySQL DSN，配置了证书文件时注册自定义TLS配置
+func mysqlDSN(config CommandConfig) (string, error) {
+	port := config.Port
+	if port == 0 {
+		port = 3306
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User = config.Username
+	cfg.Passwd = config.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(config.Host, strconv.Itoa(port))
+	cfg.DBName = config.Database
+
+	if config.usesCustomTLS() {
+		name, err := registerMySQLTLS(config)
+		if err != nil {
+			return "", err
+		}
+		cfg.TLSConfig = name
+	} else if config.TLS != "" {
+		cfg.TLSConfig = strings.ToLower(config.TLS)
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// usesCustomTLS 是否配置了CA、客户端证书或服务器名称
+func (c CommandConfig) usesCustomTLS() bool {
+	return c.TLSCA != "" || c.TLSCert != "" || c.TLSKey != "" || c.TLSServerName != ""
+}
+
+// registerMySQLTLS 按证书文件注册自定义TLS配置，相同配置只注册一次（重新加载命令映射后重新读取证书）
+func registerMySQLTLS(config CommandConfig) (string, error) {
+	mode := strings.ToLower(config.TLS)
+	sum := sha256.Sum256([]byte(strings.Join([]string{mode, config.TLSCA, config.TLSCert, config.TLSKey, config.TLSServerName, config.Host}, "\x00")))
+	name := "go-agent-" + hex.EncodeToString(sum[:8])
+
+	mysqlTLSMu.Lock()
+	defer mysqlTLSMu.Unlock()
+	if mysqlTLSRegistered[name] {
+		return name, nil
+	}
+
+	tlsConfig, err := newTLSConfig(mode, config.TLSCA, config.TLSCert, config.TLSKey, config.TLSServerName, config.Host)
+	if err != nil {
+		return "", err
+	}
+
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("注册TLS配置失败: %v", err)
+	}
+	mysqlTLSRegistered[name] = true
+	return name, nil
+}
+
+// resetMySQLTLS 清除已注册标记，下次使用时重新读取证书文件（证书轮换后重新加载即可生效）
+func resetMySQLTLS() {
+	mysqlTLSMu.Lock()
+	defer mysqlTLSMu.Unlock()
+	mysqlTLSRegistered = make(map[string]bool)
+}
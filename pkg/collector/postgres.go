@@ -0,0 +1,519 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+)
+
+// postgresHandler 采集一个PostgreSQL key，params不含连接配置名称
+type postgresHandler func(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error)
+
+// postgresKey PostgreSQL key定义
+type postgresKey struct {
+	serviceKey
+	collect postgresHandler
+}
+
+// PostgreSQL统计查询，结果按连接配置缓存cache_ttl
+const (
+	pgStatDatabaseQuery = `SELECT sum(numbackends)::bigint AS numbackends, sum(xact_commit)::bigint AS xact_commit,
+		sum(xact_rollback)::bigint AS xact_rollback, sum(blks_read)::bigint AS blks_read, sum(blks_hit)::bigint AS blks_hit,
+		sum(tup_returned)::bigint AS tup_returned, sum(tup_fetched)::bigint AS tup_fetched,
+		sum(tup_inserted)::bigint AS tup_inserted, sum(tup_updated)::bigint AS tup_updated,
+		sum(tup_deleted)::bigint AS tup_deleted, sum(conflicts)::bigint AS conflicts,
+		sum(temp_files)::bigint AS temp_files, sum(temp_bytes)::bigint AS temp_bytes, sum(deadlocks)::bigint AS deadlocks
+		FROM pg_stat_database`
+	pgStatBgwriterQuery = `SELECT * FROM pg_stat_bgwriter`
+	pgConnectionsQuery  = `SELECT coalesce(state, 'unknown'), count(*) FROM pg_stat_activity WHERE backend_type = 'client backend' GROUP BY 1`
+	pgLocksQuery        = `SELECT mode, count(*) FROM pg_locks GROUP BY mode`
+)
+
+// postgresKeys PostgreSQL原生采集器支持的key，可在参数前加连接配置名称（默认default）
+var postgresKeys = map[string]postgresKey{
+	"postgres.version": {serviceKey{"[profile]", "PostgreSQL版本", "", "character"},
+		pgValue("SHOW server_version")},
+	"postgres.uptime": {serviceKey{"[profile]", "运行时间", "s", "unsigned"},
+		pgValue("SELECT extract(epoch FROM now() - pg_postmaster_start_time())::bigint")},
+
+	"postgres.connections": {serviceKey{"[profile]", "按状态统计的客户端连接数（JSON，含total和max）", "", "text"}, pgConnectionsJSON},
+	"postgres.connections.state": {serviceKey{"[profile,state]", "指定状态的连接数，如 active、idle in transaction", "", "unsigned"},
+		pgConnectionsState},
+	"postgres.connections.total": {serviceKey{"[profile]", "客户端连接总数", "", "unsigned"}, pgConnectionsField("total")},
+	"postgres.connections.max":   {serviceKey{"[profile]", "最大连接数（max_connections）", "", "unsigned"}, pgConnectionsField("max")},
+	"postgres.connections.waiting": {serviceKey{"[profile]", "等待锁的连接数", "", "unsigned"},
+		pgValue("SELECT count(*) FROM pg_stat_activity WHERE wait_event_type = 'Lock'")},
+
+	"postgres.get_stat_database": {serviceKey{"[profile]", "全部数据库的pg_stat_database合计（JSON，一次查询）", "", "text"},
+		pgStatJSON("stat_database", pgStatDatabaseQuery)},
+	"postgres.stat_database": {serviceKey{"[profile,field]", "pg_stat_database合计中的指定字段，如 xact_commit", "", "unsigned"},
+		pgStatField("stat_database", pgStatDatabaseQuery)},
+	"postgres.get_stat_bgwriter": {serviceKey{"[profile]", "pg_stat_bgwriter（JSON）", "", "text"},
+		pgStatJSON("stat_bgwriter", pgStatBgwriterQuery)},
+	"postgres.stat_bgwriter": {serviceKey{"[profile,field]", "pg_stat_bgwriter中的指定字段，如 buffers_clean", "", ""},
+		pgStatField("stat_bgwriter", pgStatBgwriterQuery)},
+	"postgres.cache_hit_ratio": {serviceKey{"[profile]", "缓冲区命中率（启动以来）", "%", "float"}, pgCacheHitRatio},
+
+	"postgres.replication.recovery": {serviceKey{"[profile]", "是否为备库（1 备库，0 主库）", "", "unsigned"},
+		pgValue("SELECT CASE WHEN pg_is_in_recovery() THEN 1 ELSE 0 END")},
+	"postgres.replication.lag": {serviceKey{"[profile]", "备库回放延迟", "s", "float"}, pgReplicationLag},
+	"postgres.replication.count": {serviceKey{"[profile]", "主库上连接的备库数", "", "unsigned"},
+		pgValue("SELECT count(*) FROM pg_stat_replication")},
+	"postgres.replication.status": {serviceKey{"[profile]", "主库上各备库的复制状态（JSON）", "", "text"}, pgReplicationStatus},
+
+	"postgres.db.discovery": {serviceKey{"[profile]", "数据库列表（JSON，不含模板库）", "", "text"}, pgDatabases},
+	"postgres.db.size":      {serviceKey{"[profile,database]", "数据库大小", "B", "unsigned"}, pgDatabaseSize},
+
+	"postgres.bloat.dead_tuples": {serviceKey{"[profile]", "当前库用户表的死元组数", "", "unsigned"},
+		pgValue("SELECT coalesce(sum(n_dead_tup), 0)::bigint FROM pg_stat_user_tables")},
+	"postgres.bloat.ratio": {serviceKey{"[profile]", "当前库用户表的死元组比例", "%", "float"},
+		pgValue("SELECT coalesce(round(sum(n_dead_tup) * 100.0 / nullif(sum(n_live_tup) + sum(n_dead_tup), 0), 2), 0)::float8 FROM pg_stat_user_tables")},
+	"postgres.bloat.tables": {serviceKey{"[profile]", "死元组最多的10张表（JSON）", "", "text"}, pgBloatTables},
+
+	"postgres.locks":       {serviceKey{"[profile]", "按模式统计的锁数量（JSON）", "", "text"}, pgLocksJSON},
+	"postgres.locks.total": {serviceKey{"[profile]", "锁总数", "", "unsigned"}, pgValue("SELECT count(*) FROM pg_locks")},
+	"postgres.locks.waiting": {serviceKey{"[profile]", "未获得的锁数量", "", "unsigned"},
+		pgValue("SELECT count(*) FROM pg_locks WHERE NOT granted")},
+}
+
+// postgresPingKey 连接检查key，连接失败时返回0而不是错误
+const postgresPingKey = "postgres.ping"
+
+// postgresPing 连接检查key的定义
+var postgresPing = serviceKey{"[profile]", "连接检查（1 可用，0 不可用）", "", "unsigned"}
+
+// PostgresCollector 原生PostgreSQL采集器，按命名的连接配置复用连接池
+type PostgresCollector struct {
+	enabled     bool
+	profiles    map[string]config.DatabaseProfile
+	cacheTTL    time.Duration
+	pool        *sqlPool
+	stats       *resultCache // 每个连接配置的统计查询缓存
+	credentials *credential.Vault
+	mu          sync.RWMutex
+}
+
+// NewPostgresCollector 创建PostgreSQL采集器
+func NewPostgresCollector(cfg config.PostgresConfig) *PostgresCollector {
+	return &PostgresCollector{
+		enabled:  cfg.Enabled,
+		profiles: cfg.Profiles,
+		cacheTTL: cfg.CacheTTL,
+		pool:     newSQLPool(),
+		stats:    newResultCache(),
+	}
+}
+
+// Name 采集器名称
+func (p *PostgresCollector) Name() string {
+	return "postgres"
+}
+
+// IsEnabled 检查是否启用
+func (p *PostgresCollector) IsEnabled() bool {
+	return p.enabled
+}
+
+// HasKey 是否支持该key
+func (p *PostgresCollector) HasKey(itemKey string) bool {
+	name, _ := splitServiceKey(itemKey)
+	if name == postgresPingKey {
+		return true
+	}
+	_, exists := postgresKeys[name]
+	return exists
+}
+
+// Keys 返回支持的全部key说明
+func (p *PostgresCollector) Keys() []ServiceKey {
+	defs := make(map[string]serviceKey, len(postgresKeys)+1)
+	for name, key := range postgresKeys {
+		defs[name] = key.serviceKey
+	}
+	defs[postgresPingKey] = postgresPing
+	return serviceKeyList(defs)
+}
+
+// SetCredentials 设置凭据库
+func (p *PostgresCollector) SetCredentials(vault *credential.Vault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.credentials = vault
+}
+
+// Collect 采集key对应的原始值
+func (p *PostgresCollector) Collect(ctx context.Context, itemKey string) (interface{}, error) {
+	name, params := splitServiceKey(itemKey)
+	if name == postgresPingKey {
+		profile, _ := postgresPing.profileParams(params)
+		if _, err := p.connect(ctx, profile); err != nil {
+			return 0, nil
+		}
+		return 1, nil
+	}
+
+	key, exists := postgresKeys[name]
+	if !exists {
+		return nil, fmt.Errorf("不支持的PostgreSQL监控项: %s", name)
+	}
+	profile, params := key.profileParams(params)
+	conn, err := p.connect(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	value, err := key.collect(p, ctx, conn, params)
+	if err != nil {
+		// 下次采集前重新检查连接
+		p.pool.Invalidate("postgres", conn.dsn)
+		return nil, err
+	}
+	return value, nil
+}
+
+// Close 关闭全部连接池
+func (p *PostgresCollector) Close() {
+	p.pool.Close()
+}
+
+// connect 获取连接配置对应的连接池
+func (p *PostgresCollector) connect(ctx context.Context, profileName string) (*sqlConn, error) {
+	p.mu.RLock()
+	vault := p.credentials
+	p.mu.RUnlock()
+
+	profile, err := resolveProfile(p.profiles, profileName, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := postgresDSN(CommandConfig{
+		Host:     profile.Host,
+		Port:     profile.Port,
+		Username: profile.Username,
+		Password: profile.Password,
+		Database: profile.Database,
+		SSLMode:  profile.SSLMode,
+		TLSCA:    profile.TLSCA,
+		TLSCert:  profile.TLSCert,
+		TLSKey:   profile.TLSKey,
+	})
+
+	db, err := p.pool.Get(ctx, "postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("连接PostgreSQL失败 (%s): %v", profileName, err)
+	}
+	return &sqlConn{profile: profileName, dsn: dsn, db: db}, nil
+}
+
+// cached 返回统计查询结果，cache_ttl内同一连接配置的同一查询只执行一次
+func (p *PostgresCollector) cached(ctx context.Context, conn *sqlConn, name string, load func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	entry := p.stats.entry(conn.profile + "/" + name)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if values, ok := entry.value.(map[string]interface{}); ok && time.Now().Before(entry.expires) {
+		return values, nil
+	}
+
+	values, err := load()
+	if err != nil {
+		return nil, err
+	}
+	entry.value = values
+	entry.collectedAt = time.Now()
+	entry.expires = entry.collectedAt.Add(p.cacheTTL)
+	return values, nil
+}
+
+// connections 按状态统计的客户端连接数，另含total和max
+func (p *PostgresCollector) connections(ctx context.Context, conn *sqlConn) (map[string]interface{}, error) {
+	return p.cached(ctx, conn, "connections", func() (map[string]interface{}, error) {
+		counts, err := pgQueryMap(ctx, conn, pgConnectionsQuery)
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		for _, count := range counts {
+			if n, ok := count.(int64); ok {
+				total += n
+			}
+		}
+		counts["total"] = total
+
+		maxConnections, err := pgQueryValue(ctx, conn, "SELECT setting::bigint FROM pg_settings WHERE name = 'max_connections'")
+		if err != nil {
+			return nil, err
+		}
+		counts["max"] = maxConnections
+		return counts, nil
+	})
+}
+
+// pgQueryValue 返回查询结果第一行第一列，没有结果或为NULL时返回错误
+func pgQueryValue(ctx context.Context, conn *sqlConn, query string, args ...interface{}) (interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("PostgreSQL查询失败: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	records, err := scanRows(rows, columns, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || records[0][0] == nil {
+		return nil, fmt.Errorf("查询结果为空")
+	}
+	return records[0][0], nil
+}
+
+// pgQueryRow 返回查询结果第一行（列名为字段），没有结果时返回错误
+func pgQueryRow(ctx context.Context, conn *sqlConn, query string) (map[string]interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("PostgreSQL查询失败: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	records, err := scanRows(rows, columns, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("查询结果为空")
+	}
+	return rowObject(columns, records[0]), nil
+}
+
+// pgQueryMap 将名称/值两列的查询结果转换为map
+func pgQueryMap(ctx context.Context, conn *sqlConn, query string) (map[string]interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("PostgreSQL查询失败: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	records, err := scanRows(rows, columns, 0)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(records))
+	for _, record := range records {
+		result[fmt.Sprintf("%v", record[0])] = record[1]
+	}
+	return result, nil
+}
+
+// pgQueryJSON 将全部行转换为JSON数组
+func pgQueryJSON(ctx context.Context, conn *sqlConn, query string) (interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("PostgreSQL查询失败: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	records, err := scanRows(rows, columns, 0)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		objects = append(objects, rowObject(columns, record))
+	}
+	return marshalResult(objects)
+}
+
+// pgValue 返回执行单值查询的处理函数
+func pgValue(query string) postgresHandler {
+	return func(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		return pgQueryValue(ctx, conn, query)
+	}
+}
+
+// pgStatJSON 返回以JSON输出缓存的统计查询结果的处理函数
+func pgStatJSON(name, query string) postgresHandler {
+	return func(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		values, err := p.cached(ctx, conn, name, func() (map[string]interface{}, error) {
+			return pgQueryRow(ctx, conn, query)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return marshalResult(values)
+	}
+}
+
+// pgStatField 返回读取缓存的统计查询结果中指定字段的处理函数
+func pgStatField(name, query string) postgresHandler {
+	return func(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		field, err := requireParam(params, 0, "field")
+		if err != nil {
+			return nil, err
+		}
+		values, err := p.cached(ctx, conn, name, func() (map[string]interface{}, error) {
+			return pgQueryRow(ctx, conn, query)
+		})
+		if err != nil {
+			return nil, err
+		}
+		value, exists := values[strings.ToLower(field)]
+		if !exists {
+			fields := make([]string, 0, len(values))
+			for key := range values {
+				fields = append(fields, key)
+			}
+			sort.Strings(fields)
+			return nil, fmt.Errorf("字段 %s 不存在（可用: %s）", field, strings.Join(fields, ", "))
+		}
+		if value == nil {
+			return 0, nil
+		}
+		return value, nil
+	}
+}
+
+// pgCacheHitRatio postgres.cache_hit_ratio，由pg_stat_database合计的blks_hit和blks_read计算
+func pgCacheHitRatio(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	values, err := p.cached(ctx, conn, "stat_database", func() (map[string]interface{}, error) {
+		return pgQueryRow(ctx, conn, pgStatDatabaseQuery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	hit, _ := values["blks_hit"].(int64)
+	read, _ := values["blks_read"].(int64)
+	if hit+read == 0 {
+		return 100.0, nil
+	}
+	return float64(hit) / float64(hit+read) * 100, nil
+}
+
+// pgConnectionsJSON postgres.connections
+func pgConnectionsJSON(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	counts, err := p.connections(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return marshalResult(counts)
+}
+
+// pgConnectionsField 返回读取连接统计中指定字段的处理函数
+func pgConnectionsField(field string) postgresHandler {
+	return func(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+		counts, err := p.connections(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		return counts[field], nil
+	}
+}
+
+// pgConnectionsState postgres.connections.state[profile,state]，没有该状态的连接时为0
+func pgConnectionsState(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	state, err := requireParam(params, 0, "state")
+	if err != nil {
+		return nil, err
+	}
+	counts, err := p.connections(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if count, exists := counts[strings.ToLower(state)]; exists {
+		return count, nil
+	}
+	return 0, nil
+}
+
+// pgReplicationLag postgres.replication.lag，备库已回放到最新位置时为0，主库上返回错误
+func pgReplicationLag(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	row, err := pgQueryRow(ctx, conn, `SELECT pg_is_in_recovery() AS recovery,
+		CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0) END::float8 AS lag`)
+	if err != nil {
+		return nil, err
+	}
+	if recovery, _ := row["recovery"].(bool); !recovery {
+		return nil, fmt.Errorf("不是备库，没有回放延迟")
+	}
+	return row["lag"], nil
+}
+
+// pgReplicationStatus postgres.replication.status
+func pgReplicationStatus(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	return pgQueryJSON(ctx, conn, `SELECT application_name, client_addr::text AS client_addr, state, sync_state,
+		coalesce(extract(epoch FROM replay_lag), 0)::float8 AS replay_lag FROM pg_stat_replication ORDER BY application_name`)
+}
+
+// pgDatabases postgres.db.discovery
+func pgDatabases(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	rows, err := conn.db.QueryContext(ctx, "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn ORDER BY datname")
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("读取数据库列表失败: %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取数据库列表失败: %v", err)
+	}
+	return marshalResult(names)
+}
+
+// pgDatabaseSize postgres.db.size[profile,database]
+func pgDatabaseSize(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	database, err := requireParam(params, 0, "database")
+	if err != nil {
+		return nil, err
+	}
+	value, err := pgQueryValue(ctx, conn, "SELECT pg_database_size(datname) FROM pg_database WHERE datname = $1", database)
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库 %s 大小失败: %v", database, err)
+	}
+	return value, nil
+}
+
+// pgBloatTables postgres.bloat.tables
+func pgBloatTables(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	return pgQueryJSON(ctx, conn, `SELECT schemaname || '.' || relname AS relation, n_live_tup AS live_tuples, n_dead_tup AS dead_tuples,
+		coalesce(round(n_dead_tup * 100.0 / nullif(n_live_tup + n_dead_tup, 0), 2), 0)::float8 AS dead_ratio,
+		greatest(last_vacuum, last_autovacuum) AS last_vacuum
+		FROM pg_stat_user_tables ORDER BY n_dead_tup DESC LIMIT 10`)
+}
+
+// pgLocksJSON postgres.locks
+func pgLocksJSON(p *PostgresCollector, ctx context.Context, conn *sqlConn, params []string) (interface{}, error) {
+	counts, err := pgQueryMap(ctx, conn, pgLocksQuery)
+	if err != nil {
+		return nil, err
+	}
+	return marshalResult(counts)
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgreSQL支持的sslmode取值，与lib/pq一致；为空时驱动默认require
+var postgresSSLModes = map[string]bool{
+	"":            true,
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// postgresDSN 根据命令配置生成lib/pq的 key=value 连接字符串，证书文件通过 sslrootcert/sslcert/sslkey 传入
+func postgresDSN(config CommandConfig) string {
+	port := config.Port
+	if port == 0 {
+		port = 5432
+	}
+	database := config.Database
+	if database == "" {
+		database = "postgres"
+	}
+
+	params := map[string]string{
+		"host":             config.Host,
+		"port":             strconv.Itoa(port),
+		"user":             config.Username,
+		"password":         config.Password,
+		"dbname":           database,
+		"sslmode":          strings.ToLower(config.SSLMode),
+		"sslrootcert":      config.TLSCA,
+		"sslcert":          config.TLSCert,
+		"sslkey":           config.TLSKey,
+		"application_name": "go-agent",
+	}
+
+	// 按参数名排序，相同配置生成相同的连接字符串，便于连接池复用
+	names := make([]string, 0, len(params))
+	for name, value := range params {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+quotePostgresValue(params[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quotePostgresValue 用单引号包含参数值，转义反斜杠和单引号
+func quotePostgresValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
@@ -0,0 +1,486 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+)
+
+// redisDefaultPort Redis默认端口
+const redisDefaultPort = 6379
+
+// redisMaxBulkSize 批量回复的最大长度，防止异常的长度值耗尽内存
+const redisMaxBulkSize = 64 << 20
+
+// redisMaxArrayLength 数组回复的最大元素数
+const redisMaxArrayLength = 1 << 20
+
+// redisHandler 采集一个Redis key，params不含连接配置名称
+type redisHandler func(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error)
+
+// redisKey Redis key定义
+type redisKey struct {
+	serviceKey
+	collect redisHandler
+}
+
+// redisKeys Redis原生采集器支持的key，可在参数前加连接配置名称（默认default）
+var redisKeys = map[string]redisKey{
+	"redis.get_info": {serviceKey{"[profile]", "INFO全部内容（JSON，按section分组，一次查询）", "", "text"}, redisInfoJSON},
+	"redis.info": {serviceKey{"[profile,section,field]", "INFO中的指定字段，如 redis.info[memory,used_memory]，section为空时查找全部section", "", ""},
+		redisInfoParam},
+	"redis.version": {serviceKey{"[profile]", "Redis版本", "", "character"}, redisInfoField("server", "redis_version")},
+	"redis.uptime":  {serviceKey{"[profile]", "运行时间", "s", "unsigned"}, redisInfoField("server", "uptime_in_seconds")},
+
+	"redis.clients.connected": {serviceKey{"[profile]", "客户端连接数", "", "unsigned"}, redisInfoField("clients", "connected_clients")},
+	"redis.clients.blocked":   {serviceKey{"[profile]", "阻塞的客户端数", "", "unsigned"}, redisInfoField("clients", "blocked_clients")},
+	"redis.memory.used":       {serviceKey{"[profile]", "已使用内存", "B", "unsigned"}, redisInfoField("memory", "used_memory")},
+	"redis.memory.fragmentation_ratio": {serviceKey{"[profile]", "内存碎片率", "", "float"},
+		redisInfoField("memory", "mem_fragmentation_ratio")},
+	"redis.ops_per_sec":          {serviceKey{"[profile]", "每秒执行的命令数", "", "unsigned"}, redisInfoField("stats", "instantaneous_ops_per_sec")},
+	"redis.keys.evicted":         {serviceKey{"[profile]", "累计淘汰的key数", "", "unsigned"}, redisInfoField("stats", "evicted_keys")},
+	"redis.keys.expired":         {serviceKey{"[profile]", "累计过期的key数", "", "unsigned"}, redisInfoField("stats", "expired_keys")},
+	"redis.connections.rejected": {serviceKey{"[profile]", "累计拒绝的连接数", "", "unsigned"}, redisInfoField("stats", "rejected_connections")},
+	"redis.hit_ratio":            {serviceKey{"[profile]", "key命中率（启动以来）", "%", "float"}, redisHitRatio},
+	"redis.keyspace": {serviceKey{"[profile,db,field]", "指定库的keys、expires或avg_ttl，如 redis.keyspace[0,keys]", "", "unsigned"},
+		redisKeyspace},
+
+	"redis.replication.role": {serviceKey{"[profile]", "复制角色（master/slave）", "", "character"}, redisInfoField("replication", "role")},
+	"redis.replication.connected_slaves": {serviceKey{"[profile]", "连接的从库数", "", "unsigned"},
+		redisInfoField("replication", "connected_slaves")},
+	"redis.replication.link_up": {serviceKey{"[profile]", "从库与主库的连接是否正常（1/0）", "", "unsigned"}, redisLinkUp},
+
+	"redis.slowlog.count": {serviceKey{"[profile]", "慢日志条数", "", "unsigned"}, redisSlowlogCount},
+	"redis.config":        {serviceKey{"[profile,parameter]", "配置参数的值，如 redis.config[maxmemory]", "", ""}, redisConfig},
+}
+
+// redisPingKey 连接检查key，连接失败时返回0而不是错误
+const redisPingKey = "redis.ping"
+
+// redisPing 连接检查key的定义
+var redisPing = serviceKey{"[profile]", "连接检查（1 可用，0 不可用）", "", "unsigned"}
+
+// RedisCollector 原生Redis采集器，直接使用RESP协议通信，支持密码/ACL认证和TLS
+type RedisCollector struct {
+	enabled     bool
+	profiles    map[string]config.DatabaseProfile
+	cacheTTL    time.Duration
+	pool        *connPool
+	info        *resultCache // 每个连接配置的INFO结果缓存
+	credentials *credential.Vault
+	mu          sync.RWMutex
+}
+
+// NewRedisCollector 创建Redis采集器
+func NewRedisCollector(cfg config.RedisConfig) *RedisCollector {
+	return &RedisCollector{
+		enabled:  cfg.Enabled,
+		profiles: cfg.Profiles,
+		cacheTTL: cfg.CacheTTL,
+		pool:     newConnPool(),
+		info:     newResultCache(),
+	}
+}
+
+// Name 采集器名称
+func (r *RedisCollector) Name() string {
+	return "redis"
+}
+
+// IsEnabled 检查是否启用
+func (r *RedisCollector) IsEnabled() bool {
+	return r.enabled
+}
+
+// HasKey 是否支持该key
+func (r *RedisCollector) HasKey(itemKey string) bool {
+	name, _ := splitServiceKey(itemKey)
+	if name == redisPingKey {
+		return true
+	}
+	_, exists := redisKeys[name]
+	return exists
+}
+
+// Keys 返回支持的全部key说明
+func (r *RedisCollector) Keys() []ServiceKey {
+	defs := make(map[string]serviceKey, len(redisKeys)+1)
+	for name, key := range redisKeys {
+		defs[name] = key.serviceKey
+	}
+	defs[redisPingKey] = redisPing
+	return serviceKeyList(defs)
+}
+
+// SetCredentials 设置凭据库
+func (r *RedisCollector) SetCredentials(vault *credential.Vault) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.credentials = vault
+}
+
+// Collect 采集key对应的原始值
+func (r *RedisCollector) Collect(ctx context.Context, itemKey string) (interface{}, error) {
+	name, params := splitServiceKey(itemKey)
+	if name == redisPingKey {
+		profile, _ := redisPing.profileParams(params)
+		reply, err := r.do(ctx, profile, "PING")
+		if err != nil || reply != "PONG" {
+			return 0, nil
+		}
+		return 1, nil
+	}
+
+	key, exists := redisKeys[name]
+	if !exists {
+		return nil, fmt.Errorf("不支持的Redis监控项: %s", name)
+	}
+	profile, params := key.profileParams(params)
+	return key.collect(r, ctx, profile, params)
+}
+
+// Close 关闭全部空闲连接
+func (r *RedisCollector) Close() {
+	r.pool.Close()
+}
+
+// do 在连接配置对应的连接上执行一条命令
+func (r *RedisCollector) do(ctx context.Context, profileName string, args ...string) (interface{}, error) {
+	r.mu.RLock()
+	vault := r.credentials
+	r.mu.RUnlock()
+
+	profile, err := resolveProfile(r.profiles, profileName, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	dial := func(ctx context.Context) (*serviceConn, error) {
+		return dialRedis(ctx, profile)
+	}
+	reply, err := r.pool.do(ctx, profileName, dial, func(conn *serviceConn) (interface{}, error) {
+		return redisCommand(conn, args...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Redis %s 失败 (%s): %v", args[0], profileName, err)
+	}
+	return reply, nil
+}
+
+// infoSections 返回按section分组的INFO内容，cache_ttl内同一连接配置共用一次 INFO ALL
+func (r *RedisCollector) infoSections(ctx context.Context, profile string) (map[string]map[string]string, error) {
+	entry := r.info.entry(profile)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if sections, ok := entry.value.(map[string]map[string]string); ok && time.Now().Before(entry.expires) {
+		return sections, nil
+	}
+
+	reply, err := r.do(ctx, profile, "INFO", "ALL")
+	if err != nil {
+		return nil, err
+	}
+	text, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("INFO返回了非文本结果")
+	}
+
+	sections := parseRedisInfo(text)
+	entry.value = sections
+	entry.collectedAt = time.Now()
+	entry.expires = entry.collectedAt.Add(r.cacheTTL)
+	return sections, nil
+}
+
+// infoValue 读取INFO中的字段，section为空时查找全部section
+func (r *RedisCollector) infoValue(ctx context.Context, profile, section, field string) (string, error) {
+	sections, err := r.infoSections(ctx, profile)
+	if err != nil {
+		return "", err
+	}
+	if section == "" {
+		for _, fields := range sections {
+			if value, exists := fields[field]; exists {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("INFO中不存在字段 %s", field)
+	}
+
+	fields, exists := sections[strings.ToLower(section)]
+	if !exists {
+		return "", fmt.Errorf("INFO中不存在section %s", section)
+	}
+	value, exists := fields[field]
+	if !exists {
+		return "", fmt.Errorf("INFO的 %s 中不存在字段 %s", section, field)
+	}
+	return value, nil
+}
+
+// parseRedisInfo 解析INFO输出，section名称转换为小写
+func parseRedisInfo(text string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			current = make(map[string]string)
+			sections[name] = current
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = make(map[string]string)
+			sections["default"] = current
+		}
+		current[name] = value
+	}
+	return sections
+}
+
+// dialRedis 建立连接并完成认证和选库
+func dialRedis(ctx context.Context, profile config.DatabaseProfile) (*serviceConn, error) {
+	conn, err := dialService(ctx, profile, redisDefaultPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.begin(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if profile.Password != "" {
+		args := []string{"AUTH", profile.Password}
+		if profile.Username != "" {
+			// Redis 6 ACL用户
+			args = []string{"AUTH", profile.Username, profile.Password}
+		}
+		if _, err := redisCommand(conn, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("认证失败: %v", err)
+		}
+	}
+	if profile.Database != "" && profile.Database != "0" {
+		if _, err := redisCommand(conn, "SELECT", profile.Database); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("选择数据库 %s 失败: %v", profile.Database, err)
+		}
+	}
+	return conn, nil
+}
+
+// redisCommand 以RESP数组发送命令并读取回复
+func redisCommand(conn *serviceConn, args ...string) (interface{}, error) {
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(conn, request.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(conn)
+}
+
+// readRedisReply 读取一个RESP回复：简单字符串和批量字符串为string，整数为int64，数组为[]interface{}，空值为nil
+func readRedisReply(conn *serviceConn) (interface{}, error) {
+	line, err := conn.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, fmt.Errorf("无效的Redis回复")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, serverError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的Redis整数回复: %s", line)
+		}
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("无效的Redis批量回复长度: %s", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		if size > redisMaxBulkSize {
+			return nil, fmt.Errorf("Redis批量回复长度 %d 超过上限 %d", size, redisMaxBulkSize)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(conn.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("无效的Redis数组长度: %s", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		if count > redisMaxArrayLength {
+			return nil, fmt.Errorf("Redis数组长度 %d 超过上限 %d", count, redisMaxArrayLength)
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRedisReply(conn)
+			if _, ok := err.(serverError); err != nil && !ok {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("不支持的Redis回复类型: %q", line[0])
+	}
+}
+
+// redisInfoField 返回读取INFO中指定字段的处理函数
+func redisInfoField(section, field string) redisHandler {
+	return func(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+		return r.infoValue(ctx, profile, section, field)
+	}
+}
+
+// redisInfoJSON redis.get_info
+func redisInfoJSON(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	sections, err := r.infoSections(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return marshalResult(sections)
+}
+
+// redisInfoParam redis.info[profile,section,field]
+func redisInfoParam(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	field, err := requireParam(params, 1, "field")
+	if err != nil {
+		return nil, err
+	}
+	return r.infoValue(ctx, profile, params[0], field)
+}
+
+// redisHitRatio redis.hit_ratio，由keyspace_hits和keyspace_misses计算
+func redisHitRatio(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	hits, err := r.infoValue(ctx, profile, "stats", "keyspace_hits")
+	if err != nil {
+		return nil, err
+	}
+	misses, err := r.infoValue(ctx, profile, "stats", "keyspace_misses")
+	if err != nil {
+		return nil, err
+	}
+	h, _ := strconv.ParseFloat(hits, 64)
+	m, _ := strconv.ParseFloat(misses, 64)
+	if h+m == 0 {
+		return 100.0, nil
+	}
+	return h / (h + m) * 100, nil
+}
+
+// redisKeyspace redis.keyspace[profile,db,field]，库中没有key时为0
+func redisKeyspace(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	db, err := requireParam(params, 0, "db")
+	if err != nil {
+		return nil, err
+	}
+	field, err := requireParam(params, 1, "field")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(db, "db") {
+		db = "db" + db
+	}
+
+	sections, err := r.infoSections(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	// 格式: db0:keys=1,expires=0,avg_ttl=0
+	line, exists := sections["keyspace"][db]
+	if !exists {
+		return 0, nil
+	}
+	for _, pair := range strings.Split(line, ",") {
+		name, value, _ := strings.Cut(pair, "=")
+		if name == field {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("%s 中不存在字段 %s", db, field)
+}
+
+// redisLinkUp redis.replication.link_up，主库上返回错误
+func redisLinkUp(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	role, err := r.infoValue(ctx, profile, "replication", "role")
+	if err != nil {
+		return nil, err
+	}
+	if role == "master" {
+		return nil, fmt.Errorf("不是从库")
+	}
+	status, err := r.infoValue(ctx, profile, "replication", "master_link_status")
+	if err != nil {
+		return nil, err
+	}
+	if status == "up" {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// redisSlowlogCount redis.slowlog.count
+func redisSlowlogCount(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	return r.do(ctx, profile, "SLOWLOG", "LEN")
+}
+
+// redisConfig redis.config[profile,parameter]
+func redisConfig(r *RedisCollector, ctx context.Context, profile string, params []string) (interface{}, error) {
+	parameter, err := requireParam(params, 0, "parameter")
+	if err != nil {
+		return nil, err
+	}
+	reply, err := r.do(ctx, profile, "CONFIG", "GET", parameter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 回复为 [名称, 值, ...]，名称支持通配符时可能返回多组
+	items, _ := reply.([]interface{})
+	values := make(map[string]interface{}, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		values[fmt.Sprintf("%v", items[i])] = items[i+1]
+	}
+	if value, exists := values[parameter]; exists {
+		return value, nil
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("配置参数 %s 不存在", parameter)
+	}
+	return marshalResult(values)
+}
@@ -0,0 +1,325 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-agent/pkg/config"
+)
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      interface{}
+		wantErr   bool
+		serverErr bool
+	}{
+		{name: "简单字符串", input: "+OK\r\n", want: "OK"},
+		{name: "错误", input: "-ERR unknown command\r\n", wantErr: true, serverErr: true},
+		{name: "整数", input: ":42\r\n", want: int64(42)},
+		{name: "批量字符串", input: "$5\r\nhello\r\n", want: "hello"},
+		{name: "包含换行的批量字符串", input: "$7\r\na\r\nb:cd\r\n", want: "a\r\nb:cd"},
+		{name: "空批量字符串", input: "$0\r\n\r\n", want: ""},
+		{name: "空值", input: "$-1\r\n", want: nil},
+		{name: "数组", input: "*3\r\n$1\r\na\r\n:1\r\n*1\r\n+b\r\n", want: []interface{}{"a", int64(1), []interface{}{"b"}}},
+		{name: "空数组", input: "*0\r\n", want: []interface{}{}},
+		{name: "空值数组", input: "*-1\r\n", want: nil},
+		{name: "数组中的错误元素", input: "*2\r\n-ERR e\r\n+OK\r\n", want: []interface{}{nil, "OK"}},
+		{name: "无效整数", input: ":x\r\n", wantErr: true},
+		{name: "无效长度", input: "$x\r\n", wantErr: true},
+		{name: "批量字符串超过上限", input: fmt.Sprintf("$%d\r\n", redisMaxBulkSize+1), wantErr: true},
+		{name: "数组超过上限", input: fmt.Sprintf("*%d\r\n", redisMaxArrayLength+1), wantErr: true},
+		{name: "批量字符串不完整", input: "$10\r\nabc", wantErr: true},
+		{name: "未知类型", input: "?x\r\n", wantErr: true},
+		{name: "空行", input: "\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &serviceConn{reader: bufio.NewReader(strings.NewReader(tt.input))}
+			got, err := readRedisReply(conn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readRedisReply(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if _, ok := err.(serverError); ok != tt.serverErr {
+				t.Errorf("readRedisReply(%q) error = %T, want serverError %v", tt.input, err, tt.serverErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readRedisReply(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRedisInfo(t *testing.T) {
+	sections := parseRedisInfo("# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n\r\n# Keyspace\r\ndb0:keys=5,expires=1,avg_ttl=0\r\n")
+	want := map[string]map[string]string{
+		"server":   {"redis_version": "7.2.4", "redis_mode": "standalone"},
+		"keyspace": {"db0": "keys=5,expires=1,avg_ttl=0"},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("parseRedisInfo = %v, want %v", sections, want)
+	}
+}
+
+// redisInfoReply 模拟服务返回的INFO ALL内容
+const redisInfoReply = "# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:3600\r\n\r\n" +
+	"# Clients\r\nconnected_clients:12\r\n\r\n" +
+	"# Stats\r\nkeyspace_hits:90\r\nkeyspace_misses:10\r\n\r\n" +
+	"# Replication\r\nrole:master\r\nconnected_slaves:0\r\n\r\n" +
+	"# Keyspace\r\ndb0:keys=5,expires=1,avg_ttl=0\r\n"
+
+// mockRedis 模拟Redis服务，记录收到的命令
+type mockRedis struct {
+	*mockServer
+	username, password string
+	mu                 sync.Mutex
+	commands           []string
+}
+
+// startMockRedis 启动模拟Redis服务，设置了password时要求先认证
+func startMockRedis(t *testing.T, username, password string) *mockRedis {
+	m := &mockRedis{username: username, password: password}
+	m.mockServer = startMockServer(t, m.serve)
+	return m
+}
+
+// serve 处理一个连接上的RESP命令
+func (m *mockRedis) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	authed := m.password == ""
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.commands = append(m.commands, strings.Join(args, " "))
+		m.mu.Unlock()
+
+		command := strings.ToUpper(args[0])
+		var reply string
+		switch {
+		case command == "AUTH":
+			// 只有密码时为默认用户，Redis 6 ACL用户为 AUTH username password
+			credentials := strings.Join(args[1:], " ")
+			if credentials == strings.TrimSpace(m.username+" "+m.password) {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid username-password pair or user is disabled.\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case command == "PING":
+			reply = "+PONG\r\n"
+		case command == "SELECT":
+			reply = "+OK\r\n"
+		case command == "INFO":
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(redisInfoReply), redisInfoReply)
+		case command == "SLOWLOG" && len(args) == 2 && strings.EqualFold(args[1], "LEN"):
+			reply = ":3\r\n"
+		case command == "CONFIG" && len(args) == 3 && args[2] == "maxmemory":
+			reply = "*2\r\n$9\r\nmaxmemory\r\n$9\r\n104857600\r\n"
+		case command == "CONFIG" && len(args) == 3 && args[2] == "max*":
+			reply = "*4\r\n$9\r\nmaxmemory\r\n$1\r\n0\r\n$10\r\nmaxclients\r\n$5\r\n10000\r\n"
+		case command == "CONFIG":
+			reply = "*0\r\n"
+		default:
+			reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// received 返回收到的全部命令
+func (m *mockRedis) received() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.commands...)
+}
+
+// readRESPCommand 读取客户端发送的RESP数组命令
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("无效的命令: %q", line)
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("无效的参数长度: %q", header)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+	return args, nil
+}
+
+// newTestRedisCollector 创建连接到模拟服务的Redis采集器
+func newTestRedisCollector(t *testing.T, profile config.DatabaseProfile) *RedisCollector {
+	collector := NewRedisCollector(config.RedisConfig{
+		Enabled:  true,
+		Profiles: map[string]config.DatabaseProfile{DefaultProfile: profile},
+	})
+	t.Cleanup(collector.Close)
+	return collector
+}
+
+func TestRedisCollectorKeys(t *testing.T) {
+	server := startMockRedis(t, "monitor", "secret")
+	profile := server.profile()
+	profile.Username = "monitor"
+	profile.Password = "secret"
+	profile.Database = "2"
+	collector := newTestRedisCollector(t, profile)
+
+	tests := []struct {
+		key     string
+		want    interface{}
+		wantErr bool
+	}{
+		{key: "redis.ping", want: 1},
+		{key: "redis.version", want: "7.2.4"},
+		{key: "redis.uptime[default]", want: "3600"},
+		{key: "redis.clients.connected", want: "12"},
+		{key: "redis.info[default,server,redis_version]", want: "7.2.4"},
+		{key: "redis.info[,,connected_clients]", want: "12"},
+		{key: "redis.info[default,server,nosuch]", wantErr: true},
+		{key: "redis.hit_ratio", want: 90.0},
+		{key: "redis.keyspace[0,keys]", want: "5"},
+		{key: "redis.keyspace[default,db0,expires]", want: "1"},
+		{key: "redis.keyspace[1,keys]", want: 0},
+		{key: "redis.replication.role", want: "master"},
+		{key: "redis.replication.link_up", wantErr: true},
+		{key: "redis.slowlog.count", want: int64(3)},
+		{key: "redis.config[maxmemory]", want: "104857600"},
+		{key: "redis.config[default,max*]", want: `{"maxclients":"10000","maxmemory":"0"}`},
+		{key: "redis.config[nosuch]", wantErr: true},
+		{key: "redis.version[other]", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := collector.Collect(context.Background(), tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Collect(%s) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Collect(%s) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+
+	commands := server.received()
+	if len(commands) < 2 || commands[0] != "AUTH monitor secret" || commands[1] != "SELECT 2" {
+		t.Errorf("连接建立时的命令 = %v, want AUTH monitor secret, SELECT 2", commands)
+	}
+	if n := server.accepts.Load(); n != 1 {
+		t.Errorf("建立了 %d 个连接，want 1（连接应被复用）", n)
+	}
+}
+
+func TestRedisCollectorAuthFailure(t *testing.T) {
+	server := startMockRedis(t, "monitor", "secret")
+	profile := server.profile()
+	profile.Username = "monitor"
+	profile.Password = "wrong"
+	collector := newTestRedisCollector(t, profile)
+
+	if got, err := collector.Collect(context.Background(), "redis.ping"); err != nil || got != 0 {
+		t.Errorf("Collect(redis.ping) = %v, %v, want 0", got, err)
+	}
+	_, err := collector.Collect(context.Background(), "redis.version")
+	if err == nil || !strings.Contains(err.Error(), "认证失败") || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Collect(redis.version) error = %v, want authentication failure", err)
+	}
+}
+
+func TestRedisPasswordOnlyAuth(t *testing.T) {
+	server := startMockRedis(t, "", "secret")
+	profile := server.profile()
+	profile.Password = "secret"
+	collector := newTestRedisCollector(t, profile)
+
+	if got, err := collector.Collect(context.Background(), "redis.ping"); err != nil || got != 1 {
+		t.Fatalf("Collect(redis.ping) = %v, %v, want 1", got, err)
+	}
+	if commands := server.received(); commands[0] != "AUTH secret" {
+		t.Errorf("first command = %q, want AUTH secret", commands[0])
+	}
+}
+
+// 服务端返回错误回复后连接仍然可用，应归还连接池继续使用
+func TestRedisPoolReuseAfterServerError(t *testing.T) {
+	server := startMockRedis(t, "", "")
+	collector := newTestRedisCollector(t, server.profile())
+
+	ctx := context.Background()
+	if _, err := collector.do(ctx, DefaultProfile, "NOSUCHCOMMAND"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Fatalf("do(NOSUCHCOMMAND) error = %v, want server error", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := collector.Collect(ctx, "redis.slowlog.count"); err != nil || got != int64(3) {
+			t.Fatalf("Collect(redis.slowlog.count) = %v, %v", got, err)
+		}
+	}
+	if n := server.accepts.Load(); n != 1 {
+		t.Errorf("建立了 %d 个连接，want 1", n)
+	}
+}
+
+// 空闲连接被服务端关闭后，用新连接重试一次
+func TestRedisPoolRedialsClosedConnection(t *testing.T) {
+	var mu sync.Mutex
+	served := 0
+	server := startMockServer(t, func(conn net.Conn) {
+		mu.Lock()
+		served++
+		first := served == 1
+		mu.Unlock()
+
+		reader := bufio.NewReader(conn)
+		for {
+			if _, err := readRESPCommand(reader); err != nil {
+				return
+			}
+			io.WriteString(conn, "+PONG\r\n")
+			if first {
+				// 第一个连接回复后即关闭，模拟服务端超时断开空闲连接
+				return
+			}
+		}
+	})
+	collector := newTestRedisCollector(t, server.profile())
+
+	for i := 0; i < 2; i++ {
+		if got, err := collector.Collect(context.Background(), "redis.ping"); err != nil || got != 1 {
+			t.Fatalf("Collect(redis.ping) #%d = %v, %v, want 1", i+1, got, err)
+		}
+	}
+	if n := server.accepts.Load(); n != 2 {
+		t.Errorf("建立了 %d 个连接，want 2", n)
+	}
+}
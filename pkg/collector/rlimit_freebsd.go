@@ -0,0 +1,15 @@
+package collector
+
+import (
+	"math"
+	"syscall"
+)
+
+// setRlimit 将当前进程的资源上限（软、硬限制）都设置为limit，FreeBSD的Rlimit字段为int64
+func setRlimit(resource int, limit uint64) error {
+	value := int64(math.MaxInt64)
+	if limit < math.MaxInt64 {
+		value = int64(limit)
+	}
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value})
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !freebsd
+
+package collector
+
+import "syscall"
+
+// setRlimit 将当前进程的资源上限（软、硬限制）都设置为limit
+func setRlimit(resource int, limit uint64) error {
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: limit, Max: limit})
+}
@@ -3,16 +3,29 @@ package collector
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"go-agent/pkg/config"
 )
 
+// scriptInterpreters 以脚本文件为第一个参数的解释器，执行前同时检查该脚本文件的权限
+var scriptInterpreters = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true,
+	"python": true, "python3": true, "perl": true, "ruby": true, "php": true, "node": true,
+	"powershell": true, "pwsh": true,
+}
+
 // ScriptCollector 脚本执行采集器
 type ScriptCollector struct {
-	enabled bool
-	scripts []string
-	timeout time.Duration
+	enabled     bool
+	scripts     []string
+	timeout     time.Duration
+	options     execOptions // 执行用户、环境变量和工作目录
+	allowUnsafe bool        // 允许执行所有用户可写或属于其他用户的脚本
 }
 
 // ScriptMetrics 脚本执行结果
@@ -20,20 +33,45 @@ type ScriptMetrics struct {
 	Timestamp time.Time `json:"timestamp"`
 	Script    string    `json:"script"`
 	Output    string    `json:"output"`
+	Stderr    string    `json:"stderr,omitempty"`
 	Error     string    `json:"error,omitempty"`
 	ExitCode  int       `json:"exit_code"`
 	Duration  float64   `json:"duration"`
 }
 
 // NewScriptCollector 创建脚本采集器
-func NewScriptCollector(enabled bool, scripts []string, timeout time.Duration) *ScriptCollector {
+func NewScriptCollector(cfg config.ScriptConfig) *ScriptCollector {
 	return &ScriptCollector{
-		enabled: enabled,
-		scripts: scripts,
-		timeout: timeout,
+		enabled:     cfg.Enabled,
+		scripts:     cfg.Scripts,
+		timeout:     cfg.Timeout,
+		options:     scriptExecOptions(cfg),
+		allowUnsafe: cfg.AllowUnsafeScript,
+	}
+}
+
+// scriptExecOptions 脚本采集器配置的执行用户、环境变量和工作目录
+func scriptExecOptions(cfg config.ScriptConfig) execOptions {
+	return execOptions{
+		runAs:     cfg.RunAs,
+		env:       cfg.Env,
+		allowlist: cfg.EnvAllowlist,
+		workDir:   cfg.WorkDir,
 	}
 }
 
+// CheckScriptConfig 检查脚本采集器的环境变量和执行用户配置
+func CheckScriptConfig(file string, cfg config.ScriptConfig) []config.Problem {
+	if !cfg.Enabled {
+		return nil
+	}
+	problems := &config.Problems{File: file}
+	if err := scriptExecOptions(cfg).check(); err != nil {
+		problems.Add("collect.script", "%v", err)
+	}
+	return problems.List()
+}
+
 // Collect 执行脚本并采集结果
 func (c *ScriptCollector) Collect(ctx context.Context) ([]*ScriptMetrics, error) {
 	if !c.enabled {
@@ -61,10 +99,8 @@ func (c *ScriptCollector) Collect(ctx context.Context) ([]*ScriptMetrics, error)
 	return results, nil
 }
 
-// executeScript 执行单个脚本
+// executeScript 执行单个脚本，stdout作为输出，失败时Error包含原因、退出码和stderr摘要
 func (c *ScriptCollector) executeScript(ctx context.Context, script string) (*ScriptMetrics, error) {
-	startTime := time.Now()
-
 	// 创建带超时的上下文
 	execCtx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
@@ -77,50 +113,93 @@ func (c *ScriptCollector) executeScript(ctx context.Context, script string) (*Sc
 
 	// 创建命令
 	cmd := exec.CommandContext(execCtx, args[0], args[1:]...)
+	if !c.allowUnsafe {
+		if err := c.checkScriptFiles(cmd, args); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.options.apply(cmd); err != nil {
+		return nil, err
+	}
 
 	// 执行命令
-	output, err := cmd.CombinedOutput()
+	result, err := runExec(execCtx, cmd, "脚本", execRules{maxOutput: defaultMaxOutput})
+	metrics := &ScriptMetrics{
+		Timestamp: time.Now(),
+		Script:    script,
+		Output:    strings.TrimSpace(result.Stdout),
+		Stderr:    strings.TrimSpace(result.Stderr),
+		ExitCode:  result.ExitCode,
+		Duration:  result.Duration.Seconds(),
+	}
+	if err != nil {
+		metrics.Error = err.Error()
+	}
+	return metrics, nil
+}
 
-	// 计算执行时间
-	duration := time.Since(startTime).Seconds()
+// checkScriptFiles 检查要执行的程序，以及解释器执行的脚本文件（如 sh /opt/check.sh）的权限
+func (c *ScriptCollector) checkScriptFiles(cmd *exec.Cmd, args []string) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	return checkScriptArgs(cmd.Path, args, c.options.workDir)
+}
 
-	// 获取退出码
-	exitCode := 0
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			exitCode = exitError.ExitCode()
-		} else {
-			exitCode = -1
+// checkScriptArgs 检查程序program，以及args[0]为解释器时其执行的脚本文件的权限，相对路径按工作目录workDir解析
+func checkScriptArgs(program string, args []string, workDir string) error {
+	files := []string{resolvePath(program, workDir)}
+	name := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+	if scriptInterpreters[name] {
+		for _, arg := range args[1:] {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			file := resolvePath(arg, workDir)
+			if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+				files = append(files, file)
+			}
+			break
 		}
 	}
 
-	// 处理输出
-	outputStr := strings.TrimSpace(string(output))
+	for _, file := range files {
+		if err := checkScriptFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// 检查是否超时
-	if execCtx.Err() == context.DeadlineExceeded {
-		return &ScriptMetrics{
-			Timestamp: time.Now(),
-			Script:    script,
-			Error:     "脚本执行超时",
-			ExitCode:  -1,
-			Duration:  duration,
-		}, nil
+// checkShellScript 命令的第一个词是脚本路径（含 /）或解释器（如 bash /opt/check.sh）时检查该脚本文件的权限；
+// 只检查第一个简单命令，管道、&& 等之后的命令和命令内部再调用的脚本不检查
+func checkShellScript(command, workDir string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	if strings.Contains(args[0], "/") {
+		if info, err := os.Stat(resolvePath(args[0], workDir)); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		return checkScriptArgs(args[0], args, workDir)
+	}
+	if !scriptInterpreters[args[0]] {
+		return nil
+	}
+	program, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil
 	}
+	return checkScriptArgs(program, args, workDir)
+}
 
-	return &ScriptMetrics{
-		Timestamp: time.Now(),
-		Script:    script,
-		Output:    outputStr,
-		Error: func() string {
-			if err != nil {
-				return err.Error()
-			}
-			return ""
-		}(),
-		ExitCode: exitCode,
-		Duration: duration,
-	}, nil
+// resolvePath 相对路径按工作目录解析
+func resolvePath(path, workDir string) string {
+	if filepath.IsAbs(path) || workDir == "" {
+		return path
+	}
+	return filepath.Join(workDir, path)
 }
 
 // parseScriptCommand 解析脚本命令
@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
+)
+
+// DefaultProfile key未指定连接配置名称时使用的连接配置
+const DefaultProfile = "default"
+
+// ServiceCollector 数据库、缓存等服务的原生采集器，按key前缀处理一族监控项
+type ServiceCollector interface {
+	// Name 采集器名称，同时是key前缀和调度器中的采集器类别，如 mysql
+	Name() string
+	// HasKey 是否支持该key
+	HasKey(itemKey string) bool
+	// Collect 采集key对应的原始值
+	Collect(ctx context.Context, itemKey string) (interface{}, error)
+	// Keys 返回支持的全部key说明
+	Keys() []ServiceKey
+	// SetCredentials 设置凭据库，连接配置中的credential从中读取
+	SetCredentials(vault *credential.Vault)
+	// Close 关闭连接
+	Close()
+}
+
+// ServiceKey 原生采集器支持的key说明
+type ServiceKey struct {
+	Key         string // 含参数说明，如 mysql.db.size[profile,schema]，profile可省略
+	Description string
+	Units       string
+	ValueType   string
+}
+
+// serviceKey 原生采集器内部的key定义
+type serviceKey struct {
+	params      string // 参数说明，如 [profile,schema]
+	description string
+	units       string
+	valueType   string
+}
+
+// serviceKeyList 按key名称排序生成key说明
+func serviceKeyList(defs map[string]serviceKey) []ServiceKey {
+	keys := make([]ServiceKey, 0, len(defs))
+	for name, def := range defs {
+		keys = append(keys, ServiceKey{
+			Key:         name + def.params,
+			Description: def.description,
+			Units:       def.units,
+			ValueType:   def.valueType,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// splitServiceKey 拆分key名称和参数
+func splitServiceKey(itemKey string) (string, []string) {
+	name, params, _ := splitItemKey(itemKey)
+	return name, params
+}
+
+// arity key定义中连接配置名称之外的参数个数
+func (k serviceKey) arity() int {
+	return strings.Count(k.params, ",")
+}
+
+// profileParams 拆分连接配置名称和其余参数：参数多于key定义时第一个参数为连接配置名称（为空时为default），
+// 否则使用default，如 mysql.db.size[shop] 等同于 mysql.db.size[default,shop]
+func (k serviceKey) profileParams(params []string) (string, []string) {
+	if len(params) <= k.arity() {
+		return DefaultProfile, params
+	}
+	if params[0] == "" {
+		return DefaultProfile, params[1:]
+	}
+	return params[0], params[1:]
+}
+
+// resolveProfile 查找连接配置并从凭据库填入用户名和密码
+func resolveProfile(profiles map[string]config.DatabaseProfile, name string, vault *credential.Vault) (config.DatabaseProfile, error) {
+	profile, exists := profiles[name]
+	if !exists {
+		// viper读取配置时map的键会转换为小写
+		profile, exists = profiles[strings.ToLower(name)]
+	}
+	if !exists {
+		names := make([]string, 0, len(profiles))
+		for profileName := range profiles {
+			names = append(names, profileName)
+		}
+		sort.Strings(names)
+		return profile, fmt.Errorf("连接配置 %s 不存在（可用: %s）", name, strings.Join(names, ", "))
+	}
+
+	if profile.Credential != "" {
+		if vault == nil {
+			return profile, fmt.Errorf("连接配置 %s 引用了凭据 %s，但未加载凭据库", name, profile.Credential)
+		}
+		cred, exists := vault.Get(profile.Credential)
+		if !exists {
+			return profile, fmt.Errorf("凭据 %s 不存在", profile.Credential)
+		}
+		profile.Username = cred.Username
+		profile.Password = cred.Password
+	}
+	return profile, nil
+}
+
+// requireParam 返回第index个参数（不含连接配置名称），为空时返回错误
+func requireParam(params []string, index int, name string) (string, error) {
+	if index >= len(params) || params[index] == "" {
+		return "", fmt.Errorf("缺少参数 %s", name)
+	}
+	return params[index], nil
+}
+
+// CheckProfileCredentials 检查连接配置引用的凭据是否存在，prefix为配置路径，如 collect.mysql
+func CheckProfileCredentials(file, prefix string, profiles map[string]config.DatabaseProfile, vault *credential.Vault) []config.Problem {
+	problems := &config.Problems{File: file}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		credentialName := profiles[name].Credential
+		if credentialName == "" {
+			continue
+		}
+		path := fmt.Sprintf("%s.profiles.%s.credential", prefix, name)
+		if vault == nil {
+			problems.Add(path, "引用了凭据 %s，但未加载凭据库", credentialName)
+		} else if _, exists := vault.Get(credentialName); !exists {
+			problems.Add(path, "凭据 %s 不存在于 %s", credentialName, vault.Path())
+		}
+	}
+	return problems.List()
+}
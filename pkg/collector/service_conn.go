@@ -0,0 +1,207 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/config"
+)
+
+// 原生采集器的默认连接超时，上下文没有截止时间时使用
+const serviceDialTimeout = 10 * time.Second
+
+// serviceMaxIdle 每个连接配置保留的空闲连接数
+const serviceMaxIdle = 2
+
+// newTLSConfig 按证书文件创建TLS配置，mode为skip-verify时不校验服务器证书，serverName为空时使用host
+func newTLSConfig(mode, caFile, certFile, keyFile, serverName, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: mode == "skip-verify",
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA证书 %s 中没有有效的PEM证书", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// serviceConn Redis、Memcached等文本协议服务的连接
+type serviceConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// dialService 按连接配置建立TCP连接，tls为true或skip-verify时建立TLS连接
+func dialService(ctx context.Context, profile config.DatabaseProfile, defaultPort int) (*serviceConn, error) {
+	port := profile.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	addr := net.JoinHostPort(profile.Host, strconv.Itoa(port))
+
+	dialCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, serviceDialTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := strings.ToLower(profile.TLS)
+	if mode == "true" || mode == "skip-verify" {
+		tlsConfig, err := newTLSConfig(mode, profile.TLSCA, profile.TLSCert, profile.TLSKey, profile.TLSServerName, profile.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(dialCtx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS握手失败: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	return &serviceConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// begin 按上下文设置本次请求的读写截止时间
+func (c *serviceConn) begin(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(serviceDialTimeout)
+	}
+	return c.SetDeadline(deadline)
+}
+
+// readLine 读取一行并去掉行尾的\r\n
+func (c *serviceConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// connPool 按连接配置名称保留空闲连接，跨多次采集复用
+type connPool struct {
+	mu   sync.Mutex
+	idle map[string][]*serviceConn
+}
+
+// newConnPool 创建连接池
+func newConnPool() *connPool {
+	return &connPool{idle: make(map[string][]*serviceConn)}
+}
+
+// get 取出一个空闲连接，没有时返回nil
+func (p *connPool) get(profile string) *serviceConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.idle[profile]
+	if len(conns) == 0 {
+		return nil
+	}
+	conn := conns[len(conns)-1]
+	p.idle[profile] = conns[:len(conns)-1]
+	return conn
+}
+
+// put 归还正常的连接，空闲连接已满时关闭
+func (p *connPool) put(profile string, conn *serviceConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.idle[profile]) >= serviceMaxIdle {
+		conn.Close()
+		return
+	}
+	p.idle[profile] = append(p.idle[profile], conn)
+}
+
+// Close 关闭全部空闲连接
+func (p *connPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conns := range p.idle {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	p.idle = make(map[string][]*serviceConn)
+}
+
+// serverError 服务端返回的错误回复，连接仍可继续使用
+type serverError string
+
+// Error 实现error接口
+func (e serverError) Error() string {
+	return string(e)
+}
+
+// do 使用空闲连接（没有时新建）执行一次请求，正常完成后归还连接；空闲连接已失效时用新连接重试一次
+func (p *connPool) do(ctx context.Context, profile string, dial func(ctx context.Context) (*serviceConn, error), request func(conn *serviceConn) (interface{}, error)) (interface{}, error) {
+	conn := p.get(profile)
+	pooled := conn != nil
+	for {
+		if conn == nil {
+			var err error
+			if conn, err = dial(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		var value interface{}
+		err := conn.begin(ctx)
+		if err == nil {
+			value, err = request(conn)
+		}
+		if _, ok := err.(serverError); err == nil || ok {
+			p.put(profile, conn)
+			return value, err
+		}
+
+		conn.Close()
+		if !pooled || ctx.Err() != nil {
+			return nil, err
+		}
+		pooled = false
+		conn = nil
+	}
+}
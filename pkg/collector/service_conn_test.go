@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"go-agent/pkg/config"
+)
+
+// mockServer 进程内的TCP模拟服务，每个连接交给handle处理
+type mockServer struct {
+	listener net.Listener
+	accepts  atomic.Int32
+}
+
+// startMockServer 在127.0.0.1的随机端口启动模拟服务，测试结束时关闭
+func startMockServer(t *testing.T, handle func(conn net.Conn)) *mockServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动模拟服务失败: %v", err)
+	}
+
+	server := &mockServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.accepts.Add(1)
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Close 停止接受连接
+func (s *mockServer) Close() {
+	s.listener.Close()
+}
+
+// profile 返回指向模拟服务的连接配置
+func (s *mockServer) profile() config.DatabaseProfile {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return config.DatabaseProfile{Host: host, Port: n}
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PoolSettings 数据库连接池设置（command_mapping.yaml 的 settings.pool），时间单位为秒
+type PoolSettings struct {
+	MaxOpenConns        int `mapstructure:"max_open_conns"`        // 每个连接池的最大连接数
+	MaxIdleConns        int `mapstructure:"max_idle_conns"`        // 每个连接池保留的空闲连接数
+	ConnMaxLifetime     int `mapstructure:"conn_max_lifetime"`     // 连接最长使用时间
+	ConnMaxIdleTime     int `mapstructure:"conn_max_idle_time"`    // 连接最长空闲时间
+	HealthCheckInterval int `mapstructure:"health_check_interval"` // 连接池距上次检查超过该时间时先ping，失败则重建连接池
+	IdleTimeout         int `mapstructure:"idle_timeout"`          // 连接池超过该时间未被使用时关闭
+}
+
+// 连接池默认设置
+var defaultPoolSettings = PoolSettings{
+	MaxOpenConns:        2,
+	MaxIdleConns:        2,
+	ConnMaxLifetime:     300,
+	ConnMaxIdleTime:     120,
+	HealthCheckInterval: 60,
+	IdleTimeout:         600,
+}
+
+// withDefaults 未设置（为0）的字段使用默认值
+func (p PoolSettings) withDefaults() PoolSettings {
+	if p.MaxOpenConns == 0 {
+		p.MaxOpenConns = defaultPoolSettings.MaxOpenConns
+	}
+	if p.MaxIdleConns == 0 {
+		p.MaxIdleConns = defaultPoolSettings.MaxIdleConns
+	}
+	if p.ConnMaxLifetime == 0 {
+		p.ConnMaxLifetime = defaultPoolSettings.ConnMaxLifetime
+	}
+	if p.ConnMaxIdleTime == 0 {
+		p.ConnMaxIdleTime = defaultPoolSettings.ConnMaxIdleTime
+	}
+	if p.HealthCheckInterval == 0 {
+		p.HealthCheckInterval = defaultPoolSettings.HealthCheckInterval
+	}
+	if p.IdleTimeout == 0 {
+		p.IdleTimeout = defaultPoolSettings.IdleTimeout
+	}
+	return p
+}
+
+// pooledDB 一个DSN对应的连接池
+type pooledDB struct {
+	db          *sql.DB
+	lastChecked time.Time // 上次确认连接可用的时间
+	lastUsed    time.Time
+}
+
+// sqlConn 原生数据库采集器一次采集使用的连接
+type sqlConn struct {
+	profile string // 连接配置名称
+	dsn     string
+	db      *sql.DB
+}
+
+// sqlPool 按驱动和DSN复用数据库连接池，跨多次执行保持连接
+type sqlPool struct {
+	settings PoolSettings
+	pools    map[string]*pooledDB
+	mu       sync.Mutex
+}
+
+// newSQLPool 创建数据库连接池管理器
+func newSQLPool() *sqlPool {
+	return &sqlPool{
+		settings: defaultPoolSettings,
+		pools:    make(map[string]*pooledDB),
+	}
+}
+
+// Configure 更新连接池设置，已有连接池立即应用新的连接数和生存时间
+func (p *sqlPool) Configure(settings PoolSettings) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.settings = settings.withDefaults()
+	for _, pooled := range p.pools {
+		p.applySettings(pooled.db)
+	}
+}
+
+// Get 获取driver+dsn对应的连接池；新建或超过检查间隔的连接池会先ping，失败时重建一次
+// ping在锁外进行，一个不可达的数据库不会阻塞其他连接池
+func (p *sqlPool) Get(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	key := driver + "\x00" + dsn
+
+	p.mu.Lock()
+	now := time.Now()
+	p.closeIdle(now)
+	pooled, exists := p.pools[key]
+	if exists {
+		pooled.lastUsed = now
+		if now.Sub(pooled.lastChecked) < time.Duration(p.settings.HealthCheckInterval)*time.Second {
+			p.mu.Unlock()
+			return pooled.db, nil
+		}
+	}
+	p.mu.Unlock()
+
+	if exists {
+		if err := pooled.db.PingContext(ctx); err == nil {
+			p.mu.Lock()
+			pooled.lastChecked = time.Now()
+			p.mu.Unlock()
+			return pooled.db, nil
+		}
+		// 连接不可用（如数据库重启），丢弃整个连接池后重新连接
+		p.remove(key, pooled)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开连接失败: %v", err)
+	}
+	p.mu.Lock()
+	p.applySettings(db)
+	p.mu.Unlock()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接检查失败: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 并发执行时可能已有其他调用建立了连接池
+	if existing, exists := p.pools[key]; exists {
+		db.Close()
+		existing.lastUsed = time.Now()
+		return existing.db, nil
+	}
+	now = time.Now()
+	p.pools[key] = &pooledDB{db: db, lastChecked: now, lastUsed: now}
+	return db, nil
+}
+
+// remove 关闭并移除连接池（仍是同一个连接池时）
+func (p *sqlPool) remove(key string, pooled *pooledDB) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pools[key] == pooled {
+		delete(p.pools, key)
+		pooled.db.Close()
+	}
+}
+
+// Invalidate 连接出错时标记连接池需要在下次使用前重新检查
+func (p *sqlPool) Invalidate(driver, dsn string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pooled, exists := p.pools[driver+"\x00"+dsn]; exists {
+		pooled.lastChecked = time.Time{}
+	}
+}
+
+// Count 返回当前连接池数量
+func (p *sqlPool) Count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pools)
+}
+
+// Close 关闭全部连接池
+func (p *sqlPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, pooled := range p.pools {
+		pooled.db.Close()
+		delete(p.pools, key)
+	}
+}
+
+// closeIdle 关闭长时间未使用的连接池（如命令已从配置中删除），调用方需持有锁
+func (p *sqlPool) closeIdle(now time.Time) {
+	idleTimeout := time.Duration(p.settings.IdleTimeout) * time.Second
+	for key, pooled := range p.pools {
+		if now.Sub(pooled.lastUsed) > idleTimeout {
+			pooled.db.Close()
+			delete(p.pools, key)
+		}
+	}
+}
+
+// applySettings 应用连接数和生存时间设置，调用方需持有锁
+func (p *sqlPool) applySettings(db *sql.DB) {
+	db.SetMaxOpenConns(p.settings.MaxOpenConns)
+	db.SetMaxIdleConns(p.settings.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(p.settings.ConnMaxLifetime) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(p.settings.ConnMaxIdleTime) * time.Second)
+}
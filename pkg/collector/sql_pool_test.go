@@ -0,0 +1,253 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriverName 测试用数据库驱动，按DSN模拟数据库可用状态
+const fakeDriverName = "fakepool"
+
+func init() {
+	sql.Register(fakeDriverName, fakeDriver{})
+}
+
+// fakeServer 一个DSN对应的模拟数据库
+type fakeServer struct {
+	down      bool          // 数据库不可用时连接和ping都失败
+	pingDelay time.Duration // ping耗时，用于制造并发建立连接池的竞争
+	opened    int           // 已建立的连接数
+	closed    int           // 已关闭的连接数
+}
+
+var (
+	fakeServersMu sync.Mutex
+	fakeServers   = make(map[string]*fakeServer)
+)
+
+// newFakeServer 登记DSN对应的模拟数据库，测试结束时移除
+func newFakeServer(t *testing.T, dsn string) *fakeServer {
+	t.Helper()
+	server := &fakeServer{}
+	fakeServersMu.Lock()
+	fakeServers[dsn] = server
+	fakeServersMu.Unlock()
+	t.Cleanup(func() {
+		fakeServersMu.Lock()
+		delete(fakeServers, dsn)
+		fakeServersMu.Unlock()
+	})
+	return server
+}
+
+// setDown 设置模拟数据库是否可用
+func (s *fakeServer) setDown(down bool) {
+	fakeServersMu.Lock()
+	defer fakeServersMu.Unlock()
+	s.down = down
+}
+
+// open 返回仍未关闭的连接数
+func (s *fakeServer) open() int {
+	fakeServersMu.Lock()
+	defer fakeServersMu.Unlock()
+	return s.opened - s.closed
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fakeServersMu.Lock()
+	defer fakeServersMu.Unlock()
+	server, exists := fakeServers[dsn]
+	if !exists || server.down {
+		return nil, errors.New("connection refused")
+	}
+	server.opened++
+	return &fakeConn{server: server}, nil
+}
+
+type fakeConn struct {
+	server *fakeServer
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	fakeServersMu.Lock()
+	delay, down := c.server.pingDelay, c.server.down
+	fakeServersMu.Unlock()
+	time.Sleep(delay)
+	if down {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) Close() error {
+	fakeServersMu.Lock()
+	defer fakeServersMu.Unlock()
+	c.server.closed++
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestSQLPoolReusesPool(t *testing.T) {
+	server := newFakeServer(t, "reuse")
+	pool := newSQLPool()
+	defer pool.Close()
+
+	first, err := pool.Get(context.Background(), fakeDriverName, "reuse")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	second, err := pool.Get(context.Background(), fakeDriverName, "reuse")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if first != second || pool.Count() != 1 || server.open() != 1 {
+		t.Errorf("pool not reused: same=%v count=%d open=%d", first == second, pool.Count(), server.open())
+	}
+
+	pool.Close()
+	if pool.Count() != 0 || server.open() != 0 {
+		t.Errorf("after Close count=%d open=%d, want 0", pool.Count(), server.open())
+	}
+}
+
+// 连接出错后下次使用前重新检查：检查通过时继续使用，失败时丢弃连接池，数据库恢复后重建
+func TestSQLPoolHealthCheckAndRebuild(t *testing.T) {
+	server := newFakeServer(t, "rebuild")
+	pool := newSQLPool()
+	defer pool.Close()
+	ctx := context.Background()
+
+	db, err := pool.Get(ctx, fakeDriverName, "rebuild")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+
+	pool.Invalidate(fakeDriverName, "rebuild")
+	if again, err := pool.Get(ctx, fakeDriverName, "rebuild"); err != nil || again != db {
+		t.Fatalf("Get after Invalidate = %p, %v, want healthy pool %p", again, err, db)
+	}
+
+	server.setDown(true)
+	pool.Invalidate(fakeDriverName, "rebuild")
+	if _, err := pool.Get(ctx, fakeDriverName, "rebuild"); err == nil {
+		t.Fatal("Get with database down error = nil")
+	}
+	if pool.Count() != 0 {
+		t.Errorf("Count after failed check = %d, want 0", pool.Count())
+	}
+	if err := db.Ping(); err == nil || server.open() != 0 {
+		t.Errorf("old pool not closed: ping=%v open=%d", err, server.open())
+	}
+
+	server.setDown(false)
+	rebuilt, err := pool.Get(ctx, fakeDriverName, "rebuild")
+	if err != nil {
+		t.Fatalf("Get after recovery error: %v", err)
+	}
+	if rebuilt == db || pool.Count() != 1 {
+		t.Errorf("pool not rebuilt: same=%v count=%d", rebuilt == db, pool.Count())
+	}
+}
+
+// 超过检查间隔的连接池同样需要先检查
+func TestSQLPoolHealthCheckInterval(t *testing.T) {
+	server := newFakeServer(t, "interval")
+	pool := newSQLPool()
+	defer pool.Close()
+	ctx := context.Background()
+
+	db, err := pool.Get(ctx, fakeDriverName, "interval")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+
+	// 检查间隔内不ping，即使数据库已不可用
+	server.setDown(true)
+	if again, err := pool.Get(ctx, fakeDriverName, "interval"); err != nil || again != db {
+		t.Fatalf("Get within interval = %p, %v, want cached pool", again, err)
+	}
+
+	pool.mu.Lock()
+	pool.pools[fakeDriverName+"\x00interval"].lastChecked = time.Now().Add(-2 * time.Minute)
+	pool.mu.Unlock()
+	if _, err := pool.Get(ctx, fakeDriverName, "interval"); err == nil {
+		t.Error("Get after interval with database down error = nil")
+	}
+}
+
+func TestSQLPoolCloseIdle(t *testing.T) {
+	server := newFakeServer(t, "idle")
+	other := newFakeServer(t, "busy")
+	pool := newSQLPool()
+	defer pool.Close()
+	pool.Configure(PoolSettings{IdleTimeout: 60})
+
+	idle, err := pool.Get(context.Background(), fakeDriverName, "idle")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if _, err := pool.Get(context.Background(), fakeDriverName, "busy"); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+
+	pool.mu.Lock()
+	pool.pools[fakeDriverName+"\x00idle"].lastUsed = time.Now().Add(-2 * time.Minute)
+	pool.closeIdle(time.Now())
+	pool.mu.Unlock()
+
+	if pool.Count() != 1 || server.open() != 0 || other.open() != 1 {
+		t.Errorf("after closeIdle count=%d idle open=%d busy open=%d, want 1, 0, 1", pool.Count(), server.open(), other.open())
+	}
+	if err := idle.Ping(); err == nil {
+		t.Error("idle pool not closed")
+	}
+}
+
+// 并发获取同一个尚不存在的连接池时，只保留一个连接池，其余新建的连接池被关闭
+func TestSQLPoolConcurrentCreate(t *testing.T) {
+	server := newFakeServer(t, "race")
+	server.pingDelay = 50 * time.Millisecond
+	pool := newSQLPool()
+	defer pool.Close()
+
+	const workers = 8
+	dbs := make([]*sql.DB, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := pool.Get(context.Background(), fakeDriverName, "race")
+			if err != nil {
+				t.Errorf("Get error: %v", err)
+				return
+			}
+			dbs[i] = db
+		}(i)
+	}
+	wg.Wait()
+
+	for i, db := range dbs {
+		if db != dbs[0] {
+			t.Fatalf("worker %d got a different pool", i)
+		}
+	}
+	if pool.Count() != 1 || server.open() != 1 {
+		t.Errorf("after concurrent Get count=%d open=%d, want 1, 1", pool.Count(), server.open())
+	}
+}
@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQL查询结果的处理方式（命令配置的result字段）
+const (
+	ResultValue = "value" // 第一行中的一个值：column指定列名，默认第一列
+	ResultMap   = "map"   // 名称/值两列的多行结果转换为JSON对象，如 SHOW GLOBAL STATUS
+	ResultRow   = "row"   // 第一行转换为JSON对象（列名为字段），如 SHOW SLAVE STATUS
+	ResultJSON  = "json"  // 全部行转换为JSON数组
+)
+
+// resultModes 支持的结果处理方式，为空等同于value
+var resultModes = map[string]bool{
+	"":          true,
+	ResultValue: true,
+	ResultMap:   true,
+	ResultRow:   true,
+	ResultJSON:  true,
+}
+
+// shapeRows 按命令配置的result处理查询结果
+func shapeRows(rows *sql.Rows, config CommandConfig) (interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列信息失败: %v", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("查询结果为空")
+	}
+
+	switch strings.ToLower(config.Result) {
+	case ResultMap:
+		return shapeMap(rows, columns, config)
+	case ResultRow:
+		records, err := scanRows(rows, columns, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("查询结果为空")
+		}
+		return marshalResult(rowObject(columns, records[0]))
+	case ResultJSON:
+		records, err := scanRows(rows, columns, 0)
+		if err != nil {
+			return nil, err
+		}
+		objects := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			objects = append(objects, rowObject(columns, record))
+		}
+		return marshalResult(objects)
+	default:
+		return shapeValue(rows, columns, config)
+	}
+}
+
+// shapeValue 返回第一行中指定列（默认第一列）的值
+func shapeValue(rows *sql.Rows, columns []string, config CommandConfig) (interface{}, error) {
+	index := 0
+	if config.Column != "" {
+		var err error
+		if index, err = columnIndex(columns, config.Column); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := scanRows(rows, columns, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("查询结果为空")
+	}
+
+	// 返回原始值，由监控项的值类型决定如何转换
+	val := records[0][index]
+	if val == nil {
+		return 0, nil
+	}
+	return val, nil
+}
+
+// shapeMap 将名称/值两列的多行结果转换为JSON对象，key_column默认第一列，column默认第二列
+func shapeMap(rows *sql.Rows, columns []string, config CommandConfig) (interface{}, error) {
+	keyIndex, valueIndex := 0, 1
+	var err error
+	if config.KeyColumn != "" {
+		if keyIndex, err = columnIndex(columns, config.KeyColumn); err != nil {
+			return nil, err
+		}
+	}
+	if config.Column != "" {
+		if valueIndex, err = columnIndex(columns, config.Column); err != nil {
+			return nil, err
+		}
+	} else if len(columns) < 2 {
+		return nil, fmt.Errorf("map结果需要名称和值两列，查询只返回了 %d 列", len(columns))
+	}
+
+	records, err := scanRows(rows, columns, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(records))
+	for _, record := range records {
+		if record[keyIndex] == nil {
+			continue
+		}
+		result[fmt.Sprintf("%v", record[keyIndex])] = record[valueIndex]
+	}
+	return marshalResult(result)
+}
+
+// scanRows 读取最多limit行（0表示全部），[]byte转换为字符串
+func scanRows(rows *sql.Rows, columns []string, limit int) ([][]interface{}, error) {
+	var records [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("扫描结果失败: %v", err)
+		}
+		for i, value := range values {
+			values[i] = sqlValue(value)
+		}
+
+		records = append(records, values)
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取结果失败: %v", err)
+	}
+	return records, nil
+}
+
+// sqlValue 将驱动返回的值转换为可直接使用和序列化的值
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, int64, float64, bool:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// rowObject 以列名为字段名组成一行的对象
+func rowObject(columns []string, record []interface{}) map[string]interface{} {
+	object := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		object[column] = record[i]
+	}
+	return object
+}
+
+// columnIndex 按列名（不区分大小写）查找列
+func columnIndex(columns []string, name string) (int, error) {
+	for i, column := range columns {
+		if strings.EqualFold(column, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("结果中不存在列 %s（可用列: %s）", name, strings.Join(columns, ", "))
+}
+
+// marshalResult 将结果序列化为JSON文本
+func marshalResult(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("序列化查询结果失败: %v", err)
+	}
+	return string(data), nil
+}
@@ -2,6 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,21 +18,62 @@ type Config struct {
 	Collect       CollectConfig        `mapstructure:"collect"`
 	Transport     TransportConfig      `mapstructure:"transport"`
 	DeviceMonitor *DeviceMonitorConfig `mapstructure:"device_monitor"`
+	Items         ItemsConfig          `mapstructure:"items"`
+	Credentials   CredentialsConfig    `mapstructure:"credentials"`
 	Log           LogConfig            `mapstructure:"log"`
 }
 
 // AgentConfig 代理配置
 type AgentConfig struct {
-	Name     string        `mapstructure:"name"`
-	Interval time.Duration `mapstructure:"interval"`
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Name           string         `mapstructure:"name"`
+	Interval       time.Duration  `mapstructure:"interval"`
+	Timeout        time.Duration  `mapstructure:"timeout"`
+	Timezone       string         `mapstructure:"timezone"` // 计算自定义间隔所用时区（IANA名称），为空时使用本机时区
+	Schedule       ScheduleConfig `mapstructure:"schedule"`
+	CommandMapping string         `mapstructure:"command_mapping"` // 命令映射配置文件路径
+	StatusListen   string         `mapstructure:"status_listen"`   // 本地状态接口监听地址，为空时不启用
+	WatchConfig    bool           `mapstructure:"watch_config"`    // 监听配置文件变化并自动重新加载
+}
+
+// ScheduleConfig 监控项调度配置
+type ScheduleConfig struct {
+	Spread          bool           `mapstructure:"spread"`           // 按agentID+itemID哈希在间隔内分散执行时间
+	Align           bool           `mapstructure:"align"`            // 按墙上时间对齐（如60s间隔在整分钟+偏移处执行）
+	StartupJitter   time.Duration  `mapstructure:"startup_jitter"`   // 首次执行前的随机延迟上限
+	Workers         int            `mapstructure:"workers"`          // 采集工作协程数量
+	QueueSize       int            `mapstructure:"queue_size"`       // 待执行队列长度
+	OverlapPolicy   string         `mapstructure:"overlap_policy"`   // 上次采集未完成时的处理策略: skip, coalesce
+	CollectorLimits map[string]int `mapstructure:"collector_limits"` // 每类采集器的最大并发数，如 command: 4
+}
+
+// 监控项配置来源
+const (
+	ItemSourceAPI  = "api"  // 从数据中心获取监控项
+	ItemSourceFile = "file" // 从本地YAML文件加载监控项
+)
+
+// ItemsConfig 监控项配置来源
+type ItemsConfig struct {
+	Source string `mapstructure:"source"` // api 或 file
+	File   string `mapstructure:"file"`   // source为file时的监控项文件路径
+}
+
+// CredentialsConfig 加密凭据库配置，命令映射中通过 credential: 名称 引用凭据
+type CredentialsConfig struct {
+	File       string `mapstructure:"file"`       // 凭据库文件路径
+	KeyFile    string `mapstructure:"key_file"`   // 密钥文件路径，优先于口令
+	Passphrase string `mapstructure:"passphrase"` // 口令，建议通过 GOAGENT_CREDENTIALS_PASSPHRASE 或 ${file:...} 提供
 }
 
 // CollectConfig 采集配置
 type CollectConfig struct {
-	System SystemConfig `mapstructure:"system"`
-	SNMP   SNMPConfig   `mapstructure:"snmp"`
-	Script ScriptConfig `mapstructure:"script"`
+	System    SystemConfig    `mapstructure:"system"`
+	SNMP      SNMPConfig      `mapstructure:"snmp"`
+	Script    ScriptConfig    `mapstructure:"script"`
+	MySQL     MySQLConfig     `mapstructure:"mysql"`
+	Postgres  PostgresConfig  `mapstructure:"postgres"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Memcached MemcachedConfig `mapstructure:"memcached"`
 }
 
 // SystemConfig 系统指标采集配置
@@ -53,6 +99,57 @@ type ScriptConfig struct {
 	Enabled bool          `mapstructure:"enabled"`
 	Scripts []string      `mapstructure:"scripts"`
 	Timeout time.Duration `mapstructure:"timeout"`
+
+	// 执行用户、环境变量和工作目录，与命令映射中的同名配置相同
+	RunAs             string   `mapstructure:"run_as"`              // 以指定用户执行（用户名或uid，可加 :组名 或 :gid），仅Linux/Unix
+	Env               []string `mapstructure:"env"`                 // 显式设置的环境变量，NAME=value 形式
+	EnvAllowlist      []string `mapstructure:"env_allowlist"`       // 从代理环境继承的变量名，未配置时使用默认列表，* 表示全部
+	WorkDir           string   `mapstructure:"workdir"`             // 工作目录，默认为代理的工作目录
+	AllowUnsafeScript bool     `mapstructure:"allow_unsafe_script"` // 允许执行所有用户可写或属于其他用户的脚本
+}
+
+// DatabaseProfile 命名的连接配置，原生采集器的key通过第一个参数引用，如 mysql.ping[prod]
+type DatabaseProfile struct {
+	Host          string `mapstructure:"host"`
+	Port          int    `mapstructure:"port"`
+	Username      string `mapstructure:"username"` // Redis为ACL用户名（Redis 6+），仅设置password时使用默认用户
+	Password      string `mapstructure:"password"`
+	Credential    string `mapstructure:"credential"`      // 凭据库中的凭据名称，替代 username/password
+	Database      string `mapstructure:"database"`        // Redis为库编号
+	TLS           string `mapstructure:"tls"`             // MySQL: false, true, skip-verify, preferred；Redis/Memcached: false, true, skip-verify
+	SSLMode       string `mapstructure:"sslmode"`         // PostgreSQL: disable, require, verify-ca, verify-full
+	TLSCA         string `mapstructure:"tls_ca"`          // CA证书文件
+	TLSCert       string `mapstructure:"tls_cert"`        // 客户端证书文件
+	TLSKey        string `mapstructure:"tls_key"`         // 客户端私钥文件
+	TLSServerName string `mapstructure:"tls_server_name"` // 校验证书时使用的服务器名称，默认为host
+}
+
+// MySQLConfig 原生MySQL/MariaDB采集配置
+type MySQLConfig struct {
+	Enabled  bool                       `mapstructure:"enabled"`
+	CacheTTL time.Duration              `mapstructure:"cache_ttl"` // 状态变量缓存时间，同一连接的状态类key共用一次查询
+	Profiles map[string]DatabaseProfile `mapstructure:"profiles"`  // 连接配置，未指定时使用default
+}
+
+// PostgresConfig 原生PostgreSQL采集配置
+type PostgresConfig struct {
+	Enabled  bool                       `mapstructure:"enabled"`
+	CacheTTL time.Duration              `mapstructure:"cache_ttl"` // pg_stat_* 统计缓存时间，同一连接的统计类key共用一次查询
+	Profiles map[string]DatabaseProfile `mapstructure:"profiles"`  // 连接配置，未指定时使用default
+}
+
+// RedisConfig 原生Redis采集配置
+type RedisConfig struct {
+	Enabled  bool                       `mapstructure:"enabled"`
+	CacheTTL time.Duration              `mapstructure:"cache_ttl"` // INFO结果缓存时间，同一连接的INFO类key共用一次查询
+	Profiles map[string]DatabaseProfile `mapstructure:"profiles"`  // 连接配置，未指定时使用default
+}
+
+// MemcachedConfig 原生Memcached采集配置
+type MemcachedConfig struct {
+	Enabled  bool                       `mapstructure:"enabled"`
+	CacheTTL time.Duration              `mapstructure:"cache_ttl"` // stats结果缓存时间
+	Profiles map[string]DatabaseProfile `mapstructure:"profiles"`  // 连接配置，只使用host、port和tls相关字段
 }
 
 // TransportConfig 数据传输配置
@@ -93,32 +190,65 @@ type DeviceMonitorConfig struct {
 	ConfigRefreshInterval time.Duration `mapstructure:"config_refresh_interval"`
 	MetricsBufferSize     int           `mapstructure:"metrics_buffer_size"`
 	MetricsFlushInterval  time.Duration `mapstructure:"metrics_flush_interval"`
+	ConfigCachePath       string        `mapstructure:"config_cache_path"`    // 离线配置缓存文件，为空时不缓存
+	ConfigWatch           bool          `mapstructure:"config_watch"`         // 长轮询等待配置变更通知
+	ConfigWatchTimeout    time.Duration `mapstructure:"config_watch_timeout"` // 单次长轮询等待时间
+	Tasks                 TasksConfig   `mapstructure:"tasks"`
 }
 
-// Load 加载配置文件
+// TasksConfig 远程任务配置
+type TasksConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 任务轮询间隔，为0时仅接收随心跳下发的任务
+	AllowedTypes []string      `mapstructure:"allowed_types"` // 允许执行的任务类型: execute_item, refresh_config, flush_metrics
+	Workers      int           `mapstructure:"workers"`
+	Timeout      time.Duration `mapstructure:"timeout"` // 任务未指定超时时的默认超时
+}
+
+// Load 加载配置文件，配置存在问题时返回包含全部问题的错误
 func Load(configFile string) (*Config, error) {
+	config, problems, err := Check(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if errs := Errors(problems); len(errs) > 0 {
+		return nil, fmt.Errorf("配置验证失败: %v", &ValidationError{Problems: errs})
+	}
+	return config, nil
+}
+
+// Check 读取并严格检查配置文件：拒绝未知配置项并收集全部问题，只有文件无法读取时返回错误
+func Check(configFile string) (*Config, []Problem, error) {
 	viper.SetConfigFile(configFile)
 	viper.SetConfigType("yaml")
+	// GOAGENT_DEVICE_MONITOR_BASE_URL 覆盖 device_monitor.base_url
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
+	bindEnvKeys(viper.GetViper(), reflect.TypeOf(Config{}), "")
 
 	// 设置默认值
 	setDefaults()
 
 	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		return nil, nil, fmt.Errorf("读取配置文件失败: %v", err)
 	}
 
-	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	problems := &Problems{File: configFile}
+	for _, key := range UnknownKeys(viper.AllSettings(), &Config{}, "") {
+		problems.Add(key, "未知的配置项")
 	}
 
+	var config Config
+	problems.AddDecodeError(viper.Unmarshal(&config))
+
+	// 替换 ${ENV_VAR} 和 ${file:/path} 引用
+	ExpandStrings(&config, "", problems)
+
 	// 验证配置
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("配置验证失败: %v", err)
-	}
+	validateConfig(&config, problems)
 
-	return &config, nil
+	return &config, problems.List(), nil
 }
 
 // setDefaults 设置默认配置值
@@ -126,12 +256,28 @@ func setDefaults() {
 	viper.SetDefault("agent.name", "go-agent")
 	viper.SetDefault("agent.interval", "30s")
 	viper.SetDefault("agent.timeout", "10s")
+	viper.SetDefault("agent.schedule.spread", true)
+	viper.SetDefault("agent.schedule.align", false)
+	viper.SetDefault("agent.schedule.startup_jitter", "0s")
+	viper.SetDefault("agent.schedule.workers", 10)
+	viper.SetDefault("agent.schedule.overlap_policy", "skip")
+	viper.SetDefault("agent.command_mapping", "configs/command_mapping.yaml")
+	viper.SetDefault("agent.status_listen", "127.0.0.1:9274")
+	viper.SetDefault("agent.watch_config", true)
 
 	viper.SetDefault("collect.system.enabled", true)
 	viper.SetDefault("collect.system.cpu", true)
 	viper.SetDefault("collect.system.memory", true)
 	viper.SetDefault("collect.system.disk", true)
 	viper.SetDefault("collect.system.network", true)
+	viper.SetDefault("collect.mysql.enabled", false)
+	viper.SetDefault("collect.mysql.cache_ttl", "25s")
+	viper.SetDefault("collect.postgres.enabled", false)
+	viper.SetDefault("collect.postgres.cache_ttl", "25s")
+	viper.SetDefault("collect.redis.enabled", false)
+	viper.SetDefault("collect.redis.cache_ttl", "25s")
+	viper.SetDefault("collect.memcached.enabled", false)
+	viper.SetDefault("collect.memcached.cache_ttl", "25s")
 
 	viper.SetDefault("collect.snmp.enabled", false)
 	viper.SetDefault("collect.snmp.community", "public")
@@ -152,29 +298,113 @@ func setDefaults() {
 	viper.SetDefault("device_monitor.config_refresh_interval", "5m")
 	viper.SetDefault("device_monitor.metrics_buffer_size", 100)
 	viper.SetDefault("device_monitor.metrics_flush_interval", "10s")
+	viper.SetDefault("device_monitor.config_cache_path", "data/config_cache.json")
+	viper.SetDefault("device_monitor.config_watch", true)
+	viper.SetDefault("device_monitor.config_watch_timeout", "60s")
+	// 远程任务默认关闭，execute_item 需要显式加入 allowed_types
+	viper.SetDefault("device_monitor.tasks.enabled", false)
+	viper.SetDefault("device_monitor.tasks.poll_interval", "10s")
+	viper.SetDefault("device_monitor.tasks.allowed_types", []string{"refresh_config", "flush_metrics"})
+	viper.SetDefault("device_monitor.tasks.workers", 2)
+	viper.SetDefault("device_monitor.tasks.timeout", "30s")
+
+	viper.SetDefault("items.source", ItemSourceAPI)
+	viper.SetDefault("items.file", "configs/items.yaml")
+
+	viper.SetDefault("credentials.file", "data/credentials.vault")
 
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
 	viper.SetDefault("log.output", "stdout")
 }
 
-// validateConfig 验证配置
-func validateConfig(cfg *Config) error {
+// Location 返回代理配置的时区，未配置时为本机时区
+func (c *AgentConfig) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// validateConfig 验证配置，问题记录到p中，可以自动修正的配置项直接填充默认值
+func validateConfig(cfg *Config, p *Problems) {
 	// 验证代理配置
 	if cfg.Agent.Name == "" {
-		return fmt.Errorf("代理名称不能为空")
+		p.Add("agent.name", "代理名称不能为空")
 	}
 	if cfg.Agent.Interval <= 0 {
-		return fmt.Errorf("采集间隔必须大于0")
+		p.Add("agent.interval", "采集间隔必须大于0")
 	}
 	if cfg.Agent.Timeout <= 0 {
-		return fmt.Errorf("超时时间必须大于0")
+		p.Add("agent.timeout", "超时时间必须大于0")
+	}
+	if cfg.Agent.Schedule.StartupJitter < 0 {
+		p.Add("agent.schedule.startup_jitter", "启动随机延迟不能为负数")
+	}
+	if cfg.Agent.Schedule.Workers < 0 {
+		p.Add("agent.schedule.workers", "采集工作协程数量不能为负数")
+	}
+	switch cfg.Agent.Schedule.OverlapPolicy {
+	case "", "skip", "coalesce":
+	default:
+		p.Add("agent.schedule.overlap_policy", "不支持的采集重叠策略: %s", cfg.Agent.Schedule.OverlapPolicy)
+	}
+	if cfg.Agent.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Agent.Timezone); err != nil {
+			p.Add("agent.timezone", "无效的时区 %s: %v", cfg.Agent.Timezone, err)
+		}
+	}
+
+	if cfg.Agent.StatusListen != "" {
+		if _, _, err := net.SplitHostPort(cfg.Agent.StatusListen); err != nil {
+			p.Add("agent.status_listen", "无效的状态接口监听地址 %s: %v", cfg.Agent.StatusListen, err)
+		}
+	}
+
+	if cfg.Collect.MySQL.Enabled {
+		validateDatabaseProfiles(cfg.Collect.MySQL.Profiles, "collect.mysql", "mysql", p)
+		if cfg.Collect.MySQL.CacheTTL < 0 {
+			p.Add("collect.mysql.cache_ttl", "缓存时间不能为负数")
+		}
+	}
+	if cfg.Collect.Postgres.Enabled {
+		validateDatabaseProfiles(cfg.Collect.Postgres.Profiles, "collect.postgres", "postgres", p)
+		if cfg.Collect.Postgres.CacheTTL < 0 {
+			p.Add("collect.postgres.cache_ttl", "缓存时间不能为负数")
+		}
+	}
+	if cfg.Collect.Redis.Enabled {
+		validateDatabaseProfiles(cfg.Collect.Redis.Profiles, "collect.redis", "redis", p)
+		if cfg.Collect.Redis.CacheTTL < 0 {
+			p.Add("collect.redis.cache_ttl", "缓存时间不能为负数")
+		}
+	}
+	if cfg.Collect.Memcached.Enabled {
+		validateDatabaseProfiles(cfg.Collect.Memcached.Profiles, "collect.memcached", "memcached", p)
+		if cfg.Collect.Memcached.CacheTTL < 0 {
+			p.Add("collect.memcached.cache_ttl", "缓存时间不能为负数")
+		}
+	}
+
+	// 验证监控项来源
+	switch cfg.Items.Source {
+	case "", ItemSourceAPI:
+	case ItemSourceFile:
+		if cfg.Items.File == "" {
+			p.Add("items.file", "监控项来源为file时文件路径不能为空")
+		}
+	default:
+		p.Add("items.source", "不支持的监控项来源: %s", cfg.Items.Source)
 	}
 
 	// 验证HTTP传输配置
 	if cfg.Transport.HTTP.Enabled {
 		if cfg.Transport.HTTP.URL == "" {
-			return fmt.Errorf("HTTP传输器启用时URL不能为空")
+			p.Add("transport.http.url", "HTTP传输器启用时URL不能为空")
 		}
 		if cfg.Transport.HTTP.Method == "" {
 			cfg.Transport.HTTP.Method = "POST"
@@ -184,37 +414,37 @@ func validateConfig(cfg *Config) error {
 	// 验证gRPC传输配置
 	if cfg.Transport.GRPC.Enabled {
 		if cfg.Transport.GRPC.Server == "" {
-			return fmt.Errorf("gRPC传输器启用时服务器地址不能为空")
+			p.Add("transport.grpc.server", "gRPC传输器启用时服务器地址不能为空")
 		}
 		if cfg.Transport.GRPC.Port <= 0 || cfg.Transport.GRPC.Port > 65535 {
-			return fmt.Errorf("gRPC端口必须在1-65535范围内")
+			p.Add("transport.grpc.port", "gRPC端口必须在1-65535范围内")
 		}
 	}
 
 	// 验证SNMP配置
 	if cfg.Collect.SNMP.Enabled {
 		if len(cfg.Collect.SNMP.Targets) == 0 {
-			return fmt.Errorf("SNMP采集器启用时目标列表不能为空")
+			p.Add("collect.snmp.targets", "SNMP采集器启用时目标列表不能为空")
 		}
 		if cfg.Collect.SNMP.Port <= 0 || cfg.Collect.SNMP.Port > 65535 {
-			return fmt.Errorf("SNMP端口必须在1-65535范围内")
+			p.Add("collect.snmp.port", "SNMP端口必须在1-65535范围内")
 		}
 	}
 
 	// 验证脚本配置
 	if cfg.Collect.Script.Enabled {
 		if len(cfg.Collect.Script.Scripts) == 0 {
-			return fmt.Errorf("脚本采集器启用时脚本列表不能为空")
+			p.Add("collect.script.scripts", "脚本采集器启用时脚本列表不能为空")
 		}
 		if cfg.Collect.Script.Timeout <= 0 {
-			return fmt.Errorf("脚本超时时间必须大于0")
+			p.Add("collect.script.timeout", "脚本超时时间必须大于0")
 		}
 	}
 
 	// 验证设备监控配置
 	if cfg.DeviceMonitor != nil && cfg.DeviceMonitor.Enabled {
 		if cfg.DeviceMonitor.BaseURL == "" {
-			return fmt.Errorf("设备监控服务启用时BaseURL不能为空")
+			p.Add("device_monitor.base_url", "设备监控服务启用时BaseURL不能为空")
 		}
 		if cfg.DeviceMonitor.Timeout <= 0 {
 			cfg.DeviceMonitor.Timeout = 30 * time.Second
@@ -225,6 +455,12 @@ func validateConfig(cfg *Config) error {
 		if cfg.DeviceMonitor.ConfigRefreshInterval <= 0 {
 			cfg.DeviceMonitor.ConfigRefreshInterval = 5 * time.Minute
 		}
+		if cfg.DeviceMonitor.ConfigWatchTimeout <= 0 {
+			cfg.DeviceMonitor.ConfigWatchTimeout = 60 * time.Second
+		}
+		if cfg.DeviceMonitor.Tasks.PollInterval < 0 {
+			p.Add("device_monitor.tasks.poll_interval", "任务轮询间隔不能为负数")
+		}
 		if cfg.DeviceMonitor.MetricsBufferSize <= 0 {
 			cfg.DeviceMonitor.MetricsBufferSize = 100
 		}
@@ -232,6 +468,75 @@ func validateConfig(cfg *Config) error {
 			cfg.DeviceMonitor.MetricsFlushInterval = 10 * time.Second
 		}
 	}
+}
+
+// validateDatabaseProfiles 检查命名的连接配置，kind为mysql、postgres、redis或memcached，决定TLS相关字段的检查方式
+func validateDatabaseProfiles(profiles map[string]DatabaseProfile, prefix, kind string, p *Problems) {
+	if len(profiles) == 0 {
+		p.Add(prefix+".profiles", "启用后至少需要一个连接配置")
+		return
+	}
+	for name, profile := range profiles {
+		path := prefix + ".profiles." + name
+		if profile.Host == "" {
+			p.Add(path+".host", "不能为空")
+		}
+		if profile.Port < 0 || profile.Port > 65535 {
+			p.Add(path+".port", "必须在0-65535范围内")
+		}
+		if profile.Credential != "" && (profile.Username != "" || profile.Password != "") {
+			p.Add(path+".credential", "不能与 username/password 同时设置")
+		}
+
+		switch kind {
+		case "postgres":
+			validatePostgresTLS(path, profile, p)
+		case "mysql":
+			validateProfileTLS(path, profile, []string{"false", "true", "skip-verify", "preferred"}, p)
+		case "redis":
+			validateProfileTLS(path, profile, []string{"false", "true", "skip-verify"}, p)
+			if profile.Database != "" {
+				if db, err := strconv.Atoi(profile.Database); err != nil || db < 0 {
+					p.Add(path+".database", "Redis库编号必须是非负整数")
+				}
+			}
+		case "memcached":
+			validateProfileTLS(path, profile, []string{"false", "true", "skip-verify"}, p)
+			if profile.Username != "" || profile.Password != "" || profile.Credential != "" || profile.Database != "" {
+				p.Add(path, "Memcached不支持 username/password/credential/database")
+			}
+		}
+	}
+}
 
-	return nil
+// validateProfileTLS 检查使用tls字段的连接配置（MySQL、Redis、Memcached）
+func validateProfileTLS(path string, profile DatabaseProfile, modes []string, p *Problems) {
+	if profile.SSLMode != "" {
+		p.Add(path+".sslmode", "只适用于PostgreSQL，其他服务使用tls")
+	}
+	mode := strings.ToLower(profile.TLS)
+	if mode != "" && !slices.Contains(modes, mode) {
+		p.Add(path+".tls", "不支持的TLS模式 %q，可选 %s", profile.TLS, strings.Join(modes, ", "))
+	}
+	if (profile.TLSCert == "") != (profile.TLSKey == "") {
+		p.Add(path+".tls_cert", "tls_cert 和 tls_key 必须同时配置")
+	}
+}
+
+// validatePostgresTLS 检查PostgreSQL连接配置的sslmode和证书
+func validatePostgresTLS(path string, profile DatabaseProfile, p *Problems) {
+	if profile.TLS != "" {
+		p.Add(path+".tls", "PostgreSQL不支持tls，请使用sslmode")
+	}
+	switch strings.ToLower(profile.SSLMode) {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		p.Add(path+".sslmode", "不支持的SSL模式 %q，可选 disable, require, verify-ca, verify-full", profile.SSLMode)
+	}
+	if profile.TLSServerName != "" {
+		p.Add(path+".tls_server_name", "PostgreSQL不支持，verify-full按host校验证书")
+	}
+	if (profile.TLSCert == "") != (profile.TLSKey == "") {
+		p.Add(path+".tls_cert", "tls_cert 和 tls_key 必须同时配置")
+	}
 }
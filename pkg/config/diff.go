@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff 比较两份配置，返回取值不同的配置项路径（如 transport.http.headers）
+// map和切片整体比较，不展开到元素
+func Diff(old, new *Config) []string {
+	var changed []string
+	diffValue(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+// diffValue 递归比较结构体字段
+func diffValue(a, b reflect.Value, path string, changed *[]string) {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				*changed = append(*changed, path)
+			}
+			return
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changed = append(*changed, path)
+		}
+		return
+	}
+
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		diffValue(a.Field(i), b.Field(i), name, changed)
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"go-agent/pkg/logger"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量覆盖配置项时使用的前缀，如 GOAGENT_DEVICE_MONITOR_BASE_URL 覆盖 device_monitor.base_url
+const EnvPrefix = "GOAGENT"
+
+// sensitiveKeyWords 配置项名称包含这些词时，其值视为敏感信息，不会出现在日志中
+// 凭据名称和凭据库文件路径不是敏感信息，排查问题时需要，因此不包含 credential（凭据库口令由 passphrase 覆盖）
+var sensitiveKeyWords = []string{"password", "passwd", "secret", "passphrase", "token", "authorization", "api_key", "apikey"}
+
+// IsSensitiveKey 检查配置项或HTTP头部名称是否表示敏感信息
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	for _, word := range sensitiveKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandString 替换字符串中的 ${VAR}、${VAR:-默认值} 和 ${file:/path}，$${ 表示字面量 ${
+// 返回值中的secret表示是否引用了文件（文件内容一律视为敏感信息）
+func ExpandString(value string) (expanded string, secret bool, err error) {
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		if start > 0 && rest[start-1] == '$' {
+			b.WriteString(rest[:start-1])
+			b.WriteString("${")
+			rest = rest[start+2:]
+			continue
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", false, fmt.Errorf("%q 中的 ${ 缺少 }", value)
+		}
+		b.WriteString(rest[:start])
+
+		ref := rest[start+2 : start+end]
+		if !isReference(ref) {
+			// 不是变量引用（如 PowerShell 的 ${env:PATH}），原样保留
+			b.WriteString(rest[start : start+end+1])
+			rest = rest[start+end+1:]
+			continue
+		}
+		resolved, fromFile, err := resolveReference(ref)
+		if err != nil {
+			return "", false, err
+		}
+		secret = secret || fromFile
+		b.WriteString(resolved)
+		rest = rest[start+end+1:]
+	}
+	return b.String(), secret, nil
+}
+
+// isReference 检查 ${...} 中的内容是否为 file: 引用或合法的环境变量名
+func isReference(ref string) bool {
+	if strings.HasPrefix(ref, "file:") {
+		return true
+	}
+	name, _, _ := strings.Cut(ref, ":-")
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveReference 解析单个 ${...} 引用
+func resolveReference(ref string) (string, bool, error) {
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return "", true, fmt.Errorf("读取密钥文件失败: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+
+	name, fallback, hasFallback := strings.Cut(ref, ":-")
+	if value, exists := os.LookupEnv(name); exists && (value != "" || !hasFallback) {
+		return value, false, nil
+	}
+	if hasFallback {
+		return fallback, false, nil
+	}
+	return "", false, fmt.Errorf("环境变量 %s 未设置", name)
+}
+
+// ExpandStrings 对结构体中的全部字符串（含map值和切片元素）执行 ExpandString，失败的配置项记录到problems
+// 引用文件的值和敏感配置项的值会登记到日志脱敏
+func ExpandStrings(target interface{}, prefix string, problems *Problems) {
+	expandValue(reflect.ValueOf(target), prefix, false, problems)
+}
+
+// expandValue 递归替换字符串字段
+func expandValue(v reflect.Value, path string, sensitive bool, problems *Problems) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			expandValue(v.Elem(), path, sensitive, problems)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			expandValue(v.Field(i), joinPath(path, name), sensitive || IsSensitiveKey(name), problems)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			// map的值不可寻址，复制后替换再写回
+			for _, key := range v.MapKeys() {
+				entry := reflect.New(v.Type().Elem()).Elem()
+				entry.Set(v.MapIndex(key))
+				expandValue(entry, joinPath(path, fmt.Sprintf("%v", key.Interface())), sensitive, problems)
+				v.SetMapIndex(key, entry)
+			}
+			return
+		}
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			keyName := fmt.Sprintf("%v", key.Interface())
+			expanded, ok := expandLeaf(v.MapIndex(key).String(), joinPath(path, keyName), sensitive || IsSensitiveKey(keyName), problems)
+			if ok {
+				v.SetMapIndex(key, reflect.ValueOf(expanded).Convert(v.Type().Elem()))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), sensitive, problems)
+		}
+	case reflect.String:
+		if expanded, ok := expandLeaf(v.String(), path, sensitive, problems); ok && v.CanSet() {
+			v.SetString(expanded)
+		}
+	}
+}
+
+// expandLeaf 替换单个字符串值并登记敏感值
+func expandLeaf(value, path string, sensitive bool, problems *Problems) (string, bool) {
+	expanded, fromFile, err := ExpandString(value)
+	if err != nil {
+		problems.Add(path, "%v", err)
+		return value, false
+	}
+	if sensitive || fromFile {
+		logger.AddSecret(expanded)
+		// 如 Authorization: Bearer xxx，单独登记令牌部分
+		if _, token, found := strings.Cut(expanded, " "); found {
+			logger.AddSecret(strings.TrimSpace(token))
+		}
+	}
+	return expanded, true
+}
+
+// joinPath 拼接配置项路径
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// bindEnvKeys 为配置结构中的每个配置项绑定环境变量，未出现在配置文件中的配置项也可以通过环境变量设置
+func bindEnvKeys(v *viper.Viper, t reflect.Type, prefix string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := joinPath(prefix, name)
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Duration(0)) {
+			bindEnvKeys(v, fieldType, key)
+			continue
+		}
+		_ = v.BindEnv(key)
+	}
+}
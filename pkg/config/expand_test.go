@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-agent/pkg/logger"
+)
+
+func TestExpandString(t *testing.T) {
+	t.Setenv("GOAGENT_TEST_HOST", "db.local")
+	t.Setenv("GOAGENT_TEST_EMPTY", "")
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte("s3cret-value\r\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		value      string
+		want       string
+		wantSecret bool
+		wantErr    bool
+	}{
+		{name: "无引用", value: "plain", want: "plain"},
+		{name: "环境变量", value: "tcp://${GOAGENT_TEST_HOST}:3306", want: "tcp://db.local:3306"},
+		{name: "默认值", value: "${GOAGENT_TEST_UNSET:-localhost}", want: "localhost"},
+		{name: "空值使用默认值", value: "${GOAGENT_TEST_EMPTY:-localhost}", want: "localhost"},
+		{name: "空值无默认值", value: "${GOAGENT_TEST_EMPTY}", want: ""},
+		{name: "未设置的变量", value: "${GOAGENT_TEST_UNSET}", wantErr: true},
+		{name: "转义", value: "$${GOAGENT_TEST_HOST} ${GOAGENT_TEST_HOST}", want: "${GOAGENT_TEST_HOST} db.local"},
+		{name: "非变量引用原样保留", value: "${env:PATH}", want: "${env:PATH}"},
+		{name: "缺少右括号", value: "${GOAGENT_TEST_HOST", wantErr: true},
+		{name: "密钥文件去除末尾换行", value: "${file:" + secretFile + "}", want: "s3cret-value", wantSecret: true},
+		{name: "密钥文件不存在", value: "${file:" + filepath.Join(dir, "missing") + "}", wantErr: true},
+		{name: "密钥文件不可读", value: "${file:" + dir + "}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, secret, err := ExpandString(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandString(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want || secret != tt.wantSecret {
+				t.Errorf("ExpandString(%q) = %q, %v, want %q, %v", tt.value, got, secret, tt.want, tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestExpandStringsRegistersSecrets(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("file-token-1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOAGENT_TEST_PASSWORD", "env-password-1234")
+
+	type target struct {
+		Host     string            `mapstructure:"host"`
+		Password string            `mapstructure:"password"`
+		Extra    string            `mapstructure:"extra"` // 名称不敏感，但引用了文件
+		Headers  map[string]string `mapstructure:"headers"`
+		Missing  string            `mapstructure:"missing"`
+	}
+	cfg := target{
+		Host:     "${GOAGENT_TEST_HOST:-localhost}",
+		Password: "${GOAGENT_TEST_PASSWORD}",
+		Extra:    "${file:" + secretFile + "}",
+		Headers:  map[string]string{"Authorization": "Bearer ${GOAGENT_TEST_PASSWORD}"},
+		Missing:  "${GOAGENT_TEST_UNSET}",
+	}
+
+	problems := &Problems{File: "config.yaml"}
+	ExpandStrings(&cfg, "db", problems)
+
+	if cfg.Host != "localhost" || cfg.Password != "env-password-1234" || cfg.Extra != "file-token-1234" {
+		t.Errorf("expanded = %+v", cfg)
+	}
+	if got := cfg.Headers["Authorization"]; got != "Bearer env-password-1234" {
+		t.Errorf("Authorization = %q", got)
+	}
+	// 失败的配置项保留原值并记录问题
+	list := problems.List()
+	if len(list) != 1 || list[0].Key != "db.missing" || cfg.Missing != "${GOAGENT_TEST_UNSET}" {
+		t.Errorf("problems = %+v, missing = %q", list, cfg.Missing)
+	}
+
+	// 敏感配置项的值和引用文件的值登记到日志脱敏，普通配置项不受影响
+	text := logger.Redact("password=env-password-1234 token=file-token-1234 host=localhost")
+	if strings.Contains(text, "env-password-1234") || strings.Contains(text, "file-token-1234") {
+		t.Errorf("secrets not registered for redaction: %q", text)
+	}
+	if !strings.Contains(text, "host=localhost") {
+		t.Errorf("non-secret value redacted: %q", text)
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Problem 配置文件中的单个问题
+type Problem struct {
+	File    string // 配置文件路径
+	Key     string // 出问题的配置项路径，如 device_monitor.heartbeat_interval
+	Message string
+	Warning bool // 仅为警告，不影响启动
+}
+
+// String 返回 文件: 配置项: 描述 形式的问题说明
+func (p Problem) String() string {
+	var parts []string
+	if p.File != "" {
+		parts = append(parts, p.File)
+	}
+	if p.Key != "" {
+		parts = append(parts, p.Key)
+	}
+	parts = append(parts, p.Message)
+	return strings.Join(parts, ": ")
+}
+
+// ValidationError 配置检查发现的全部问题
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error 每行一个问题
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("发现 %d 个配置问题:", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  - "+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Problems 收集配置问题
+type Problems struct {
+	File  string
+	items []Problem
+}
+
+// Add 记录一个问题，同一配置项只记录第一个问题（如解码失败后不再报告取值范围）
+func (p *Problems) Add(key, format string, args ...interface{}) {
+	if key != "" {
+		for _, existing := range p.items {
+			if existing.Key == key {
+				return
+			}
+		}
+	}
+	p.items = append(p.items, Problem{File: p.File, Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// Warn 记录一个警告
+func (p *Problems) Warn(key, format string, args ...interface{}) {
+	p.items = append(p.items, Problem{File: p.File, Key: key, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// List 返回已记录的问题
+func (p *Problems) List() []Problem {
+	return p.items
+}
+
+// Errors 返回问题列表中的错误，忽略警告
+func Errors(problems []Problem) []Problem {
+	var errs []Problem
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	return errs
+}
+
+// UnknownKeys 对照目标结构体的mapstructure标签，返回配置中未定义的键的完整路径
+// map类型的字段接受任意键，值为结构体时（如命名的连接配置）检查每个值的字段
+func UnknownKeys(settings map[string]interface{}, target interface{}, prefix string) []string {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var unknown []string
+	collectUnknownKeys(settings, t, prefix, &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+// collectUnknownKeys 递归检查嵌套的结构体字段
+func collectUnknownKeys(settings map[string]interface{}, t reflect.Type, prefix string, unknown *[]string) {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+
+	for key, value := range settings {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		fieldType, exists := fields[strings.ToLower(key)]
+		if !exists {
+			*unknown = append(*unknown, path)
+			continue
+		}
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Map && fieldType.Elem().Kind() == reflect.Struct {
+			if entries, ok := toStringMap(value); ok {
+				for name, entry := range entries {
+					if nested, ok := toStringMap(entry); ok {
+						collectUnknownKeys(nested, fieldType.Elem(), path+"."+name, unknown)
+					}
+				}
+			}
+			continue
+		}
+		if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		if nested, ok := toStringMap(value); ok {
+			collectUnknownKeys(nested, fieldType, path, unknown)
+		}
+	}
+}
+
+// toStringMap 将YAML解析出的map转换为map[string]interface{}
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			converted[fmt.Sprintf("%v", k)] = v
+		}
+		return converted, true
+	default:
+		return nil, false
+	}
+}
+
+// AddDecodeError 将mapstructure解码错误（可能由多个错误合并而成）逐个记录为问题
+func (p *Problems) AddDecodeError(err error) {
+	if err == nil {
+		return
+	}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		for _, e := range joined.Unwrap() {
+			p.AddDecodeError(e)
+		}
+		return
+	}
+	if named, ok := err.(interface {
+		Name() string
+		Unwrap() error
+	}); ok && named.Unwrap() != nil {
+		p.Add(named.Name(), "%v", named.Unwrap())
+		return
+	}
+	p.Add("", "%v", err)
+}
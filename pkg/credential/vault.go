@@ -0,0 +1,350 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/logger"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// vaultVersion 凭据库文件格式版本
+	vaultVersion = 1
+	// kdfName 由密钥文件内容或口令派生加密密钥的算法
+	kdfName = "pbkdf2-sha256"
+	// kdfIterations PBKDF2迭代次数
+	kdfIterations = 600000
+	// maxKDFIterations 打开凭据库时允许的最大迭代次数，防止被篡改的文件在认证前耗尽CPU
+	maxKDFIterations = 10 * kdfIterations
+	// keySize AES-256密钥长度
+	keySize = 32
+	// saltSize 盐长度
+	saltSize = 16
+)
+
+// ErrNoSecret 未配置凭据库密钥
+var ErrNoSecret = errors.New("未配置凭据库密钥（credentials.key_file 或 credentials.passphrase）")
+
+// Credential 一条命名凭据
+type Credential struct {
+	Username    string    `json:"username,omitempty"`
+	Password    string    `json:"password"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// vaultFile 凭据库文件内容，凭据以AES-256-GCM加密存放在Data中，文件头作为附加认证数据
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Data       string `json:"data"`
+}
+
+// Vault 加密的本地凭据库
+type Vault struct {
+	path        string
+	secret      []byte
+	salt        []byte
+	iterations  int
+	credentials map[string]Credential
+	mu          sync.RWMutex
+}
+
+// Secret 读取凭据库密钥：优先使用密钥文件，其次使用口令
+func Secret(keyFile, passphrase string) ([]byte, error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取凭据库密钥文件失败: %v", err)
+		}
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("凭据库密钥文件 %s 为空", keyFile)
+		}
+		return secret, nil
+	}
+	if passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	return nil, ErrNoSecret
+}
+
+// GenerateKeyFile 生成随机密钥文件（权限0600），已存在时返回错误
+func GenerateKeyFile(path string) error {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("生成随机密钥失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("创建密钥文件失败: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+// Load 按配置打开凭据库，未配置或凭据库文件不存在时返回nil
+func Load(cfg config.CredentialsConfig) (*Vault, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(cfg.File); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	secret, err := Secret(cfg.KeyFile, cfg.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return Open(cfg.File, secret)
+}
+
+// Open 打开凭据库，文件不存在时返回空凭据库（首次Save时创建）
+func Open(path string, secret []byte) (*Vault, error) {
+	if len(secret) == 0 {
+		return nil, ErrNoSecret
+	}
+
+	vault := &Vault{
+		path:        path,
+		secret:      secret,
+		iterations:  kdfIterations,
+		credentials: make(map[string]Credential),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		vault.salt = make([]byte, saltSize)
+		if _, err := rand.Read(vault.salt); err != nil {
+			return nil, fmt.Errorf("生成随机盐失败: %v", err)
+		}
+		return vault, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据库失败: %v", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: %v", err)
+	}
+	if file.Version != vaultVersion || file.KDF != kdfName || file.Iterations <= 0 {
+		return nil, fmt.Errorf("不支持的凭据库格式: version=%d, kdf=%s", file.Version, file.KDF)
+	}
+	if file.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("凭据库迭代次数 %d 超过上限 %d", file.Iterations, maxKDFIterations)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: salt: %v", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(file.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: nonce: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: data: %v", err)
+	}
+
+	vault.salt = salt
+	vault.iterations = file.Iterations
+	aead, err := vault.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("解析凭据库失败: nonce长度错误")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, file.header())
+	if err != nil {
+		return nil, fmt.Errorf("解密凭据库失败，密钥错误或文件已损坏")
+	}
+	if err := json.Unmarshal(plaintext, &vault.credentials); err != nil {
+		return nil, fmt.Errorf("解析凭据失败: %v", err)
+	}
+	if vault.credentials == nil {
+		vault.credentials = make(map[string]Credential)
+	}
+	for _, credential := range vault.credentials {
+		logger.AddSecret(credential.Password)
+	}
+
+	return vault, nil
+}
+
+// Path 返回凭据库文件路径
+func (v *Vault) Path() string {
+	return v.path
+}
+
+// Get 获取凭据
+func (v *Vault) Get(name string) (Credential, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	credential, exists := v.credentials[name]
+	return credential, exists
+}
+
+// Set 添加或替换凭据，需调用Save写入文件
+func (v *Vault) Set(name string, credential Credential) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	credential.UpdatedAt = time.Now()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.credentials[name] = credential
+	return nil
+}
+
+// Remove 删除凭据，返回凭据是否存在；需调用Save写入文件
+func (v *Vault) Remove(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, exists := v.credentials[name]; !exists {
+		return false
+	}
+	delete(v.credentials, name)
+	return true
+}
+
+// Names 返回排序后的凭据名称
+func (v *Vault) Names() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	names := make([]string, 0, len(v.credentials))
+	for name := range v.credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save 加密写入凭据库文件（先写临时文件再重命名，权限0600）
+func (v *Vault) Save() error {
+	v.mu.RLock()
+	plaintext, err := json.Marshal(v.credentials)
+	v.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("序列化凭据失败: %v", err)
+	}
+
+	aead, err := v.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成随机数失败: %v", err)
+	}
+
+	file := vaultFile{
+		Version:    vaultVersion,
+		KDF:        kdfName,
+		Iterations: v.iterations,
+		Salt:       base64.StdEncoding.EncodeToString(v.salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+	}
+	file.Data = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, file.header()))
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化凭据库失败: %v", err)
+	}
+
+	dir := filepath.Dir(v.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("创建凭据库目录失败: %v", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(v.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入凭据库失败: %v", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("设置凭据库权限失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入凭据库失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), v.path); err != nil {
+		return fmt.Errorf("保存凭据库失败: %v", err)
+	}
+	return nil
+}
+
+// aead 由密钥和盐派生AES-GCM
+func (v *Vault) aead() (cipher.AEAD, error) {
+	key := pbkdf2SHA256(v.secret, v.salt, v.iterations, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密失败: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密失败: %v", err)
+	}
+	return aead, nil
+}
+
+// header 返回参与认证的文件头，防止篡改派生参数
+func (f vaultFile) header() []byte {
+	return []byte(fmt.Sprintf("go-agent-vault:%d:%s:%d:%s", f.Version, f.KDF, f.Iterations, f.Salt))
+}
+
+// ValidateName 检查凭据名称：字母、数字、点、下划线和连字符
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("凭据名称不能为空")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '.' && r != '_' && r != '-' {
+			return fmt.Errorf("凭据名称 %q 只能包含字母、数字、点、下划线和连字符", name)
+		}
+	}
+	return nil
+}
+
+// pbkdf2SHA256 按 RFC 8018 派生密钥
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	return pbkdf2.Key(password, salt, iterations, keyLen, sha256.New)
+}
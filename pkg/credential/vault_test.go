@@ -0,0 +1,77 @@
+package credential
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// PBKDF2-HMAC-SHA256 已知结果，前两组来自 RFC 7914 第11节
+func TestPBKDF2SHA256(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iterations     int
+		keyLen         int
+		want           string
+	}{
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, 64, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+		{"password", "salt", 2, 32, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 40, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+		{"pass\x00word", "sa\x00lt", 4096, 16, "89b69d0516f829893c696226650a8687"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, tt.keyLen))
+		if got != tt.want {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, tt.keyLen, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRejectsExcessiveIterations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	data := `{"version":1,"kdf":"pbkdf2-sha256","iterations":2000000000,"salt":"AAAAAAAAAAAAAAAAAAAAAA==","nonce":"AAAAAAAAAAAAAAAA","data":""}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err := Open(path, []byte("secret"))
+	if err == nil || !strings.Contains(err.Error(), "迭代次数") {
+		t.Fatalf("Open error = %v, want iteration limit error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Open took %v before rejecting the file", elapsed)
+	}
+}
+
+func TestVaultSaveOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	vault, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Set("mysql-prod", Credential{Username: "monitor", Password: "p@ss"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vault.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, ok := reopened.Get("mysql-prod")
+	if !ok || cred.Username != "monitor" || cred.Password != "p@ss" {
+		t.Errorf("Get(mysql-prod) = %+v, %v", cred, ok)
+	}
+
+	if _, err := Open(path, []byte("wrong")); err == nil {
+		t.Error("Open with wrong secret succeeded")
+	}
+}
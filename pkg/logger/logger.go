@@ -11,6 +11,8 @@ import (
 var (
 	// 全局日志实例
 	log *logrus.Logger
+	// 是否以详细模式启动
+	verboseMode bool
 )
 
 // Init 初始化日志系统
@@ -21,6 +23,8 @@ func Init(verbose bool) error {
 // InitWithConfig 使用配置初始化日志系统
 func InitWithConfig(level, format, output string, verbose bool) error {
 	log = logrus.New()
+	log.SetFormatter(&redactingFormatter{log.Formatter})
+	verboseMode = verbose
 
 	// 设置日志级别
 	if verbose {
@@ -42,6 +46,20 @@ func InitWithConfig(level, format, output string, verbose bool) error {
 	return nil
 }
 
+// ApplyConfig 在运行中应用新的日志配置，以详细模式启动时保持debug级别
+func ApplyConfig(level, format, output string) error {
+	if !verboseMode {
+		if err := SetLevel(level); err != nil {
+			return fmt.Errorf("设置日志级别失败: %v", err)
+		}
+	}
+	SetFormat(format)
+	if err := SetOutput(output); err != nil {
+		return fmt.Errorf("设置日志输出失败: %v", err)
+	}
+	return nil
+}
+
 // SetLevel 设置日志级别
 func SetLevel(level string) error {
 	lvl, err := logrus.ParseLevel(level)
@@ -56,19 +74,19 @@ func SetLevel(level string) error {
 func SetFormat(format string) {
 	switch format {
 	case "json":
-		log.SetFormatter(&logrus.JSONFormatter{
+		log.SetFormatter(&redactingFormatter{&logrus.JSONFormatter{
 			TimestampFormat: "2006-01-02 15:04:05",
 			FieldMap: logrus.FieldMap{
 				logrus.FieldKeyTime:  "timestamp",
 				logrus.FieldKeyLevel: "level",
 				logrus.FieldKeyMsg:   "message",
 			},
-		})
+		}})
 	case "text":
-		log.SetFormatter(&logrus.TextFormatter{
+		log.SetFormatter(&redactingFormatter{&logrus.TextFormatter{
 			TimestampFormat: "2006-01-02 15:04:05",
 			FullTimestamp:   true,
-		})
+		}})
 	}
 }
 
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedText 替换敏感信息的文本
+const redactedText = "******"
+
+// minSecretLength 短于此长度的值不做替换，避免误伤普通文本
+const minSecretLength = 4
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret 登记需要在日志中隐藏的敏感值（密码、令牌等）
+func AddSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, existing := range secrets {
+		if existing == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+	// 先替换较长的值，避免其中包含的较短值被部分替换
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact 将文本中已登记的敏感值替换为 ******
+func Redact(text string) string {
+	return string(redactBytes([]byte(text)))
+}
+
+// redactBytes 替换原文以及JSON转义后的敏感值
+func redactBytes(data []byte) []byte {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(redactedText))
+		if escaped, err := json.Marshal(secret); err == nil {
+			escaped = escaped[1 : len(escaped)-1]
+			if !bytes.Equal(escaped, []byte(secret)) {
+				data = bytes.ReplaceAll(data, escaped, []byte(redactedText))
+			}
+		}
+	}
+	return data
+}
+
+// redactingFormatter 在格式化后的每条日志中隐藏敏感值
+type redactingFormatter struct {
+	logrus.Formatter
+}
+
+// Format 格式化日志并隐藏敏感值
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return redactBytes(data), nil
+}
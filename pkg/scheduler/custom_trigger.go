@@ -1,6 +1,10 @@
 package scheduler
 
 import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
 	"time"
 
 	"go-agent/pkg/client"
@@ -9,114 +13,311 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// delayChangeHorizon 查找间隔变化点的最大范围
+const delayChangeHorizon = 8 * 24 * time.Hour
+
 // CustomTrigger 自定义触发器，基于Java的CustomScheduleTrigger实现
+// 支持默认间隔、灵活间隔（含0间隔静默期）、调度间隔和cron表达式
 type CustomTrigger struct {
 	monitorItem *services.CollectItem
 	intervals   []*client.ItemCustomInterval
+	delay       time.Duration       // 默认采集间隔
+	flexible    []*FlexibleInterval // 灵活间隔
+	schedules   []TimeSchedule      // 调度间隔与cron表达式
+	location    *time.Location      // 计算时间窗口所用的时区
+	offset      time.Duration       // 间隔内的固定偏移，用于分散同一时刻的执行
+	align       bool                // 是否按墙上时间对齐间隔
 	logger      *logrus.Logger
 }
 
 // NewCustomTrigger 创建自定义触发器
 func NewCustomTrigger(monitorItem *services.CollectItem, logger *logrus.Logger) *CustomTrigger {
-	return &CustomTrigger{
+	ct := &CustomTrigger{
 		monitorItem: monitorItem,
 		intervals:   monitorItem.Intervals,
+		delay:       time.Duration(monitorItem.UpdateIntervalSeconds) * time.Second,
+		location:    time.Local,
 		logger:      logger,
 	}
+
+	for _, interval := range monitorItem.Intervals {
+		if interval == nil {
+			continue
+		}
+		flexible, schedule, err := ParseCustomInterval(interval)
+		if err != nil {
+			logger.Warnf("监控项 %s 的自定义间隔无效，已忽略: %v", monitorItem.ItemName, err)
+			continue
+		}
+		if flexible != nil {
+			ct.flexible = append(ct.flexible, flexible)
+		}
+		if schedule != nil {
+			ct.schedules = append(ct.schedules, schedule)
+		}
+	}
+
+	return ct
 }
 
-// NextExecutionTime 计算下次执行时间，实现Java版本的nextExecutionTime逻辑
-func (ct *CustomTrigger) NextExecutionTime(lastCompletionTime *time.Time) time.Time {
-	// 上次完成时间，如果为空则使用当前时间
-	var completionTime time.Time
-	if lastCompletionTime == nil {
-		completionTime = time.Now()
-	} else {
-		completionTime = *lastCompletionTime
+// ParseCustomInterval 将服务端下发的自定义间隔解析为灵活间隔或时间点调度
+func ParseCustomInterval(interval *client.ItemCustomInterval) (*FlexibleInterval, TimeSchedule, error) {
+	switch strings.ToLower(strings.TrimSpace(interval.Type)) {
+	case "":
+		flexible, err := parseWeekWindow(interval)
+		return flexible, nil, err
+	case IntervalTypeFlexible:
+		flexible, err := ParseFlexibleInterval(interval.Expression)
+		return flexible, nil, err
+	case IntervalTypeScheduling:
+		schedule, err := ParseSchedulingInterval(interval.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, schedule, nil
+	case IntervalTypeCron:
+		schedule, err := ParseCronInterval(interval.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, schedule, nil
+	default:
+		return nil, nil, fmt.Errorf("不支持的自定义间隔类型: %s", interval.Type)
 	}
+}
 
-	// 获取当前时间
-	now := time.Now()
-	currentTime := now.Format("15:04:05") // HH:mm:ss格式
-	dayOfWeek := int(now.Weekday())
+// CheckCustomInterval 检查自定义间隔能否被解析，用于加载和校验本地监控项文件
+func CheckCustomInterval(interval *client.ItemCustomInterval) error {
+	_, _, err := ParseCustomInterval(interval)
+	return err
+}
 
-	// 星期天为0，但Java中星期天为7，需要转换
-	if dayOfWeek == 0 {
-		dayOfWeek = 7
+// parseWeekWindow 解析星期+时间窗口形式的自定义间隔，开始和结束时间均包含在窗口内
+// 间隔为0时按旧版行为忽略该窗口，沿用默认采集间隔；静默期只由带类型的灵活间隔表示
+func parseWeekWindow(interval *client.ItemCustomInterval) (*FlexibleInterval, error) {
+	if interval.IntervalSeconds < 0 {
+		return nil, fmt.Errorf("星期%d %s-%s 的间隔不能为负数", interval.Week, interval.StartTime, interval.EndTime)
+	}
+	if interval.IntervalSeconds == 0 {
+		return nil, nil
+	}
+	if interval.Week < 1 || interval.Week > 7 {
+		return nil, fmt.Errorf("星期取值 %d 超出范围 1-7", interval.Week)
+	}
+	if interval.StartTime == "" || interval.EndTime == "" {
+		return nil, fmt.Errorf("自定义间隔的开始时间或结束时间为空")
 	}
 
-	// 如果intervals数组为空或长度为0，则直接使用updateIntervalSeconds
-	if len(ct.intervals) == 0 {
-		if ct.monitorItem.UpdateIntervalSeconds > 0 {
-			nextTime := completionTime.Add(time.Duration(ct.monitorItem.UpdateIntervalSeconds) * time.Second)
-			ct.logger.Debugf("intervals为空，使用updateIntervalSeconds间隔 %d 秒，下次执行时间: %v", 
-				ct.monitorItem.UpdateIntervalSeconds, nextTime)
-			return nextTime
-		}
-		// 如果updateIntervalSeconds也为0，返回零值
-		ct.logger.Warnf("监控项 %s 既没有自定义间隔也没有updateIntervalSeconds配置", ct.monitorItem.ItemName)
-		return time.Time{}
+	start, err := ParseClock(interval.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("解析开始时间失败: %v", err)
+	}
+	end, err := ParseClock(interval.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("解析结束时间失败: %v", err)
 	}
 
-	// 检查是否处于自定义间隔范围内
-	for _, interval := range ct.intervals {
-		if ct.isInCustomInterval(interval, dayOfWeek, currentTime) {
-			if interval.IntervalSeconds > 0 {
-				nextTime := completionTime.Add(time.Duration(interval.IntervalSeconds) * time.Second)
-				ct.logger.Debugf("处于自定义间隔范围内，使用自定义间隔 %d 秒，下次执行时间: %v", 
-					interval.IntervalSeconds, nextTime)
-				return nextTime
-			}
-		}
+	// 结束时间精确到秒且包含在窗口内
+	end++
+	if end > secondsPerDay {
+		end = secondsPerDay
+	}
+	if start == end || start == secondsPerDay {
+		return nil, fmt.Errorf("时间窗口 %s-%s 无效", interval.StartTime, interval.EndTime)
 	}
 
-	// 如果不在自定义时间的区间内，则使用updateIntervalSeconds
-	if ct.monitorItem.UpdateIntervalSeconds > 0 {
-		nextTime := completionTime.Add(time.Duration(ct.monitorItem.UpdateIntervalSeconds) * time.Second)
-		ct.logger.Debugf("不在自定义间隔范围内，使用updateIntervalSeconds间隔 %d 秒，下次执行时间: %v", 
-			ct.monitorItem.UpdateIntervalSeconds, nextTime)
-		return nextTime
+	return &FlexibleInterval{
+		Interval: time.Duration(interval.IntervalSeconds) * time.Second,
+		Period: &TimePeriod{
+			WeekdayFrom: interval.Week,
+			WeekdayTo:   interval.Week,
+			Start:       start,
+			End:         end,
+		},
+	}, nil
+}
+
+// SetLocation 设置计算时间窗口所用的时区
+func (ct *CustomTrigger) SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
 	}
+	ct.location = loc
+}
 
-	// 如果没有配置任何间隔，返回零值（不再执行）
-	ct.logger.Warnf("监控项 %s 没有配置执行间隔", ct.monitorItem.ItemName)
-	return time.Time{}
+// Location 返回计算时间窗口所用的时区
+func (ct *CustomTrigger) Location() *time.Location {
+	return ct.location
 }
 
-// isInCustomInterval 检查当前时间是否在自定义间隔范围内
-func (ct *CustomTrigger) isInCustomInterval(interval *client.ItemCustomInterval, dayOfWeek int, currentTime string) bool {
-	// 检查星期是否匹配
-	if interval.Week != dayOfWeek {
-		return false
+// SetSpread 设置间隔内的固定偏移以及是否按墙上时间对齐
+// 对齐时执行时刻满足 (墙上时间 - offset) 为间隔的整数倍，例如60s间隔、偏移7s时在每分钟第7秒执行
+func (ct *CustomTrigger) SetSpread(offset time.Duration, align bool) {
+	if offset < 0 {
+		offset = 0
 	}
+	ct.offset = offset
+	ct.align = align
+}
 
-	// 检查时间范围
-	if interval.StartTime == "" || interval.EndTime == "" {
-		ct.logger.Warnf("自定义间隔的开始时间或结束时间为空")
-		return false
+// SpreadOffset 根据agentID和itemID计算确定性的偏移，范围为[0, interval)
+func SpreadOffset(agentID string, itemID int64, interval time.Duration) time.Duration {
+	millis := uint64(interval / time.Millisecond)
+	if millis == 0 {
+		return 0
 	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s:%d", agentID, itemID)
+	return time.Duration(h.Sum64()%millis) * time.Millisecond
+}
 
-	// 解析时间
-	startTime, err := time.Parse("15:04:05", interval.StartTime)
-	if err != nil {
-		ct.logger.Errorf("解析开始时间失败 %s: %v", interval.StartTime, err)
-		return false
+// FirstExecutionTimeAt 计算调度器启动后的首次执行时间
+// 未对齐但设置了偏移时，首次执行提前到now+偏移，使各agent的执行节奏错开
+func (ct *CustomTrigger) FirstExecutionTimeAt(now time.Time) time.Time {
+	next := ct.NextExecutionTimeAt(now, nil)
+	if ct.align || ct.offset <= 0 || next.IsZero() {
+		return next
 	}
 
-	endTime, err := time.Parse("15:04:05", interval.EndTime)
-	if err != nil {
-		ct.logger.Errorf("解析结束时间失败 %s: %v", interval.EndTime, err)
-		return false
+	delay := ct.DelayAt(now)
+	if delay <= 0 {
+		return next
 	}
 
-	current, err := time.Parse("15:04:05", currentTime)
-	if err != nil {
-		ct.logger.Errorf("解析当前时间失败 %s: %v", currentTime, err)
-		return false
+	first := now.Add(ct.offset % delay)
+	if first.Before(next) && ct.DelayAt(first) > 0 {
+		return first
 	}
+	return next
+}
 
-	// 检查当前时间是否在范围内 (startTime < current < endTime)
-	return current.After(startTime) && current.Before(endTime)
+// advance 返回t之后按delay推进的执行时间
+func (ct *CustomTrigger) advance(t time.Time, delay time.Duration) time.Time {
+	if !ct.align {
+		return t.Add(delay)
+	}
+
+	// 按墙上时间对齐：先换算到当地时间轴，再截断到间隔整数倍
+	_, zoneOffset := t.Zone()
+	shift := time.Duration(zoneOffset) * time.Second
+	base := t.Add(shift).Truncate(delay).Add(-shift).Add(ct.offset % delay)
+	for !base.After(t) {
+		base = base.Add(delay)
+	}
+	return base
+}
+
+// NextExecutionTime 计算下次执行时间，实现Java版本的nextExecutionTime逻辑
+func (ct *CustomTrigger) NextExecutionTime(lastCompletionTime *time.Time) time.Time {
+	return ct.NextExecutionTimeAt(time.Now(), lastCompletionTime)
+}
+
+// NextExecutionTimeAt 以now为当前时间计算下次执行时间，没有下次执行时间时返回零值
+func (ct *CustomTrigger) NextExecutionTimeAt(now time.Time, lastCompletionTime *time.Time) time.Time {
+	now = now.In(ct.location)
+
+	// 上次完成时间，如果为空则使用当前时间
+	completionTime := now
+	if lastCompletionTime != nil {
+		completionTime = lastCompletionTime.In(ct.location)
+	}
+
+	next := ct.nextByDelay(completionTime)
+
+	// 时间点调度不补执行已错过的时刻
+	after := completionTime
+	if now.After(after) {
+		after = now
+	}
+	for _, schedule := range ct.schedules {
+		candidate := schedule.Next(after)
+		if !candidate.IsZero() && (next.IsZero() || candidate.Before(next)) {
+			next = candidate
+		}
+	}
+
+	if next.IsZero() {
+		ct.logger.Warnf("监控项 %s 没有配置有效的执行间隔", ct.monitorItem.ItemName)
+	} else {
+		ct.logger.Debugf("监控项 %s 下次执行时间: %v", ct.monitorItem.ItemName, next)
+	}
+	return next
+}
+
+// DelayAt 返回t时刻生效的采集间隔，0表示不按间隔采集（静默期或未配置默认间隔）
+// 多个灵活间隔重叠时取最小值
+func (ct *CustomTrigger) DelayAt(t time.Time) time.Duration {
+	t = t.In(ct.location)
+
+	found := false
+	var delay time.Duration
+	for _, flexible := range ct.flexible {
+		if flexible.Period.Contains(t) && (!found || flexible.Interval < delay) {
+			delay = flexible.Interval
+			found = true
+		}
+	}
+	if found {
+		return delay
+	}
+	return ct.delay
+}
+
+// nextByDelay 按间隔计算from之后的下次执行时间
+func (ct *CustomTrigger) nextByDelay(from time.Time) time.Time {
+	t := from
+	for i := 0; i < 64; i++ {
+		delay := ct.DelayAt(t)
+		change := ct.nextDelayChange(t)
+
+		if delay > 0 {
+			candidate := ct.advance(t, delay)
+			// 进入间隔更短的时间段时，从时间段开始就按新间隔执行
+			if !change.IsZero() && candidate.After(change) {
+				if next := ct.DelayAt(change); next > 0 && next < delay {
+					return change
+				}
+			}
+			if ct.DelayAt(candidate) > 0 {
+				return candidate
+			}
+			// 候选时间落入静默期，等待静默期结束
+			t = candidate
+			continue
+		}
+
+		// 静默期或无默认间隔：等到间隔再次生效
+		if change.IsZero() {
+			return time.Time{}
+		}
+		t = change
+		if ct.DelayAt(t) > 0 {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// nextDelayChange 返回t之后生效间隔第一次发生变化的时刻，没有则返回零值
+func (ct *CustomTrigger) nextDelayChange(t time.Time) time.Time {
+	if len(ct.flexible) == 0 {
+		return time.Time{}
+	}
+
+	horizon := t.Add(delayChangeHorizon)
+	var boundaries []time.Time
+	for _, flexible := range ct.flexible {
+		boundaries = append(boundaries, flexible.Period.boundaries(t, horizon)...)
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	current := ct.DelayAt(t)
+	for _, boundary := range boundaries {
+		if boundary.After(t) && ct.DelayAt(boundary) != current {
+			return boundary
+		}
+	}
+	return time.Time{}
 }
 
 // GetMonitorItem 获取监控项
@@ -126,35 +327,30 @@ func (ct *CustomTrigger) GetMonitorItem() *services.CollectItem {
 
 // HasCustomInterval 检查是否有自定义间隔配置
 func (ct *CustomTrigger) HasCustomInterval() bool {
-	return len(ct.intervals) > 0
+	return len(ct.flexible) > 0 || len(ct.schedules) > 0
 }
 
 // ShouldExecuteNow 检查当前时间是否应该执行
 func (ct *CustomTrigger) ShouldExecuteNow() bool {
-	now := time.Now()
-	currentTime := now.Format("15:04:05")
-	dayOfWeek := int(now.Weekday())
+	return ct.ShouldExecuteAt(time.Now())
+}
 
-	if dayOfWeek == 0 {
-		dayOfWeek = 7
-	}
+// ShouldExecuteAt 检查now时刻是否应该执行：间隔生效，或刚到达调度时刻
+func (ct *CustomTrigger) ShouldExecuteAt(now time.Time) bool {
+	now = now.In(ct.location)
 
-	// 如果intervals数组为空或长度为0，则按照updateIntervalSeconds的秒数进行监控项上报
-	if len(ct.intervals) == 0 {
-		ct.logger.Debugf("监控项 %s intervals为空，可执行（由updateIntervalSeconds控制间隔）", ct.monitorItem.ItemName)
+	if ct.DelayAt(now) > 0 {
 		return true
 	}
 
-	// 如果有自定义间隔，检查是否在范围内
-	for _, interval := range ct.intervals {
-		if ct.isInCustomInterval(interval, dayOfWeek, currentTime) {
-			ct.logger.Debugf("监控项 %s 处于自定义间隔范围内，可执行", ct.monitorItem.ItemName)
+	// 定时器触发会略晚于调度时刻，允许一定的容差
+	for _, schedule := range ct.schedules {
+		next := schedule.Next(now.Add(-2 * time.Second))
+		if !next.IsZero() && !next.After(now) {
 			return true
 		}
 	}
-	
-	// 如果有自定义间隔但不在范围内，检查是否应该使用updateIntervalSeconds
-	// 根据需求：如果不处于自定义时间的区间内则再按照updateIntervalSeconds的秒数进行监控项上报
-	ct.logger.Debugf("监控项 %s 不在自定义间隔范围内，可使用updateIntervalSeconds执行", ct.monitorItem.ItemName)
-	return true
+
+	ct.logger.Debugf("监控项 %s 处于静默期，不执行", ct.monitorItem.ItemName)
+	return false
 }
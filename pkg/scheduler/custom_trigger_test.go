@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestTrigger(t *testing.T, delaySeconds int, intervals ...*client.ItemCustomInterval) *CustomTrigger {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	item := &services.CollectItem{
+		ItemName:              "test",
+		UpdateIntervalSeconds: delaySeconds,
+		Intervals:             intervals,
+	}
+	ct := NewCustomTrigger(item, logger)
+	ct.SetLocation(time.UTC)
+	return ct
+}
+
+func TestParseWeekWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval client.ItemCustomInterval
+		wantErr  bool
+		skipped  bool // 旧版0间隔窗口被忽略
+		contains map[time.Time]bool
+	}{
+		{
+			name:     "结束时间包含在窗口内",
+			interval: client.ItemCustomInterval{Week: 1, StartTime: "09:00:00", EndTime: "18:00:00", IntervalSeconds: 60},
+			contains: map[time.Time]bool{
+				at(1, 8, 59, 59): false,
+				at(1, 9, 0, 0):   true,
+				at(1, 18, 0, 0):  true,
+				at(1, 18, 0, 1):  false,
+				at(2, 10, 0, 0):  false,
+			},
+		},
+		{
+			name:     "结束于当天最后一秒",
+			interval: client.ItemCustomInterval{Week: 7, StartTime: "20:00:00", EndTime: "23:59:59", IntervalSeconds: 60},
+			contains: map[time.Time]bool{
+				at(7, 23, 59, 59): true,
+				at(8, 0, 0, 0):    false,
+			},
+		},
+		{
+			name:     "跨越午夜",
+			interval: client.ItemCustomInterval{Week: 5, StartTime: "22:00:00", EndTime: "01:59:59", IntervalSeconds: 300},
+			contains: map[time.Time]bool{
+				at(5, 21, 59, 59): false,
+				at(5, 22, 0, 0):   true,
+				at(6, 1, 59, 59):  true,
+				at(6, 2, 0, 0):    false,
+			},
+		},
+		{
+			name:     "0间隔被忽略",
+			interval: client.ItemCustomInterval{Week: 1, StartTime: "00:00:00", EndTime: "07:59:59", IntervalSeconds: 0},
+			skipped:  true,
+		},
+		{name: "负数间隔", interval: client.ItemCustomInterval{Week: 1, StartTime: "09:00:00", EndTime: "18:00:00", IntervalSeconds: -1}, wantErr: true},
+		{name: "星期超出范围", interval: client.ItemCustomInterval{Week: 8, StartTime: "09:00:00", EndTime: "18:00:00", IntervalSeconds: 60}, wantErr: true},
+		{name: "缺少结束时间", interval: client.ItemCustomInterval{Week: 1, StartTime: "09:00:00", IntervalSeconds: 60}, wantErr: true},
+		{name: "时间格式错误", interval: client.ItemCustomInterval{Week: 1, StartTime: "9", EndTime: "18:00:00", IntervalSeconds: 60}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flexible, err := parseWeekWindow(&tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWeekWindow error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.skipped {
+				if flexible != nil {
+					t.Errorf("parseWeekWindow = %+v, want nil", flexible)
+				}
+				return
+			}
+			if want := time.Duration(tt.interval.IntervalSeconds) * time.Second; flexible.Interval != want {
+				t.Errorf("Interval = %v, want %v", flexible.Interval, want)
+			}
+			for ts, want := range tt.contains {
+				if got := flexible.Period.Contains(ts); got != want {
+					t.Errorf("Contains(%v) = %v, want %v", ts, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomTriggerBlackout(t *testing.T) {
+	ct := newTestTrigger(t, 60, &client.ItemCustomInterval{Type: IntervalTypeFlexible, Expression: "0/1,00:00-08:00"})
+
+	if ct.ShouldExecuteAt(at(1, 3, 0, 0)) {
+		t.Error("ShouldExecuteAt in blackout = true, want false")
+	}
+	if !ct.ShouldExecuteAt(at(1, 8, 0, 0)) {
+		t.Error("ShouldExecuteAt after blackout = false, want true")
+	}
+	if got := ct.DelayAt(at(1, 3, 0, 0)); got != 0 {
+		t.Errorf("DelayAt in blackout = %v, want 0", got)
+	}
+	// 静默期内不执行，静默期结束时立即恢复
+	if got, want := ct.NextExecutionTimeAt(at(1, 3, 0, 0), nil), at(1, 8, 0, 0); !got.Equal(want) {
+		t.Errorf("NextExecutionTimeAt in blackout = %v, want %v", got, want)
+	}
+	// 默认间隔推进到静默期内时等待静默期结束
+	last := at(7, 23, 59, 30)
+	if got, want := ct.NextExecutionTimeAt(last, &last), at(8, 8, 0, 0); !got.Equal(want) {
+		t.Errorf("NextExecutionTimeAt before blackout = %v, want %v", got, want)
+	}
+}
+
+func TestCustomTriggerNextExecutionTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     int
+		intervals []*client.ItemCustomInterval
+		now       time.Time
+		want      time.Time
+	}{
+		{
+			name:  "默认间隔",
+			delay: 60,
+			now:   at(1, 10, 0, 0),
+			want:  at(1, 10, 1, 0),
+		},
+		{
+			name:      "窗口内使用更短间隔",
+			delay:     300,
+			intervals: []*client.ItemCustomInterval{{Week: 1, StartTime: "09:00:00", EndTime: "18:00:00", IntervalSeconds: 30}},
+			now:       at(1, 10, 0, 0),
+			want:      at(1, 10, 0, 30),
+		},
+		{
+			name:      "进入更短间隔的窗口时从窗口开始执行",
+			delay:     300,
+			intervals: []*client.ItemCustomInterval{{Week: 1, StartTime: "09:00:00", EndTime: "18:00:00", IntervalSeconds: 30}},
+			now:       at(1, 8, 58, 0),
+			want:      at(1, 9, 0, 0),
+		},
+		{
+			name:      "调度间隔",
+			intervals: []*client.ItemCustomInterval{{Type: IntervalTypeScheduling, Expression: "wd1-5h9m0"}},
+			now:       at(6, 10, 0, 0),
+			want:      at(8, 9, 0, 0),
+		},
+		{
+			name:      "cron与默认间隔取较早者",
+			delay:     3600,
+			intervals: []*client.ItemCustomInterval{{Type: IntervalTypeCron, Expression: "*/5 * * * *"}},
+			now:       at(1, 10, 2, 0),
+			want:      at(1, 10, 5, 0),
+		},
+		{
+			name:      "0间隔的星期窗口沿用默认间隔",
+			delay:     60,
+			intervals: []*client.ItemCustomInterval{{Week: 1, StartTime: "00:00:00", EndTime: "07:59:59", IntervalSeconds: 0}},
+			now:       at(1, 3, 0, 0),
+			want:      at(1, 3, 1, 0),
+		},
+		{
+			name: "没有有效间隔",
+			now:  at(1, 10, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := newTestTrigger(t, tt.delay, tt.intervals...)
+			if got := ct.NextExecutionTimeAt(tt.now, nil); !got.Equal(tt.want) {
+				t.Errorf("NextExecutionTimeAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomTriggerLocation(t *testing.T) {
+	// 东八区周一00:00-08:00不采集
+	ct := newTestTrigger(t, 60, &client.ItemCustomInterval{Type: IntervalTypeFlexible, Expression: "0/1,00:00-08:00"})
+	now := time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC) // 东八区周一07:00
+
+	if !ct.ShouldExecuteAt(now) {
+		t.Error("ShouldExecuteAt in UTC = false, want true")
+	}
+
+	ct.SetLocation(time.FixedZone("CST", 8*3600))
+	if ct.ShouldExecuteAt(now) {
+		t.Error("ShouldExecuteAt in +08:00 = true, want false")
+	}
+	if got, want := ct.NextExecutionTimeAt(now, nil), time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextExecutionTimeAt in +08:00 = %v, want %v", got, want)
+	}
+}
+
+// 调度时刻之后不足2秒的触发仍然执行
+func TestShouldExecuteAtTolerance(t *testing.T) {
+	ct := newTestTrigger(t, 0, &client.ItemCustomInterval{Type: IntervalTypeCron, Expression: "0 0 9 * * *"})
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(1, 8, 59, 59), false},
+		{at(1, 9, 0, 0), true},
+		{at(1, 9, 0, 1).Add(500 * time.Millisecond), true},
+		{at(1, 9, 0, 2).Add(-time.Millisecond), true},
+		{at(1, 9, 0, 2), false},
+		{at(1, 9, 0, 3), false},
+	}
+	for _, tt := range tests {
+		if got := ct.ShouldExecuteAt(tt.now); got != tt.want {
+			t.Errorf("ShouldExecuteAt(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestSpreadOffset(t *testing.T) {
+	interval := time.Minute
+	offset := SpreadOffset("agent-1", 42, interval)
+	if offset < 0 || offset >= interval {
+		t.Fatalf("SpreadOffset = %v, want [0, %v)", offset, interval)
+	}
+	if again := SpreadOffset("agent-1", 42, interval); again != offset {
+		t.Errorf("SpreadOffset not deterministic: %v != %v", again, offset)
+	}
+	if got := SpreadOffset("agent-1", 42, 0); got != 0 {
+		t.Errorf("SpreadOffset with zero interval = %v, want 0", got)
+	}
+}
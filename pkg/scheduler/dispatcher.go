@@ -0,0 +1,471 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"go-agent/pkg/logger"
+)
+
+// 监控项执行重叠策略
+const (
+	OverlapSkip     = "skip"     // 上次执行未完成时跳过本次
+	OverlapCoalesce = "coalesce" // 上次执行未完成时合并为完成后立即补执行一次
+)
+
+// DispatcherConfig 调度分发器配置
+type DispatcherConfig struct {
+	Workers         int            // 工作协程数量
+	QueueSize       int            // 待执行队列长度
+	OverlapPolicy   string         // 执行重叠策略
+	CollectorLimits map[string]int // 每类采集器的最大并发数
+}
+
+// DispatcherStats 调度分发器统计
+type DispatcherStats struct {
+	Scheduled int    `json:"scheduled"` // 已排期的监控项数
+	Running   int    `json:"running"`   // 正在执行或排队执行的监控项数
+	Waiting   int    `json:"waiting"`   // 因采集器并发限制等待执行的监控项数
+	Workers   int    `json:"workers"`
+	Executed  uint64 `json:"executed"`
+	Skipped   uint64 `json:"skipped"`
+	Coalesced uint64 `json:"coalesced"`
+}
+
+// dispatchEntry 优先队列中的排期项
+type dispatchEntry struct {
+	item  *ItemScheduler
+	next  time.Time
+	index int
+}
+
+// dispatchJob 一次提交的执行，记录提交时的采集器类别和排期代数
+// 并发额度按作业自身记录的类别释放，清空排期后旧代数的作业不再执行
+type dispatchJob struct {
+	item       *ItemScheduler
+	kind       string
+	generation uint64
+}
+
+// dispatchQueue 按下次执行时间排序的最小堆
+type dispatchQueue []*dispatchEntry
+
+func (q dispatchQueue) Len() int           { return len(q) }
+func (q dispatchQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q dispatchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *dispatchQueue) Push(x interface{}) {
+	entry := x.(*dispatchEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *dispatchQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// Dispatcher 集中调度所有监控项：由单个优先队列计算到期项，再分发给有限数量的工作协程执行
+// 达到采集器并发限制的监控项在分发器中等待，不占用工作协程，避免慢采集器阻塞其他类别
+type Dispatcher struct {
+	config  DispatcherConfig
+	execute func(ctx context.Context, item *ItemScheduler)
+	kindOf  func(item *ItemScheduler) string
+	queue   dispatchQueue
+	entries map[int64]*dispatchEntry
+	running map[int64]*dispatchJob // 正在执行或排队执行的监控项
+	pending map[int64]bool
+	limits  map[string]int
+	active  map[string]int            // 每类采集器已提交执行的监控项数
+	waiting map[string][]*dispatchJob // 每类采集器因并发限制等待执行的监控项
+	jobs    chan *dispatchJob
+	wakeup  chan struct{}
+	mu      sync.Mutex
+	stats   DispatcherStats
+	gen     uint64 // 排期代数，Clear 时递增
+	started bool
+}
+
+// NewDispatcher 创建调度分发器
+// execute 执行一次监控项采集；kindOf 返回监控项所属采集器类别，用于并发限制
+func NewDispatcher(config DispatcherConfig, execute func(ctx context.Context, item *ItemScheduler), kindOf func(item *ItemScheduler) string) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = 10
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = config.Workers * 4
+	}
+	if config.OverlapPolicy != OverlapCoalesce {
+		config.OverlapPolicy = OverlapSkip
+	}
+
+	limits := make(map[string]int)
+	for kind, limit := range config.CollectorLimits {
+		if limit > 0 {
+			limits[kind] = limit
+		}
+	}
+
+	return &Dispatcher{
+		config:  config,
+		execute: execute,
+		kindOf:  kindOf,
+		entries: make(map[int64]*dispatchEntry),
+		running: make(map[int64]*dispatchJob),
+		pending: make(map[int64]bool),
+		limits:  limits,
+		active:  make(map[string]int),
+		waiting: make(map[string][]*dispatchJob),
+		jobs:    make(chan *dispatchJob, config.QueueSize),
+		wakeup:  make(chan struct{}, 1),
+		stats:   DispatcherStats{Workers: config.Workers},
+	}
+}
+
+// Start 启动分发循环和工作协程，协程在ctx取消后退出
+func (d *Dispatcher) Start(ctx context.Context, wg *sync.WaitGroup) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.started {
+		return
+	}
+	d.started = true
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.loop(ctx)
+	}()
+
+	for i := 0; i < d.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.worker(ctx)
+		}()
+	}
+
+	logger.Infof("监控项调度分发器已启动: 工作协程 %d, 重叠策略 %s", d.config.Workers, d.config.OverlapPolicy)
+}
+
+// Schedule 将监控项加入排期，已存在时更新其下次执行时间
+func (d *Dispatcher) Schedule(item *ItemScheduler, next time.Time) {
+	d.mu.Lock()
+	if entry, exists := d.entries[item.ItemID]; exists {
+		entry.item = item
+		entry.next = next
+		heap.Fix(&d.queue, entry.index)
+	} else {
+		entry := &dispatchEntry{item: item, next: next}
+		heap.Push(&d.queue, entry)
+		d.entries[item.ItemID] = entry
+	}
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+// Remove 移除监控项排期，正在执行的采集会执行完毕
+func (d *Dispatcher) Remove(itemID int64) {
+	d.mu.Lock()
+	if entry, exists := d.entries[itemID]; exists {
+		heap.Remove(&d.queue, entry.index)
+		delete(d.entries, itemID)
+	}
+	delete(d.pending, itemID)
+	d.dropWaiting(func(item *ItemScheduler) bool { return item.ItemID == itemID })
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+// Clear 移除所有监控项排期，并丢弃已排队但未开始执行的作业
+// 正在执行的采集会执行完毕并释放其并发额度，但不再阻止同ID新监控项的执行
+func (d *Dispatcher) Clear() {
+	d.mu.Lock()
+	d.gen++
+	d.queue = nil
+	d.entries = make(map[int64]*dispatchEntry)
+	d.pending = make(map[int64]bool)
+	d.dropWaiting(func(*ItemScheduler) bool { return true })
+	for drained := false; !drained; {
+		select {
+		case job := <-d.jobs:
+			d.release(job)
+		default:
+			drained = true
+		}
+	}
+	d.running = make(map[int64]*dispatchJob)
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+// dropWaiting 移除满足条件的等待项，调用方需持有锁
+func (d *Dispatcher) dropWaiting(match func(item *ItemScheduler) bool) {
+	for kind, jobs := range d.waiting {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if match(job.item) {
+				d.untrack(job)
+			} else {
+				kept = append(kept, job)
+			}
+		}
+		d.waiting[kind] = kept
+	}
+}
+
+// untrack 移除作业的执行中标记，同ID的新作业已占用时保留，调用方需持有锁
+func (d *Dispatcher) untrack(job *dispatchJob) {
+	if d.running[job.item.ItemID] == job {
+		delete(d.running, job.item.ItemID)
+	}
+}
+
+// release 释放已入队作业的执行中标记和采集器并发额度，调用方需持有锁
+func (d *Dispatcher) release(job *dispatchJob) {
+	d.untrack(job)
+	if d.limits[job.kind] > 0 && d.active[job.kind] > 0 {
+		d.active[job.kind]--
+	}
+}
+
+// NextRun 返回监控项的下次执行时间
+func (d *Dispatcher) NextRun(itemID int64) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, exists := d.entries[itemID]
+	if !exists {
+		return time.Time{}, false
+	}
+	return entry.next, true
+}
+
+// Stats 返回调度统计
+func (d *Dispatcher) Stats() DispatcherStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.Scheduled = len(d.entries)
+	stats.Running = len(d.running)
+	for _, items := range d.waiting {
+		stats.Waiting += len(items)
+	}
+	return stats
+}
+
+// notify 唤醒分发循环重新计算等待时间
+func (d *Dispatcher) notify() {
+	select {
+	case d.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// loop 分发循环：取出所有到期项分发执行，然后等待到下一个到期时间
+func (d *Dispatcher) loop(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		now := time.Now()
+		var due []*ItemScheduler
+
+		d.mu.Lock()
+		for d.queue.Len() > 0 && !d.queue[0].next.After(now) {
+			entry := heap.Pop(&d.queue).(*dispatchEntry)
+			delete(d.entries, entry.item.ItemID)
+			due = append(due, entry.item)
+		}
+		d.mu.Unlock()
+
+		for _, item := range due {
+			d.dispatch(item, now)
+		}
+
+		wait := time.Hour
+		d.mu.Lock()
+		if d.queue.Len() > 0 {
+			wait = time.Until(d.queue[0].next)
+		}
+		d.mu.Unlock()
+		if wait < 0 {
+			wait = 0
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			logger.Debug("监控项调度分发器已停止")
+			return
+		case <-d.wakeup:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch 处理一个到期的监控项：先按触发器排好下一次，再交给工作协程执行
+func (d *Dispatcher) dispatch(item *ItemScheduler, now time.Time) {
+	trigger := item.customTrigger
+
+	if !trigger.ShouldExecuteAt(now) {
+		// 处于静默期，等到下次执行时间再检查
+		if next := trigger.NextExecutionTimeAt(now, nil); !next.IsZero() {
+			d.Schedule(item, next)
+		}
+		return
+	}
+
+	// 按到期时刻排期下一次，执行耗时不影响采集节奏
+	item.lastExecutionTime = &now
+	if next := trigger.NextExecutionTimeAt(now, &now); !next.IsZero() {
+		if !next.After(now) {
+			next = now.Add(time.Second) // 最小1秒间隔
+		}
+		d.Schedule(item, next)
+	} else {
+		logger.Infof("监控项 %s 没有下次执行时间，停止调度", item.ItemName)
+	}
+
+	d.submit(item)
+}
+
+// submit 提交执行，上次执行未完成时按重叠策略跳过或合并，采集器达到并发限制时等待
+func (d *Dispatcher) submit(item *ItemScheduler) {
+	// kindOf 会访问采集器状态，在锁外计算
+	kind := ""
+	if d.kindOf != nil {
+		kind = d.kindOf(item)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, busy := d.running[item.ItemID]; busy {
+		if d.config.OverlapPolicy == OverlapCoalesce {
+			if !d.pending[item.ItemID] {
+				d.pending[item.ItemID] = true
+				d.stats.Coalesced++
+			}
+			logger.Debugf("监控项 %s 上次采集未完成，完成后补执行一次", item.ItemName)
+			return
+		}
+		d.stats.Skipped++
+		logger.Warnf("监控项 %s 上次采集未完成，跳过本次执行", item.ItemName)
+		return
+	}
+
+	job := &dispatchJob{item: item, kind: kind, generation: d.gen}
+	if limit := d.limits[kind]; limit > 0 && d.active[kind] >= limit {
+		if len(d.waiting[kind]) >= d.config.QueueSize {
+			d.stats.Skipped++
+			logger.Warnf("采集器 %s 等待队列已满（%d），跳过监控项 %s 的本次执行", kind, d.config.QueueSize, item.ItemName)
+			return
+		}
+		d.waiting[kind] = append(d.waiting[kind], job)
+		d.running[item.ItemID] = job
+		logger.Debugf("采集器 %s 已达到并发限制 %d，监控项 %s 等待执行", kind, limit, item.ItemName)
+		return
+	}
+
+	d.enqueue(job)
+}
+
+// enqueue 将作业放入执行队列并占用采集器并发额度，调用方需持有锁
+func (d *Dispatcher) enqueue(job *dispatchJob) {
+	select {
+	case d.jobs <- job:
+		d.running[job.item.ItemID] = job
+		if d.limits[job.kind] > 0 {
+			d.active[job.kind]++
+		}
+	default:
+		d.untrack(job)
+		d.stats.Skipped++
+		logger.Warnf("采集队列已满（%d），跳过监控项 %s 的本次执行", cap(d.jobs), job.item.ItemName)
+	}
+}
+
+// worker 工作协程
+func (d *Dispatcher) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-d.jobs:
+			d.run(ctx, job)
+		}
+	}
+}
+
+// run 执行监控项，采集器并发限制已在提交时保证；清空排期前提交的作业不再执行
+func (d *Dispatcher) run(ctx context.Context, job *dispatchJob) {
+	item := job.item
+	defer d.finish(job)
+
+	d.mu.Lock()
+	stale := job.generation != d.gen
+	d.mu.Unlock()
+	if stale {
+		logger.Debugf("监控项 %s 的排期已被清空，丢弃本次执行", item.ItemName)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("监控项 %s 采集出现panic: %v", item.ItemName, r)
+		}
+	}()
+
+	d.execute(ctx, item)
+
+	d.mu.Lock()
+	d.stats.Executed++
+	d.mu.Unlock()
+}
+
+// finish 标记执行完成并释放采集器并发额度，让同类等待项执行；有合并的执行请求且监控项仍在排期中时立即补执行
+func (d *Dispatcher) finish(job *dispatchJob) {
+	item, kind := job.item, job.kind
+
+	d.mu.Lock()
+	d.release(job)
+	if d.limits[kind] > 0 {
+		for len(d.waiting[kind]) > 0 && d.active[kind] < d.limits[kind] {
+			next := d.waiting[kind][0]
+			d.waiting[kind] = d.waiting[kind][1:]
+			d.enqueue(next)
+		}
+	}
+	rerun := d.pending[item.ItemID]
+	delete(d.pending, item.ItemID)
+	entry, scheduled := d.entries[item.ItemID]
+	d.mu.Unlock()
+
+	if rerun && scheduled && entry.item == item {
+		d.submit(item)
+	}
+}
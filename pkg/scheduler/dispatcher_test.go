@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 达到并发限制的采集器不应占满工作协程，其他类别的监控项仍能执行
+func TestDispatcherCollectorLimitDoesNotStarveWorkers(t *testing.T) {
+	release := make(chan struct{})
+	executed := make(chan int64, 16)
+
+	var mu sync.Mutex
+	concurrent, maxConcurrent := 0, 0
+
+	d := NewDispatcher(DispatcherConfig{
+		Workers:         2,
+		CollectorLimits: map[string]int{CollectorKindCommand: 1},
+	}, func(ctx context.Context, item *ItemScheduler) {
+		if item.ItemKey == "slow" {
+			mu.Lock()
+			concurrent++
+			if concurrent > maxConcurrent {
+				maxConcurrent = concurrent
+			}
+			mu.Unlock()
+
+			<-release
+
+			mu.Lock()
+			concurrent--
+			mu.Unlock()
+		}
+		executed <- item.ItemID
+	}, func(item *ItemScheduler) string {
+		if item.ItemKey == "slow" {
+			return CollectorKindCommand
+		}
+		return CollectorKindSystem
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	d.Start(ctx, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for id := int64(1); id <= 3; id++ {
+		d.submit(&ItemScheduler{ItemID: id, ItemName: "slow", ItemKey: "slow"})
+	}
+	if stats := d.Stats(); stats.Waiting != 2 {
+		t.Fatalf("Waiting = %d, want 2", stats.Waiting)
+	}
+
+	d.submit(&ItemScheduler{ItemID: 10, ItemName: "fast", ItemKey: "fast"})
+	select {
+	case id := <-executed:
+		if id != 10 {
+			t.Fatalf("executed item %d first, want 10", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("system item starved by command limit")
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-executed:
+		case <-time.After(time.Second):
+			t.Fatal("waiting command items were not executed")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxConcurrent != 1 {
+		t.Errorf("max concurrent command items = %d, want 1", maxConcurrent)
+	}
+	if stats := d.Stats(); stats.Waiting != 0 || stats.Skipped != 0 {
+		t.Errorf("stats = %+v, want no waiting or skipped items", stats)
+	}
+}
+
+// 移除监控项时同时移除其等待中的执行
+func TestDispatcherRemoveDropsWaiting(t *testing.T) {
+	d := NewDispatcher(DispatcherConfig{
+		Workers:         1,
+		CollectorLimits: map[string]int{CollectorKindCommand: 1},
+	}, func(ctx context.Context, item *ItemScheduler) {}, func(item *ItemScheduler) string {
+		return CollectorKindCommand
+	})
+
+	// 未启动工作协程，第一项占用并发额度，第二项等待
+	d.submit(&ItemScheduler{ItemID: 1, ItemName: "a"})
+	d.submit(&ItemScheduler{ItemID: 2, ItemName: "b"})
+	if stats := d.Stats(); stats.Waiting != 1 || stats.Running != 2 {
+		t.Fatalf("stats = %+v, want 1 waiting and 2 running", stats)
+	}
+
+	d.Remove(2)
+	if stats := d.Stats(); stats.Waiting != 0 || stats.Running != 1 {
+		t.Errorf("stats after Remove = %+v, want 0 waiting and 1 running", stats)
+	}
+}
+
+// 清空排期时丢弃已排队的旧作业并释放其执行中标记和并发额度，同ID的新监控项可立即执行
+func TestDispatcherClearDropsQueuedJobs(t *testing.T) {
+	executed := make(chan *ItemScheduler, 16)
+	d := NewDispatcher(DispatcherConfig{
+		Workers:         1,
+		CollectorLimits: map[string]int{CollectorKindCommand: 1},
+	}, func(ctx context.Context, item *ItemScheduler) {
+		executed <- item
+	}, func(item *ItemScheduler) string {
+		return CollectorKindCommand
+	})
+
+	// 未启动工作协程，第一项进入执行队列，第二项等待
+	old := &ItemScheduler{ItemID: 1, ItemName: "old"}
+	d.submit(old)
+	d.submit(&ItemScheduler{ItemID: 2, ItemName: "waiting"})
+
+	d.Clear()
+	if stats := d.Stats(); stats.Running != 0 || stats.Waiting != 0 {
+		t.Fatalf("stats after Clear = %+v, want nothing running or waiting", stats)
+	}
+	if active := d.active[CollectorKindCommand]; active != 0 {
+		t.Fatalf("active after Clear = %d, want 0", active)
+	}
+
+	// 复用同一ID的新监控项不应被跳过或等待
+	renewed := &ItemScheduler{ItemID: 1, ItemName: "new"}
+	d.submit(renewed)
+	if stats := d.Stats(); stats.Running != 1 || stats.Waiting != 0 || stats.Skipped != 0 {
+		t.Fatalf("stats after resubmit = %+v, want 1 running and nothing skipped", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	d.Start(ctx, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	select {
+	case item := <-executed:
+		if item != renewed {
+			t.Fatalf("executed %q, want the new item", item.ItemName)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("new item was not executed")
+	}
+	select {
+	case item := <-executed:
+		t.Fatalf("stale item %q executed after Clear", item.ItemName)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// 执行中的旧作业在清空排期后完成时，按自身记录释放额度，不影响同ID的新作业
+func TestDispatcherFinishReleasesOwnSlot(t *testing.T) {
+	d := NewDispatcher(DispatcherConfig{
+		Workers:         1,
+		CollectorLimits: map[string]int{CollectorKindCommand: 1},
+	}, func(ctx context.Context, item *ItemScheduler) {}, func(item *ItemScheduler) string {
+		return CollectorKindCommand
+	})
+
+	d.submit(&ItemScheduler{ItemID: 1, ItemName: "old"})
+	// 模拟工作协程已取走作业
+	job := <-d.jobs
+
+	d.Clear()
+	// 旧作业仍占用并发额度，同ID的新监控项等待执行而不是被跳过
+	d.submit(&ItemScheduler{ItemID: 1, ItemName: "new"})
+	if stats := d.Stats(); stats.Waiting != 1 || stats.Skipped != 0 {
+		t.Fatalf("stats = %+v, want the new item waiting", stats)
+	}
+
+	// 旧作业完成：只释放自己占用的额度，新作业随即入队且保留执行中标记
+	d.finish(job)
+	if active := d.active[CollectorKindCommand]; active != 1 {
+		t.Errorf("active = %d, want 1", active)
+	}
+	if running := d.running[1]; running == nil || running.item.ItemName != "new" {
+		t.Errorf("running[1] = %+v, want the new job", running)
+	}
+
+	d.finish(<-d.jobs)
+	if active := d.active[CollectorKindCommand]; active != 0 {
+		t.Errorf("active after all finished = %d, want 0", active)
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/config"
+)
+
+// 采集器类别，与collectItemValue的处理优先级一致；原生服务采集器的类别为采集器名称（如 mysql）
+const (
+	CollectorKindCommand = "command" // 命令映射（command_mapping.yaml）
+	CollectorKindBuiltin = "builtin" // 内置键
+	CollectorKindSystem  = "system"  // 硬编码的系统指标（向后兼容）
+)
+
+// KeyEvaluation 临时采集单个监控项key的结果
+type KeyEvaluation struct {
+	Key         string
+	Value       *client.TypedValue
+	Collector   string
+	CollectedAt time.Time
+	Duration    time.Duration
+}
+
+// Evaluate 在本地临时采集任意受支持的key，不需要数据中心下发的监控项ID，也不发送任何数据
+// infoType为nil时根据采集到的值推断值类型
+func Evaluate(ctx context.Context, cfg *config.Config, key string, infoType *client.InfoType) (*KeyEvaluation, error) {
+	s := New()
+	s.config = cfg
+	s.ctx = ctx
+
+	if err := s.initCollectors(); err != nil {
+		return nil, fmt.Errorf("初始化采集器失败: %v", err)
+	}
+	defer s.closeServiceCollectors()
+	if err := s.initBuiltinKeyManager(); err != nil {
+		return nil, fmt.Errorf("初始化内置键管理器失败: %v", err)
+	}
+	// 不创建数据中心客户端和指标发送器，命令执行结果只返回不上报
+	s.loadCommandCollector()
+	if s.commandCollector != nil {
+		defer s.commandCollector.Close()
+	}
+
+	timeout := cfg.Agent.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	evalCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	rawValue, collectedAt, kind, err := s.collectItemValueFrom(evalCtx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	valueType := client.InferInfoType(rawValue)
+	if infoType != nil {
+		valueType = *infoType
+	}
+	value, err := client.ConvertValue(rawValue, valueType)
+	if err != nil {
+		return nil, fmt.Errorf("值类型转换失败 (类型: %s): %v", valueType, err)
+	}
+
+	return &KeyEvaluation{
+		Key:         key,
+		Value:       value,
+		Collector:   kind,
+		CollectedAt: collectedAt,
+		Duration:    time.Since(start),
+	}, nil
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/config"
+)
+
+// get子命令：带参数的内置键按参数采集指定路径的文件系统空间
+func TestEvaluateParameterizedBuiltinKey(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Collect.System.Enabled = true
+	cfg.Agent.CommandMapping = filepath.Join(t.TempDir(), "missing.yaml")
+	dir := t.TempDir()
+
+	floatType := client.InfoTypeFloat
+	result, err := Evaluate(context.Background(), cfg, "vfs.fs.size["+dir+",pused]", &floatType)
+	if err != nil {
+		t.Fatalf("Evaluate pused error: %v", err)
+	}
+	if result.Collector != CollectorKindBuiltin {
+		t.Errorf("Collector = %s, want %s", result.Collector, CollectorKindBuiltin)
+	}
+	if result.Value.Type != client.InfoTypeFloat || result.Value.Float < 0 || result.Value.Float > 100 {
+		t.Errorf("pused = %s (%s), want a percentage", result.Value, result.Value.Type)
+	}
+
+	// 未指定值类型时按结果推断，默认模式为total
+	result, err = Evaluate(context.Background(), cfg, "vfs.fs.size["+dir+"]", nil)
+	if err != nil {
+		t.Fatalf("Evaluate total error: %v", err)
+	}
+	if result.Value.Type != client.InfoTypeUnsigned || result.Value.Unsigned == 0 {
+		t.Errorf("total = %s (%s), want a positive unsigned value", result.Value, result.Value.Type)
+	}
+
+	for _, key := range []string{
+		"vfs.fs.size[" + dir + ",bogus]",
+		"vfs.fs.size[,total]",
+		"vfs.fs.size[" + filepath.Join(dir, "missing") + ",total]",
+	} {
+		if _, err := Evaluate(context.Background(), cfg, key, nil); err == nil {
+			t.Errorf("Evaluate(%s) succeeded, want error", key)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 自定义间隔类型
+const (
+	IntervalTypeFlexible   = "flexible"   // 灵活间隔: "50s/1-5,09:00-18:00"
+	IntervalTypeScheduling = "scheduling" // 调度间隔: "wd1-5h9m0"
+	IntervalTypeCron       = "cron"       // cron表达式: "0 */5 * * * *"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// TimeSchedule 按固定时间点触发的调度（调度间隔、cron表达式）
+type TimeSchedule interface {
+	// Next 返回严格晚于after的下一个触发时间，没有则返回零值
+	Next(after time.Time) time.Time
+}
+
+// TimePeriod 时间段，格式 "d-d,hh:mm[:ss]-hh:mm[:ss]"，星期1为周一、7为周日
+// 开始时间晚于结束时间表示跨越午夜，跨过午夜的部分属于开始那一天
+type TimePeriod struct {
+	WeekdayFrom int
+	WeekdayTo   int
+	Start       int // 当天开始秒数（含）
+	End         int // 当天结束秒数（不含），最大为24:00
+}
+
+// ParseTimePeriod 解析时间段
+func ParseTimePeriod(s string) (*TimePeriod, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.SplitN(s, ",", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("时间段格式错误: %q", s)
+	}
+
+	period := &TimePeriod{}
+	days := strings.SplitN(parts[0], "-", 2)
+	from, err := parseRangeValue(days[0], 1, 7)
+	if err != nil {
+		return nil, fmt.Errorf("时间段星期格式错误 %q: %v", s, err)
+	}
+	period.WeekdayFrom, period.WeekdayTo = from, from
+	if len(days) == 2 {
+		to, err := parseRangeValue(days[1], 1, 7)
+		if err != nil {
+			return nil, fmt.Errorf("时间段星期格式错误 %q: %v", s, err)
+		}
+		if to < from {
+			return nil, fmt.Errorf("时间段星期范围错误: %q", s)
+		}
+		period.WeekdayTo = to
+	}
+
+	times := strings.SplitN(parts[1], "-", 2)
+	if len(times) != 2 {
+		return nil, fmt.Errorf("时间段时间格式错误: %q", s)
+	}
+	if period.Start, err = ParseClock(times[0]); err != nil {
+		return nil, fmt.Errorf("时间段开始时间格式错误 %q: %v", s, err)
+	}
+	if period.End, err = ParseClock(times[1]); err != nil {
+		return nil, fmt.Errorf("时间段结束时间格式错误 %q: %v", s, err)
+	}
+	if period.Start == period.End {
+		return nil, fmt.Errorf("时间段开始时间与结束时间相同: %q", s)
+	}
+	if period.Start == secondsPerDay {
+		return nil, fmt.Errorf("时间段开始时间不能为24:00: %q", s)
+	}
+
+	return period, nil
+}
+
+// ParseClock 解析 "hh:mm" 或 "hh:mm:ss" 为当天秒数，允许 "24:00"
+func ParseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("时间格式错误: %q", s)
+	}
+
+	limits := []int{24, 59, 59}
+	values := make([]int, 3)
+	for i, part := range parts {
+		v, err := parseRangeValue(part, 0, limits[i])
+		if err != nil {
+			return 0, fmt.Errorf("时间格式错误 %q: %v", s, err)
+		}
+		values[i] = v
+	}
+
+	seconds := values[0]*3600 + values[1]*60 + values[2]
+	if seconds > secondsPerDay {
+		return 0, fmt.Errorf("时间超出范围: %q", s)
+	}
+	return seconds, nil
+}
+
+// CrossesMidnight 是否跨越午夜
+func (p *TimePeriod) CrossesMidnight() bool {
+	return p.Start > p.End
+}
+
+// Contains 检查时间是否处于时间段内（按t所在时区的墙上时间判断）
+func (p *TimePeriod) Contains(t time.Time) bool {
+	weekday := isoWeekday(t)
+	sod := secondOfDay(t)
+
+	if !p.CrossesMidnight() {
+		return p.matchDay(weekday) && sod >= p.Start && sod < p.End
+	}
+
+	// 跨午夜：开始当天的后半段，或前一天开始的时间段在今天的延续部分
+	if p.matchDay(weekday) && sod >= p.Start {
+		return true
+	}
+	return p.matchDay(prevWeekday(weekday)) && sod < p.End
+}
+
+// boundaries 返回[from, to]范围内时间段的所有开始和结束时刻
+func (p *TimePeriod) boundaries(from, to time.Time) []time.Time {
+	var result []time.Time
+	loc := from.Location()
+	day := time.Date(from.Year(), from.Month(), from.Day()-1, 0, 0, 0, 0, loc)
+	for !day.After(to) {
+		if p.matchDay(isoWeekday(day)) {
+			result = append(result, clockOn(day, p.Start))
+			if p.CrossesMidnight() {
+				result = append(result, clockOn(day.AddDate(0, 0, 1), p.End))
+			} else {
+				result = append(result, clockOn(day, p.End))
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return result
+}
+
+// String 返回时间段字符串
+func (p *TimePeriod) String() string {
+	days := strconv.Itoa(p.WeekdayFrom)
+	if p.WeekdayTo != p.WeekdayFrom {
+		days += "-" + strconv.Itoa(p.WeekdayTo)
+	}
+	return fmt.Sprintf("%s,%s-%s", days, formatClock(p.Start), formatClock(p.End))
+}
+
+// matchDay 检查星期是否在范围内
+func (p *TimePeriod) matchDay(weekday int) bool {
+	return weekday >= p.WeekdayFrom && weekday <= p.WeekdayTo
+}
+
+// FlexibleInterval 灵活间隔：在时间段内使用指定的采集间隔，间隔为0表示该时间段内不采集
+type FlexibleInterval struct {
+	Interval time.Duration
+	Period   *TimePeriod
+}
+
+// ParseFlexibleInterval 解析灵活间隔，格式 "50s/1-5,09:00-18:00"
+func ParseFlexibleInterval(s string) (*FlexibleInterval, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("灵活间隔格式错误: %q", s)
+	}
+
+	interval, err := ParseIntervalDuration(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("灵活间隔格式错误 %q: %v", s, err)
+	}
+
+	period, err := ParseTimePeriod(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlexibleInterval{Interval: interval, Period: period}, nil
+}
+
+// ParseIntervalDuration 解析间隔时长，支持纯秒数以及 s/m/h/d/w 后缀
+func ParseIntervalDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("间隔为空")
+	}
+
+	unit := time.Second
+	switch s[len(s)-1] {
+	case 's':
+		s = s[:len(s)-1]
+	case 'm':
+		unit, s = time.Minute, s[:len(s)-1]
+	case 'h':
+		unit, s = time.Hour, s[:len(s)-1]
+	case 'd':
+		unit, s = 24*time.Hour, s[:len(s)-1]
+	case 'w':
+		unit, s = 7*24*time.Hour, s[:len(s)-1]
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("间隔格式错误: %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// schedulingField 调度间隔中的一个时间单位过滤器
+type schedulingField struct {
+	name     string
+	min, max int
+}
+
+var schedulingFields = []schedulingField{
+	{"md", 1, 31},
+	{"wd", 1, 7},
+	{"h", 0, 23},
+	{"m", 0, 59},
+	{"s", 0, 59},
+}
+
+// SchedulingInterval 调度间隔，格式如 "md1-15", "wd1-5h9m30", "m0-59/5", "h9-17/2m0"
+// 未指定的md/wd表示任意一天；比首个指定单位更大的h/m/s表示任意，更小的单位默认为0
+type SchedulingInterval struct {
+	expr     string
+	monthDay []bool
+	weekDay  []bool
+	hours    []int
+	minutes  []int
+	seconds  []int
+}
+
+// ParseSchedulingInterval 解析调度间隔
+func ParseSchedulingInterval(s string) (*SchedulingInterval, error) {
+	expr := strings.TrimSpace(s)
+	if expr == "" {
+		return nil, fmt.Errorf("调度间隔为空")
+	}
+
+	specs := make(map[string]string)
+	lastIndex := -1
+	rest := expr
+	for rest != "" {
+		matched := false
+		for i, field := range schedulingFields {
+			if !strings.HasPrefix(rest, field.name) {
+				continue
+			}
+			if i <= lastIndex || (field.name == "wd" && specs["md"] != "") {
+				return nil, fmt.Errorf("调度间隔过滤器顺序错误或重复: %q", expr)
+			}
+			rest = rest[len(field.name):]
+			end := strings.IndexFunc(rest, func(r rune) bool {
+				return !(r >= '0' && r <= '9') && r != ',' && r != '-' && r != '/'
+			})
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("调度间隔过滤器 %s 缺少取值: %q", field.name, expr)
+			}
+			specs[field.name] = rest[:end]
+			rest = rest[end:]
+			lastIndex = i
+			matched = true
+			break
+		}
+		if !matched {
+			return nil, fmt.Errorf("调度间隔格式错误: %q", expr)
+		}
+	}
+
+	si := &SchedulingInterval{expr: expr}
+	var err error
+	if si.monthDay, err = expandField(specs["md"], schedulingFields[0]); err != nil {
+		return nil, fmt.Errorf("调度间隔 %q: %v", expr, err)
+	}
+	if si.weekDay, err = expandField(specs["wd"], schedulingFields[1]); err != nil {
+		return nil, fmt.Errorf("调度间隔 %q: %v", expr, err)
+	}
+
+	// h/m/s：首个指定单位之前为任意值，之后未指定的单位为0（指定了md/wd时h/m/s均默认为0）
+	_, hasMonthDay := specs["md"]
+	_, hasWeekDay := specs["wd"]
+	specified := hasMonthDay || hasWeekDay
+	targets := []*[]int{&si.hours, &si.minutes, &si.seconds}
+	for i, field := range schedulingFields[2:] {
+		spec, ok := specs[field.name]
+		if !ok && specified {
+			spec = "0"
+		}
+		if ok {
+			specified = true
+		}
+		set, err := expandField(spec, field)
+		if err != nil {
+			return nil, fmt.Errorf("调度间隔 %q: %v", expr, err)
+		}
+		*targets[i] = setValues(set, field)
+	}
+
+	return si, nil
+}
+
+// Next 返回严格晚于after的下一个触发时间
+func (si *SchedulingInterval) Next(after time.Time) time.Time {
+	loc := after.Location()
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc)
+
+	// md最多跨越两个月才能再次命中（如md31），向后搜索一年足够
+	for i := 0; i < 366; i++ {
+		d := day.AddDate(0, 0, i)
+		if !si.monthDay[d.Day()] || !si.weekDay[isoWeekday(d)] {
+			continue
+		}
+		for _, h := range si.hours {
+			for _, m := range si.minutes {
+				for _, s := range si.seconds {
+					candidate := time.Date(d.Year(), d.Month(), d.Day(), h, m, s, 0, loc)
+					// 夏令时跳过的墙上时间会被规范化到其他小时，忽略这些时刻
+					if candidate.Hour() != h {
+						continue
+					}
+					if candidate.After(after) {
+						return candidate
+					}
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// String 返回调度间隔表达式
+func (si *SchedulingInterval) String() string {
+	return si.expr
+}
+
+// CronInterval 基于cron表达式的调度，支持可选的秒字段以及 CRON_TZ= 前缀
+type CronInterval struct {
+	expr     string
+	schedule cron.Schedule
+}
+
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ParseCronInterval 解析cron表达式
+func ParseCronInterval(expr string) (*CronInterval, error) {
+	expr = strings.TrimSpace(expr)
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("cron表达式格式错误 %q: %v", expr, err)
+	}
+	return &CronInterval{expr: expr, schedule: schedule}, nil
+}
+
+// Next 返回严格晚于after的下一个触发时间
+func (ci *CronInterval) Next(after time.Time) time.Time {
+	return ci.schedule.Next(after)
+}
+
+// String 返回cron表达式
+func (ci *CronInterval) String() string {
+	return ci.expr
+}
+
+// expandField 展开过滤器取值，支持 "a", "a-b", "a-b/n", "/n" 及逗号分隔的列表；空表示任意值
+func expandField(spec string, field schedulingField) ([]bool, error) {
+	set := make([]bool, field.max+1)
+	if spec == "" {
+		for v := field.min; v <= field.max; v++ {
+			set[v] = true
+		}
+		return set, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		from, to, step := field.min, field.max, 1
+
+		rangePart := item
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("过滤器 %s 步长错误: %q", field.name, item)
+			}
+			step = n
+			rangePart = item[:idx]
+		}
+
+		if rangePart != "" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if from, err = parseRangeValue(bounds[0], field.min, field.max); err != nil {
+				return nil, fmt.Errorf("过滤器 %s 取值错误: %v", field.name, err)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = parseRangeValue(bounds[1], field.min, field.max); err != nil {
+					return nil, fmt.Errorf("过滤器 %s 取值错误: %v", field.name, err)
+				}
+			} else if step > 1 {
+				to = field.max
+			}
+			if to < from {
+				return nil, fmt.Errorf("过滤器 %s 范围错误: %q", field.name, item)
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// setValues 返回集合中按升序排列的取值
+func setValues(set []bool, field schedulingField) []int {
+	var values []int
+	for v := field.min; v <= field.max; v++ {
+		if set[v] {
+			values = append(values, v)
+		}
+	}
+	sort.Ints(values)
+	return values
+}
+
+// parseRangeValue 解析并校验范围内的整数
+func parseRangeValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("无效数字 %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("取值 %d 超出范围 %d-%d", v, min, max)
+	}
+	return v, nil
+}
+
+// isoWeekday 返回ISO星期（周一为1，周日为7）
+func isoWeekday(t time.Time) int {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		return 7
+	}
+	return weekday
+}
+
+// prevWeekday 返回前一天的ISO星期
+func prevWeekday(weekday int) int {
+	if weekday == 1 {
+		return 7
+	}
+	return weekday - 1
+}
+
+// secondOfDay 返回墙上时间对应的当天秒数
+func secondOfDay(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
+
+// clockOn 返回某天指定墙上时间对应的时刻（24:00为次日零点）
+func clockOn(day time.Time, seconds int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), seconds/3600, (seconds%3600)/60, seconds%60, 0, day.Location())
+}
+
+// formatClock 格式化当天秒数为 "hh:mm[:ss]"
+func formatClock(seconds int) string {
+	if seconds%60 == 0 {
+		return fmt.Sprintf("%02d:%02d", seconds/3600, (seconds%3600)/60)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
+}
@@ -0,0 +1,240 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// 2024-01-01 为周一
+func at(day, hour, min, sec int) time.Time {
+	return time.Date(2024, 1, day, hour, min, sec, 0, time.UTC)
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"09:00", 9 * 3600, false},
+		{"09:30:15", 9*3600 + 30*60 + 15, false},
+		{"00:00", 0, false},
+		{"24:00", secondsPerDay, false},
+		{"24:00:01", 0, true},
+		{"12:60", 0, true},
+		{"9", 0, true},
+		{"a:00", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseClock(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseClock(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseClock(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseIntervalDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30", 30 * time.Second, false},
+		{"50s", 50 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{"2h", 2 * time.Hour, false},
+		{"1d", 24 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"-1", 0, true},
+		{"5x", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIntervalDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIntervalDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseIntervalDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTimePeriodContains(t *testing.T) {
+	tests := []struct {
+		period string
+		t      time.Time
+		want   bool
+	}{
+		// 开始时间包含，结束时间不包含
+		{"1-5,09:00-18:00", at(1, 9, 0, 0), true},
+		{"1-5,09:00-18:00", at(1, 8, 59, 59), false},
+		{"1-5,09:00-18:00", at(1, 17, 59, 59), true},
+		{"1-5,09:00-18:00", at(1, 18, 0, 0), false},
+		{"1-5,09:00-18:00", at(6, 10, 0, 0), false},
+		{"1-7,00:00-24:00", at(7, 23, 59, 59), true},
+
+		// 跨越午夜，跨过午夜的部分属于开始那一天
+		{"5,22:00-02:00", at(5, 22, 0, 0), true},
+		{"5,22:00-02:00", at(5, 21, 59, 59), false},
+		{"5,22:00-02:00", at(6, 1, 59, 59), true},
+		{"5,22:00-02:00", at(6, 2, 0, 0), false},
+		{"5,22:00-02:00", at(5, 1, 0, 0), false},
+		{"7,22:00-02:00", at(8, 1, 0, 0), true},
+		{"1,22:00-02:00", at(8, 1, 0, 0), false},
+	}
+	for _, tt := range tests {
+		period, err := ParseTimePeriod(tt.period)
+		if err != nil {
+			t.Fatalf("ParseTimePeriod(%q): %v", tt.period, err)
+		}
+		if got := period.Contains(tt.t); got != tt.want {
+			t.Errorf("%q.Contains(%v) = %v, want %v", tt.period, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestTimePeriodContainsTimezone(t *testing.T) {
+	period, err := ParseTimePeriod("1,09:00-18:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 周一01:00 UTC 为东八区周一09:00
+	utc := at(1, 1, 0, 0)
+	if period.Contains(utc) {
+		t.Errorf("Contains(%v) = true in UTC, want false", utc)
+	}
+	cst := utc.In(time.FixedZone("CST", 8*3600))
+	if !period.Contains(cst) {
+		t.Errorf("Contains(%v) = false in +08:00, want true", cst)
+	}
+}
+
+func TestParseTimePeriodErrors(t *testing.T) {
+	for _, input := range []string{
+		"09:00-18:00",
+		"0,09:00-18:00",
+		"5-1,09:00-18:00",
+		"1,09:00",
+		"1,09:00-09:00",
+		"1,24:00-02:00",
+	} {
+		if _, err := ParseTimePeriod(input); err == nil {
+			t.Errorf("ParseTimePeriod(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestParseFlexibleInterval(t *testing.T) {
+	flexible, err := ParseFlexibleInterval("50s/1-5,09:00-18:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flexible.Interval != 50*time.Second || flexible.Period.String() != "1-5,09:00-18:00" {
+		t.Errorf("ParseFlexibleInterval = %v %s", flexible.Interval, flexible.Period)
+	}
+
+	blackout, err := ParseFlexibleInterval("0/6-7,00:00-24:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blackout.Interval != 0 {
+		t.Errorf("blackout interval = %v, want 0", blackout.Interval)
+	}
+
+	for _, input := range []string{"50s", "x/1,09:00-18:00", "50s/1,09:00"} {
+		if _, err := ParseFlexibleInterval(input); err == nil {
+			t.Errorf("ParseFlexibleInterval(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestSchedulingIntervalNext(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"wd1-5h9m0", at(1, 8, 0, 0), at(1, 9, 0, 0)},
+		{"wd1-5h9m0", at(1, 9, 0, 0), at(2, 9, 0, 0)},
+		{"wd1-5h9m0", at(5, 9, 0, 0), at(8, 9, 0, 0)},
+		{"wd6-7", at(1, 0, 0, 0), at(6, 0, 0, 0)},
+		{"h9-17/2m0", at(1, 10, 0, 0), at(1, 11, 0, 0)},
+		{"h9-17/2m0", at(1, 17, 0, 0), at(2, 9, 0, 0)},
+		{"m0-59/5", at(1, 10, 2, 30), at(1, 10, 5, 0)},
+		{"m/15", at(1, 10, 45, 0), at(1, 11, 0, 0)},
+		{"s30", at(1, 10, 0, 30), at(1, 10, 1, 30)},
+		{"h9m30s15", at(1, 9, 30, 14), at(1, 9, 30, 15)},
+		{"md1-15", at(15, 0, 0, 0), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"md31", at(31, 0, 0, 0), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"md1,15h12", at(2, 0, 0, 0), at(15, 12, 0, 0)},
+	}
+	for _, tt := range tests {
+		si, err := ParseSchedulingInterval(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedulingInterval(%q): %v", tt.expr, err)
+		}
+		if got := si.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedulingIntervalErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"x5",
+		"h9md1",
+		"md1wd1",
+		"h9h10",
+		"h",
+		"h25",
+		"m/0",
+		"m30-10",
+		"wd0",
+	} {
+		if _, err := ParseSchedulingInterval(expr); err == nil {
+			t.Errorf("ParseSchedulingInterval(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestCronIntervalNext(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		// 带秒字段
+		{"0 */5 * * * *", at(1, 10, 2, 30), at(1, 10, 5, 0)},
+		{"30 0 9 * * *", at(1, 9, 0, 0), at(1, 9, 0, 30)},
+		{"0 0 9 * * 1-5", at(5, 10, 0, 0), at(8, 9, 0, 0)},
+		// 不带秒字段
+		{"*/5 * * * *", at(1, 10, 2, 30), at(1, 10, 5, 0)},
+		{"0 9 * * *", at(1, 9, 0, 0), at(2, 9, 0, 0)},
+		{"@hourly", at(1, 10, 0, 0), at(1, 11, 0, 0)},
+		// 按after所在时区计算
+		{"0 9 * * *", at(1, 0, 0, 0).In(time.FixedZone("CST", 8*3600)), at(1, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		ci, err := ParseCronInterval(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCronInterval(%q): %v", tt.expr, err)
+		}
+		if got := ci.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, tt.after, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "* * *", "61 * * * * *", "0 0 25 * * *"} {
+		if _, err := ParseCronInterval(expr); err == nil {
+			t.Errorf("ParseCronInterval(%q) error = nil, want error", expr)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go-agent/pkg/collector"
+	"go-agent/pkg/logger"
+)
+
+// ItemError 监控项的错误状态：最近一次采集失败的原因，命令执行失败时包含退出码、stderr等执行结果
+type ItemError struct {
+	ItemID    int64     `json:"item_id"`
+	ItemName  string    `json:"item_name"`
+	ItemKey   string    `json:"item_key"`
+	Error     string    `json:"error"`
+	Since     time.Time `json:"since"`     // 本轮连续失败的开始时间
+	LastTime  time.Time `json:"last_time"` // 最近一次失败时间
+	Failures  int       `json:"failures"`  // 连续失败次数
+	ExitCode  *int      `json:"exit_code,omitempty"`
+	Stderr    string    `json:"stderr,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// itemErrors 按监控项ID记录错误状态，采集成功后清除
+type itemErrors struct {
+	mu     sync.Mutex
+	errors map[int64]*ItemError
+}
+
+// newItemErrors 创建监控项错误状态表
+func newItemErrors() *itemErrors {
+	return &itemErrors{errors: make(map[int64]*ItemError)}
+}
+
+// record 记录一次采集失败，错误信息和stderr中已登记的敏感值会被隐藏，避免经状态接口泄露
+func (e *itemErrors) record(itemScheduler *ItemScheduler, err error) {
+	now := time.Now()
+	state := &ItemError{
+		ItemID:   itemScheduler.ItemID,
+		ItemName: itemScheduler.ItemName,
+		ItemKey:  itemScheduler.ItemKey,
+		Error:    logger.Redact(err.Error()),
+		Since:    now,
+		LastTime: now,
+		Failures: 1,
+	}
+	if execErr, ok := collector.AsExecError(err); ok {
+		exitCode := execErr.Result.ExitCode
+		state.ExitCode = &exitCode
+		state.Stderr = logger.Redact(execErr.Result.Stderr)
+		state.Duration = execErr.Result.Duration.Round(time.Millisecond).String()
+		state.Truncated = execErr.Result.Truncated
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if previous, exists := e.errors[itemScheduler.ItemID]; exists {
+		state.Since = previous.Since
+		state.Failures = previous.Failures + 1
+	}
+	e.errors[itemScheduler.ItemID] = state
+}
+
+// clear 采集成功后清除错误状态
+func (e *itemErrors) clear(itemID int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.errors, itemID)
+}
+
+// prune 移除已不在调度中的监控项的错误状态，监控项被删除或重新下发后调用
+func (e *itemErrors) prune(items map[int64]*ItemScheduler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for itemID := range e.errors {
+		if _, exists := items[itemID]; !exists {
+			delete(e.errors, itemID)
+		}
+	}
+}
+
+// list 返回仍在调度的监控项的错误状态，按itemKey排序
+func (e *itemErrors) list(items map[int64]*ItemScheduler) []ItemError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]ItemError, 0, len(e.errors))
+	for itemID, state := range e.errors {
+		if _, exists := items[itemID]; exists {
+			result = append(result, *state)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ItemKey < result[j].ItemKey
+	})
+	return result
+}
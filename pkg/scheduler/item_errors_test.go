@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-agent/pkg/collector"
+	"go-agent/pkg/logger"
+)
+
+func TestItemErrorsRecordAndPrune(t *testing.T) {
+	errs := newItemErrors()
+	a := &ItemScheduler{ItemID: 1, ItemName: "a", ItemKey: "a.key"}
+	b := &ItemScheduler{ItemID: 2, ItemName: "b", ItemKey: "b.key"}
+
+	errs.record(a, errors.New("失败1"))
+	errs.record(a, errors.New("失败2"))
+	errs.record(b, errors.New("失败"))
+
+	items := map[int64]*ItemScheduler{1: a, 2: b}
+	list := errs.list(items)
+	if len(list) != 2 || list[0].ItemKey != "a.key" || list[0].Failures != 2 || list[0].Error != "失败2" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	errs.clear(1)
+	if list := errs.list(items); len(list) != 1 || list[0].ItemID != 2 {
+		t.Errorf("list after clear = %+v", list)
+	}
+
+	// 监控项2被移除后，其错误状态不再保留
+	errs.prune(map[int64]*ItemScheduler{1: a})
+	if len(errs.errors) != 0 {
+		t.Errorf("errors after prune = %v, want empty", errs.errors)
+	}
+}
+
+func TestItemErrorsRecordRedactsSecrets(t *testing.T) {
+	const secret = "item-errors-secret"
+	logger.AddSecret(secret)
+
+	errs := newItemErrors()
+	item := &ItemScheduler{ItemID: 1, ItemName: "db", ItemKey: "db.query"}
+	result := &collector.ExecResult{ExitCode: 1, Stderr: "login failed: password=" + secret}
+	errs.record(item, &collector.ExecError{Label: "db.query", Reason: "连接失败: " + secret, Result: result})
+
+	list := errs.list(map[int64]*ItemScheduler{1: item})
+	if len(list) != 1 {
+		t.Fatalf("list = %+v", list)
+	}
+	if strings.Contains(list[0].Error, secret) || strings.Contains(list[0].Stderr, secret) {
+		t.Errorf("secret not redacted: error=%q stderr=%q", list[0].Error, list[0].Stderr)
+	}
+	if !strings.Contains(list[0].Stderr, "login failed") {
+		t.Errorf("Stderr = %q, want remaining text kept", list[0].Stderr)
+	}
+}
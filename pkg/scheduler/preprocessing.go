@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/collector"
+)
+
+// 预处理步骤类型
+const (
+	PreprocessMultiplier      = "multiplier"        // 乘以系数: [系数]
+	PreprocessTrim            = "trim"              // 去除两端字符: [字符集，默认空白]
+	PreprocessLTrim           = "ltrim"             // 去除左侧字符
+	PreprocessRTrim           = "rtrim"             // 去除右侧字符
+	PreprocessRegex           = "regex"             // 正则提取: [表达式, 输出模板(\0-\9)]
+	PreprocessJSONPath        = "jsonpath"          // JSON路径提取: [$.a.b[0]]
+	PreprocessSimpleChange    = "simple_change"     // 与上次值的差值，首次采集丢弃
+	PreprocessChangePerSecond = "change_per_second" // 每秒变化量，首次采集丢弃
+)
+
+// preprocessStep 已解析的预处理步骤
+type preprocessStep struct {
+	kind    string
+	factor  float64
+	cutset  string
+	pattern *regexp.Regexp
+	output  string
+	path    []string
+}
+
+// previousSample 变化量计算所需的上次采集值
+type previousSample struct {
+	value float64
+	at    time.Time
+}
+
+// Preprocessor 监控项预处理管道，变化量类步骤会保存上次采集值
+type Preprocessor struct {
+	steps    []*preprocessStep
+	previous map[int]*previousSample
+	mu       sync.Mutex
+}
+
+// NewPreprocessor 解析预处理步骤，没有步骤时返回nil
+func NewPreprocessor(steps []*client.PreprocessingStep) (*Preprocessor, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+
+	p := &Preprocessor{previous: make(map[int]*previousSample)}
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		parsed, err := parsePreprocessStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("第%d个预处理步骤无效: %v", i+1, err)
+		}
+		p.steps = append(p.steps, parsed)
+	}
+	return p, nil
+}
+
+// parsePreprocessStep 校验并解析单个预处理步骤
+func parsePreprocessStep(step *client.PreprocessingStep) (*preprocessStep, error) {
+	kind := strings.ToLower(strings.TrimSpace(step.Type))
+	param := func(i int) string {
+		if i < len(step.Params) {
+			return step.Params[i]
+		}
+		return ""
+	}
+
+	parsed := &preprocessStep{kind: kind}
+	switch kind {
+	case PreprocessMultiplier:
+		factor, err := strconv.ParseFloat(strings.TrimSpace(param(0)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("乘数 %q 无效", param(0))
+		}
+		parsed.factor = factor
+	case PreprocessTrim, PreprocessLTrim, PreprocessRTrim:
+		parsed.cutset = param(0)
+		if parsed.cutset == "" {
+			parsed.cutset = " \t\r\n"
+		}
+	case PreprocessRegex:
+		pattern, err := regexp.Compile(param(0))
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式无效: %v", err)
+		}
+		parsed.pattern = pattern
+		parsed.output = param(1)
+		if parsed.output == "" {
+			parsed.output = `\0`
+		}
+	case PreprocessJSONPath:
+		path, err := collector.ParseJSONPath(param(0))
+		if err != nil {
+			return nil, err
+		}
+		parsed.path = path
+	case PreprocessSimpleChange, PreprocessChangePerSecond:
+	default:
+		return nil, fmt.Errorf("不支持的预处理类型: %s", step.Type)
+	}
+	return parsed, nil
+}
+
+// Fresh 返回步骤相同但不含历史值的预处理管道，用于不影响变化量计算的临时采集
+func (p *Preprocessor) Fresh() *Preprocessor {
+	if p == nil {
+		return nil
+	}
+	return &Preprocessor{steps: p.steps, previous: make(map[int]*previousSample)}
+}
+
+// Apply 依次执行预处理步骤；返回false表示本次值被丢弃（如变化量的首次采集）
+func (p *Preprocessor) Apply(value interface{}, at time.Time) (interface{}, bool, error) {
+	if p == nil {
+		return value, true, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, step := range p.steps {
+		var err error
+		switch step.kind {
+		case PreprocessMultiplier:
+			var f float64
+			f, err = preprocessFloat(value)
+			value = f * step.factor
+		case PreprocessTrim:
+			value = strings.Trim(preprocessText(value), step.cutset)
+		case PreprocessLTrim:
+			value = strings.TrimLeft(preprocessText(value), step.cutset)
+		case PreprocessRTrim:
+			value = strings.TrimRight(preprocessText(value), step.cutset)
+		case PreprocessRegex:
+			value, err = step.applyRegex(preprocessText(value))
+		case PreprocessJSONPath:
+			value, err = collector.ExtractJSONPath(preprocessText(value), step.path)
+		case PreprocessSimpleChange, PreprocessChangePerSecond:
+			var f float64
+			if f, err = preprocessFloat(value); err != nil {
+				break
+			}
+			prev := p.previous[i]
+			p.previous[i] = &previousSample{value: f, at: at}
+			if prev == nil {
+				return nil, false, nil
+			}
+			if step.kind == PreprocessSimpleChange {
+				value = f - prev.value
+				break
+			}
+			elapsed := at.Sub(prev.at).Seconds()
+			if elapsed <= 0 {
+				return nil, false, nil
+			}
+			value = (f - prev.value) / elapsed
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("预处理 %s 失败: %v", step.kind, err)
+		}
+	}
+	return value, true, nil
+}
+
+// applyRegex 按输出模板返回第一个匹配，模板中 \0-\9 引用匹配分组
+func (step *preprocessStep) applyRegex(text string) (string, error) {
+	match := step.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", fmt.Errorf("值不匹配正则表达式 %s", step.pattern)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(step.output); i++ {
+		c := step.output[i]
+		if c == '\\' && i+1 < len(step.output) && step.output[i+1] >= '0' && step.output[i+1] <= '9' {
+			if group := int(step.output[i+1] - '0'); group < len(match) {
+				b.WriteString(match[group])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// preprocessFloat 将预处理中的值转换为浮点数
+func preprocessFloat(value interface{}) (float64, error) {
+	tv, err := client.ConvertValue(value, client.InfoTypeFloat)
+	if err != nil {
+		return 0, err
+	}
+	return tv.Float, nil
+}
+
+// preprocessText 将预处理中的值转换为文本
+func preprocessText(value interface{}) string {
+	if tv, ok := value.(*client.TypedValue); ok {
+		return tv.String()
+	}
+	tv, err := client.ConvertValue(value, client.InfoTypeText)
+	if err != nil {
+		return ""
+	}
+	return tv.Text
+}
@@ -0,0 +1,277 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-agent/pkg/config"
+	"go-agent/pkg/logger"
+	"go-agent/pkg/services"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce 文件变化后等待的时间，编辑器保存时通常会连续触发多个事件
+const reloadDebounce = 500 * time.Millisecond
+
+// liveConfigKeys 可以在运行中生效的配置项（前缀匹配），其余配置项修改后需要重启
+var liveConfigKeys = []string{
+	"log.",
+	"agent.interval",
+	"agent.timeout",
+	"agent.timezone",
+	"agent.schedule.spread",
+	"agent.schedule.align",
+	"agent.schedule.startup_jitter",
+	"agent.command_mapping",
+	"agent.watch_config",
+	"items.file",
+	"credentials.",
+	"transport.http.headers",
+}
+
+// SetConfigFile 设置配置文件路径，用于热加载；需在Start之前调用
+func (s *Scheduler) SetConfigFile(configFile string) {
+	s.configFile = configFile
+}
+
+// Reload 重新加载配置文件和命令映射：配置有误时保持当前配置，可在线生效的修改立即应用，其余修改提示需要重启
+func (s *Scheduler) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if s.configFile == "" {
+		return fmt.Errorf("未设置配置文件路径，无法重新加载")
+	}
+
+	newConfig, problems, err := config.Check(s.configFile)
+	if err != nil {
+		return err
+	}
+	if errs := config.Errors(problems); len(errs) > 0 {
+		return &config.ValidationError{Problems: errs}
+	}
+
+	s.mu.RLock()
+	current := s.config
+	s.mu.RUnlock()
+
+	// 只合并可在线生效的配置项，s.config始终反映实际生效的配置
+	applied := *current
+	var restartKeys []string
+	for _, key := range config.Diff(current, newConfig) {
+		if !isLiveConfigKey(key) {
+			restartKeys = append(restartKeys, key)
+		}
+	}
+
+	applied.Log = newConfig.Log
+	applied.Agent.Interval = newConfig.Agent.Interval
+	applied.Agent.Timeout = newConfig.Agent.Timeout
+	applied.Agent.Timezone = newConfig.Agent.Timezone
+	applied.Agent.Schedule.Spread = newConfig.Agent.Schedule.Spread
+	applied.Agent.Schedule.Align = newConfig.Agent.Schedule.Align
+	applied.Agent.Schedule.StartupJitter = newConfig.Agent.Schedule.StartupJitter
+	applied.Agent.CommandMapping = newConfig.Agent.CommandMapping
+	applied.Agent.WatchConfig = newConfig.Agent.WatchConfig
+	applied.Items.File = newConfig.Items.File
+	applied.Credentials = newConfig.Credentials
+	applied.Transport.HTTP.Headers = newConfig.Transport.HTTP.Headers
+
+	s.mu.Lock()
+	s.config = &applied
+	s.mu.Unlock()
+
+	s.applyConfig(current, &applied)
+
+	for _, key := range restartKeys {
+		logger.Warnf("配置项 %s 已修改，需要重启agent才能生效", key)
+	}
+	logger.Info("配置已重新加载")
+	return nil
+}
+
+// applyConfig 应用在线生效的配置修改
+func (s *Scheduler) applyConfig(old, cfg *config.Config) {
+	if old.Log != cfg.Log {
+		if err := logger.ApplyConfig(cfg.Log.Level, cfg.Log.Format, cfg.Log.Output); err != nil {
+			logger.Warnf("应用日志配置失败: %v", err)
+		} else {
+			logger.Infof("日志配置已更新: level=%s, format=%s", cfg.Log.Level, cfg.Log.Format)
+		}
+	}
+
+	if s.httpTransport != nil {
+		s.httpTransport.SetHeaders(cfg.Transport.HTTP.Headers)
+	}
+
+	// 凭据库可能单独修改过（go-agent credential add），每次都重新加载
+	s.loadCredentials(cfg)
+
+	// 命令映射文件同样可能单独修改过
+	if s.commandCollector != nil {
+		if err := s.commandCollector.Reload(cfg.Agent.CommandMapping); err != nil {
+			logger.Errorf("%v，保持原有命令", err)
+		} else {
+			s.logCommandProblems()
+		}
+	}
+
+	if old.Agent.Interval != cfg.Agent.Interval {
+		s.rescheduleJobs()
+	}
+
+	rescheduleItems := old.Agent.Timezone != cfg.Agent.Timezone ||
+		old.Agent.Schedule.Spread != cfg.Agent.Schedule.Spread ||
+		old.Agent.Schedule.Align != cfg.Agent.Schedule.Align ||
+		old.Agent.Schedule.StartupJitter != cfg.Agent.Schedule.StartupJitter
+
+	// 本地监控项文件同样每次重新加载
+	if s.localItemMode() {
+		items, err := services.LoadLocalItems(cfg.Items.File, CheckCustomInterval)
+		if err != nil {
+			logger.Errorf("重新加载本地监控项失败，保持原有监控项: %v", err)
+		} else {
+			s.mu.Lock()
+			s.localItems = items
+			s.mu.Unlock()
+			rescheduleItems = true
+		}
+	}
+
+	if rescheduleItems {
+		s.restartItemSchedulers()
+	}
+
+	if s.watcher != nil {
+		s.updateWatchList(cfg)
+	}
+}
+
+// logCommandProblems 输出命令映射的警告，重新加载时只输出新出现的问题
+func (s *Scheduler) logCommandProblems() {
+	reported := make(map[string]bool)
+	for _, problem := range s.commandCollector.Validate(s.config.Agent.Timeout) {
+		text := problem.String()
+		if !s.commandProblems[text] {
+			logger.Warnf("命令映射配置: %s", text)
+		}
+		reported[text] = true
+	}
+	s.commandProblems = reported
+}
+
+// rescheduleJobs 按新的采集间隔重新添加基础定时任务
+func (s *Scheduler) rescheduleJobs() {
+	for _, entry := range s.cron.Entries() {
+		s.cron.Remove(entry.ID)
+	}
+	if err := s.addScheduledJobs(); err != nil {
+		logger.Errorf("重新添加定时任务失败: %v", err)
+	}
+}
+
+// isLiveConfigKey 检查配置项是否可以在线生效
+func isLiveConfigKey(key string) bool {
+	for _, prefix := range liveConfigKeys {
+		if key == prefix || strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// startConfigWatcher 监听配置文件、命令映射和本地监控项文件的变化，变化后自动重新加载
+func (s *Scheduler) startConfigWatcher() {
+	if s.configFile == "" || !s.config.Agent.WatchConfig {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warnf("创建配置文件监听失败: %v", err)
+		return
+	}
+	s.watcher = watcher
+	s.updateWatchList(s.config)
+
+	s.wg.Add(1)
+	go s.watchLoop()
+}
+
+// watchedFiles 返回需要监听的文件（绝对路径）
+func (s *Scheduler) watchedFiles(cfg *config.Config) map[string]bool {
+	files := []string{s.configFile, cfg.Agent.CommandMapping}
+	if cfg.Items.Source == config.ItemSourceFile {
+		files = append(files, cfg.Items.File)
+	}
+	// 凭据库是可选的，文件存在时才监听
+	if _, err := os.Stat(cfg.Credentials.File); err == nil {
+		files = append(files, cfg.Credentials.File)
+	}
+
+	watched := make(map[string]bool)
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(file); err == nil {
+			watched[abs] = true
+		}
+	}
+	return watched
+}
+
+// updateWatchList 监听文件所在目录，编辑器保存时常以重命名方式替换文件，直接监听文件会丢失后续事件
+func (s *Scheduler) updateWatchList(cfg *config.Config) {
+	for file := range s.watchedFiles(cfg) {
+		dir := filepath.Dir(file)
+		if err := s.watcher.Add(dir); err != nil {
+			logger.Warnf("监听目录 %s 失败: %v", dir, err)
+		}
+	}
+}
+
+// watchLoop 处理文件变化事件，合并短时间内的多次变化后重新加载
+func (s *Scheduler) watchLoop() {
+	defer s.wg.Done()
+	defer s.watcher.Close()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			s.mu.RLock()
+			cfg := s.config
+			s.mu.RUnlock()
+			abs, err := filepath.Abs(event.Name)
+			if err != nil || !s.watchedFiles(cfg)[abs] {
+				continue
+			}
+			logger.Debugf("配置文件已变化: %s", event.Name)
+			debounce = time.After(reloadDebounce)
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warnf("配置文件监听出错: %v", err)
+		case <-debounce:
+			debounce = nil
+			logger.Info("检测到配置文件变化，重新加载配置")
+			if err := s.Reload(); err != nil {
+				logger.Errorf("重新加载配置失败，保持当前配置: %v", err)
+			}
+		}
+	}
+}
@@ -3,16 +3,20 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"net/http"
 	"sync"
 	"time"
 
 	"go-agent/pkg/client"
 	"go-agent/pkg/collector"
 	"go-agent/pkg/config"
+	"go-agent/pkg/credential"
 	"go-agent/pkg/logger"
 	"go-agent/pkg/services"
 	"go-agent/pkg/transport"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/robfig/cron/v3"
 )
 
@@ -28,6 +32,7 @@ type ItemScheduler struct {
 	stopChan              chan struct{}
 	running               bool
 	customTrigger         *CustomTrigger // 自定义触发器
+	preprocessor          *Preprocessor  // 预处理管道
 	lastExecutionTime     *time.Time     // 上次执行时间
 }
 
@@ -41,16 +46,33 @@ type Scheduler struct {
 	commandCollector *collector.CommandCollector // 新增命令执行采集器
 	httpTransport    *transport.HTTPTransport
 	grpcTransport    *transport.GRPCTransport
+	// 数据库、缓存等服务的原生采集器（collect.mysql、collect.redis等）和共用的凭据库
+	serviceCollectors []collector.ServiceCollector
+	credentials       *credential.Vault
 	// 新增API相关服务
 	apiClient        *client.DeviceMonitorClient
 	registerService  *services.RegisterService
 	heartbeatService *services.HeartbeatService
 	configManager    *services.ConfigManager
 	metricsSender    *services.MetricsSender
+	taskService      *services.TaskService
 	// 内置键管理器
 	builtinKeyManager *collector.BuiltinKeyManager
-	// 监控项调度器
+	// 监控项调度分发器（优先队列 + 有限工作协程）
+	dispatcher *Dispatcher
+	// 本地文件定义的监控项（items.source为file时使用）
+	localItems []services.CollectItem
+	// 本地状态接口
+	statusServer *http.Server
+	startedAt    time.Time
+	// 配置热加载
+	configFile      string
+	watcher         *fsnotify.Watcher
+	reloadMu        sync.Mutex
+	commandProblems map[string]bool // 已输出过的命令映射警告
+	// 监控项调度器和错误状态
 	itemSchedulers map[int64]*ItemScheduler
+	itemErrors     *itemErrors
 	ctx            context.Context
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
@@ -63,6 +85,7 @@ func New() *Scheduler {
 	return &Scheduler{
 		cron:           cron.New(cron.WithSeconds()),
 		itemSchedulers: make(map[int64]*ItemScheduler),
+		itemErrors:     newItemErrors(),
 	}
 }
 
@@ -96,6 +119,19 @@ func (s *Scheduler) Start(parentCtx context.Context, cfg *config.Config) error {
 		// 这里只是警告，不阻止启动
 	}
 
+	// 本地监控项模式：从文件加载监控项，不依赖数据中心
+	if s.localItemMode() {
+		items, err := services.LoadLocalItems(s.config.Items.File, CheckCustomInterval)
+		if err != nil {
+			return fmt.Errorf("加载本地监控项失败: %v", err)
+		}
+		s.localItems = items
+		logger.Infof("已从 %s 加载 %d 个本地监控项", s.config.Items.File, len(items))
+	}
+
+	// 初始化监控项调度分发器
+	s.initDispatcher()
+
 	// 首先添加基础的定时任务（系统指标采集等），这些不依赖API
 	if err := s.addScheduledJobs(); err != nil {
 		return fmt.Errorf("添加定时任务失败: %v", err)
@@ -105,7 +141,18 @@ func (s *Scheduler) Start(parentCtx context.Context, cfg *config.Config) error {
 	if err := s.initAPIServices(); err != nil {
 		logger.Warnf("初始化API服务失败: %v，将仅使用本地采集功能", err)
 		// 不返回错误，让基础功能继续运行
+		if s.localItemMode() {
+			s.initCommandCollector()
+			if err := s.startItemSchedulers(); err != nil {
+				logger.Errorf("启动监控项调度器失败: %v", err)
+			}
+		}
 	} else {
+		// 本地监控项模式下未启用数据中心时，命令执行采集器在此初始化
+		if s.commandCollector == nil && s.localItemMode() {
+			s.initCommandCollector()
+		}
+
 		// 并行启动API服务，避免阻塞主流程
 		go func() {
 			defer func() {
@@ -130,6 +177,13 @@ func (s *Scheduler) Start(parentCtx context.Context, cfg *config.Config) error {
 	// 启动cron调度器
 	s.cron.Start()
 	s.running = true
+	s.startedAt = time.Now()
+
+	// 启动本地状态接口
+	s.startStatusServer()
+
+	// 监听配置文件变化
+	s.startConfigWatcher()
 
 	// 增加一个长期运行的任务到WaitGroup，确保Wait()会阻塞
 	s.wg.Add(1)
@@ -147,16 +201,21 @@ func (s *Scheduler) Start(parentCtx context.Context, cfg *config.Config) error {
 
 // Stop 停止调度器
 func (s *Scheduler) Stop() error {
+	// 停止API服务和监控项调度器时会等待仍在执行的任务，这些任务可能需要获取s.mu，这里不能持有锁
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return nil
 	}
+	s.running = false
+	s.mu.Unlock()
 
 	// 停止cron调度器
 	s.cron.Stop()
 
+	// 停止本地状态接口
+	s.stopStatusServer()
+
 	// 停止API服务
 	s.stopAPIServices()
 
@@ -169,7 +228,12 @@ func (s *Scheduler) Stop() error {
 	// 等待所有任务完成
 	s.wg.Wait()
 
-	s.running = false
+	// 关闭数据库连接池
+	if s.commandCollector != nil {
+		s.commandCollector.Close()
+	}
+	s.closeServiceCollectors()
+
 	logger.Info("调度器已停止")
 
 	return nil
@@ -196,12 +260,47 @@ func (s *Scheduler) initCollectors() error {
 	)
 
 	// 初始化脚本采集器
-	s.scriptCollector = collector.NewScriptCollector(
-		s.config.Collect.Script.Enabled,
-		s.config.Collect.Script.Scripts,
-		s.config.Collect.Script.Timeout,
-	)
+	s.scriptCollector = collector.NewScriptCollector(s.config.Collect.Script)
+
+	// 初始化原生服务采集器
+	s.serviceCollectors = nil
+	if s.config.Collect.MySQL.Enabled {
+		s.serviceCollectors = append(s.serviceCollectors, collector.NewMySQLCollector(s.config.Collect.MySQL))
+		logger.Infof("MySQL采集器初始化完成，连接配置数: %d", len(s.config.Collect.MySQL.Profiles))
+	}
+	if s.config.Collect.Postgres.Enabled {
+		s.serviceCollectors = append(s.serviceCollectors, collector.NewPostgresCollector(s.config.Collect.Postgres))
+		logger.Infof("PostgreSQL采集器初始化完成，连接配置数: %d", len(s.config.Collect.Postgres.Profiles))
+	}
+	if s.config.Collect.Redis.Enabled {
+		s.serviceCollectors = append(s.serviceCollectors, collector.NewRedisCollector(s.config.Collect.Redis))
+		logger.Infof("Redis采集器初始化完成，连接配置数: %d", len(s.config.Collect.Redis.Profiles))
+	}
+	if s.config.Collect.Memcached.Enabled {
+		s.serviceCollectors = append(s.serviceCollectors, collector.NewMemcachedCollector(s.config.Collect.Memcached))
+		logger.Infof("Memcached采集器初始化完成，连接配置数: %d", len(s.config.Collect.Memcached.Profiles))
+	}
+
+	// 凭据库供命令映射和原生采集器的连接配置共用
+	s.loadCredentials(s.config)
+
+	return nil
+}
+
+// closeServiceCollectors 关闭原生服务采集器的连接
+func (s *Scheduler) closeServiceCollectors() {
+	for _, serviceCollector := range s.serviceCollectors {
+		serviceCollector.Close()
+	}
+}
 
+// serviceCollectorFor 返回支持itemKey的原生服务采集器
+func (s *Scheduler) serviceCollectorFor(itemKey string) collector.ServiceCollector {
+	for _, serviceCollector := range s.serviceCollectors {
+		if serviceCollector.HasKey(itemKey) {
+			return serviceCollector
+		}
+	}
 	return nil
 }
 
@@ -356,7 +455,7 @@ func (s *Scheduler) sendSystemMetricsToDataCenter(ctx context.Context, metrics *
 		value := metric.getValue()
 		logger.Debugf("准备上报监控项: %s = %v", metric.itemKey, value)
 
-		resp, err := s.apiClient.SendSingleMetric(ctx, metric.itemID, value)
+		resp, err := s.apiClient.SendMetricWithTimestamp(ctx, metric.itemID, value, metrics.Timestamp)
 		if err != nil {
 			logger.Errorf("上报监控项失败 %s: %v", metric.itemKey, err)
 			continue
@@ -482,10 +581,14 @@ func (s *Scheduler) GetStatus() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"running":   s.running,
-		"job_count": jobCount,
-		"next_run":  s.getNextRunTime(entries),
+	status := map[string]interface{}{
+		"agent":      s.config.Agent.Name,
+		"started_at": s.startedAt,
+		"uptime":     time.Since(s.startedAt).Round(time.Second).String(),
+		"item_count": len(s.itemSchedulers),
+		"running":    s.running,
+		"job_count":  jobCount,
+		"next_run":   s.getNextRunTime(entries),
 		"collectors": map[string]bool{
 			"system": s.systemCollector != nil && s.systemCollector.IsEnabled(),
 			"snmp":   s.snmpCollector != nil && s.snmpCollector.IsEnabled(),
@@ -496,6 +599,24 @@ func (s *Scheduler) GetStatus() map[string]interface{} {
 			"grpc": s.grpcTransport != nil && s.grpcTransport.IsEnabled(),
 		},
 	}
+	if s.dispatcher != nil {
+		status["dispatcher"] = s.dispatcher.Stats()
+	}
+	if s.configManager != nil {
+		status["config"] = map[string]interface{}{
+			"version":     s.configManager.GetVersion(),
+			"offline":     s.configManager.IsOffline(),
+			"last_update": s.configManager.GetLastUpdateTime(),
+		}
+	}
+	if s.heartbeatService != nil {
+		status["heartbeat_status"] = s.heartbeatService.GetStatus()
+	}
+	if s.commandCollector != nil {
+		status["command_count"] = s.commandCollector.GetCommandCount()
+	}
+	status["item_errors"] = s.itemErrors.list(s.itemSchedulers)
+	return status
 }
 
 // getNextRunTime 获取下次运行时间
@@ -511,12 +632,16 @@ func (s *Scheduler) getNextRunTime(entries []cron.Entry) *time.Time {
 
 // startItemSchedulers 启动监控项调度器
 func (s *Scheduler) startItemSchedulers() error {
-	if s.configManager == nil {
+	var items []services.CollectItem
+	switch {
+	case s.localItemMode():
+		items = s.localItems
+	case s.configManager != nil:
+		items = s.configManager.GetItems()
+	default:
 		logger.Warn("配置管理器为空，跳过启动监控项调度器")
 		return nil
 	}
-
-	items := s.configManager.GetItems()
 	logger.Infof("获取到 %d 个监控项配置", len(items))
 
 	// 更新命令执行采集器的监控项映射
@@ -543,8 +668,16 @@ func (s *Scheduler) startItemSchedulers() error {
 		logger.Infof("处理监控项: ID=%d, Name=%s, Key=%s, Interval=%d, CustomIntervals=%d",
 			item.ItemID, item.ItemName, item.ItemKey, item.UpdateIntervalSeconds, len(item.Intervals))
 
-		// 创建自定义触发器
+		// 创建自定义触发器，并按监控项或agent时区计算时间窗口
 		customTrigger := NewCustomTrigger(&item, logger.GetLogger())
+		customTrigger.SetLocation(s.itemLocation(&item))
+		customTrigger.SetSpread(s.itemSpreadOffset(&item), s.config.Agent.Schedule.Align)
+
+		preprocessor, err := NewPreprocessor(item.Preprocessing)
+		if err != nil {
+			logger.Errorf("监控项 %s 的预处理配置无效，跳过启动: %v", item.ItemName, err)
+			continue
+		}
 
 		// 如果有间隔配置（默认间隔或自定义间隔），则启动调度器
 		if item.UpdateIntervalSeconds > 0 || len(item.Intervals) > 0 {
@@ -558,6 +691,7 @@ func (s *Scheduler) startItemSchedulers() error {
 				stopChan:              make(chan struct{}),
 				running:               false,
 				customTrigger:         customTrigger,
+				preprocessor:          preprocessor,
 				lastExecutionTime:     nil,
 			}
 
@@ -578,6 +712,45 @@ func (s *Scheduler) startItemSchedulers() error {
 	return nil
 }
 
+// localItemMode 是否从本地文件加载监控项
+func (s *Scheduler) localItemMode() bool {
+	return s.config.Items.Source == config.ItemSourceFile
+}
+
+// itemLocation 返回监控项自定义间隔所用时区：监控项时区优先，其次为agent配置时区
+func (s *Scheduler) itemLocation(item *services.CollectItem) *time.Location {
+	if item.Timezone != "" {
+		loc, err := time.LoadLocation(item.Timezone)
+		if err == nil {
+			return loc
+		}
+		logger.Warnf("监控项 %s 的时区 %s 无效，使用agent时区: %v", item.ItemName, item.Timezone, err)
+	}
+	return s.config.Agent.Location()
+}
+
+// itemSpreadOffset 返回监控项在采集间隔内的确定性偏移，未启用分散时为0
+func (s *Scheduler) itemSpreadOffset(item *services.CollectItem) time.Duration {
+	if !s.config.Agent.Schedule.Spread || item.UpdateIntervalSeconds <= 0 {
+		return 0
+	}
+
+	agentID := s.config.Agent.Name
+	if s.apiClient != nil && s.apiClient.GetAgentID() != "" {
+		agentID = s.apiClient.GetAgentID()
+	}
+	return SpreadOffset(agentID, item.ItemID, time.Duration(item.UpdateIntervalSeconds)*time.Second)
+}
+
+// startupJitter 返回首次执行前的随机延迟
+func (s *Scheduler) startupJitter() time.Duration {
+	maxJitter := s.config.Agent.Schedule.StartupJitter
+	if maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter)))
+}
+
 // startItemScheduler 启动单个监控项调度器（原方法，向后兼容）
 func (s *Scheduler) startItemScheduler(itemScheduler *ItemScheduler) {
 	s.wg.Add(1)
@@ -608,141 +781,210 @@ func (s *Scheduler) startItemScheduler(itemScheduler *ItemScheduler) {
 	}()
 }
 
-// startItemSchedulerWithCustomTrigger 启动带自定义触发器的监控项调度器
+// startItemSchedulerWithCustomTrigger 将带自定义触发器的监控项加入集中调度
 func (s *Scheduler) startItemSchedulerWithCustomTrigger(itemScheduler *ItemScheduler) {
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-
-		itemScheduler.running = true
+	// 计算首次执行时间（含间隔内偏移）
+	nextTime := itemScheduler.customTrigger.FirstExecutionTimeAt(time.Now())
 
-		// 计算初始间隔
-		nextTime := itemScheduler.customTrigger.NextExecutionTime(nil)
+	// 如果没有有效的下次执行时间，不加入调度
+	if nextTime.IsZero() {
+		logger.Warnf("监控项 %s 没有有效的执行间隔，跳过调度", itemScheduler.ItemName)
+		return
+	}
 
-		// 如果没有有效的下次执行时间，退出调度器
-		if nextTime.IsZero() {
-			logger.Warnf("监控项 %s 没有有效的执行间隔，调度器退出", itemScheduler.ItemName)
-			itemScheduler.running = false
-			return
-		}
+	if jitter := s.startupJitter(); jitter > 0 {
+		nextTime = nextTime.Add(jitter)
+	}
 
-		initialDuration := time.Until(nextTime)
-		if initialDuration < 0 {
-			initialDuration = 0 // 立即执行
-		}
+	itemScheduler.running = true
+	s.dispatcher.Schedule(itemScheduler, nextTime)
 
-		logger.Infof("启动监控项自定义调度器: %s (ID: %d, 初始间隔: %v, 首次执行时间: %v)",
-			itemScheduler.ItemName, itemScheduler.ItemID, initialDuration, nextTime)
+	logger.Infof("启动监控项自定义调度器: %s (ID: %d, 初始间隔: %v, 首次执行时间: %v, 时区: %s)",
+		itemScheduler.ItemName, itemScheduler.ItemID, time.Until(nextTime).Round(time.Millisecond), nextTime,
+		itemScheduler.customTrigger.Location())
+}
 
-		// 初始定时器
-		timer := time.NewTimer(initialDuration)
-		defer timer.Stop()
+// initDispatcher 初始化监控项调度分发器
+func (s *Scheduler) initDispatcher() {
+	schedule := s.config.Agent.Schedule
+	s.dispatcher = NewDispatcher(DispatcherConfig{
+		Workers:         schedule.Workers,
+		QueueSize:       schedule.QueueSize,
+		OverlapPolicy:   schedule.OverlapPolicy,
+		CollectorLimits: schedule.CollectorLimits,
+	}, func(ctx context.Context, itemScheduler *ItemScheduler) {
+		logger.Infof("执行监控项采集: %s (时间: %v)", itemScheduler.ItemName, time.Now())
+		s.collectAndSendItem(itemScheduler)
+	}, func(itemScheduler *ItemScheduler) string {
+		return s.collectorKind(itemScheduler.ItemKey)
+	})
+	s.dispatcher.Start(s.ctx, &s.wg)
+}
 
-		for {
-			select {
-			case <-s.ctx.Done():
-				itemScheduler.running = false
-				logger.Infof("监控项自定义调度器停止: %s", itemScheduler.ItemName)
-				return
-			case <-itemScheduler.stopChan:
-				itemScheduler.running = false
-				logger.Infof("监控项自定义调度器停止: %s", itemScheduler.ItemName)
-				return
-			case <-timer.C:
-				// 检查是否应该执行
-				if itemScheduler.customTrigger.ShouldExecuteNow() {
-					now := time.Now()
-					itemScheduler.lastExecutionTime = &now
-
-					logger.Infof("执行监控项采集: %s (时间: %v)", itemScheduler.ItemName, now)
-					s.collectAndSendItem(itemScheduler)
-
-					// 计算下次执行时间
-					nextTime := itemScheduler.customTrigger.NextExecutionTime(itemScheduler.lastExecutionTime)
-					if nextTime.IsZero() {
-						logger.Infof("监控项 %s 没有下次执行时间，调度器退出", itemScheduler.ItemName)
-						itemScheduler.running = false
-						return
-					}
-
-					nextInterval := time.Until(nextTime)
-					if nextInterval < 0 {
-						nextInterval = time.Second // 最小1秒间隔
-					}
-
-					logger.Debugf("监控项 %s 下次执行间隔: %v, 下次执行时间: %v",
-						itemScheduler.ItemName, nextInterval, nextTime)
-
-					// 重置定时器
-					timer.Reset(nextInterval)
-				} else {
-					// 如果不应该执行，等待一小段时间后重新检查
-					logger.Debugf("监控项 %s 当前不在执行时间范围内，等待1分钟后重新检查", itemScheduler.ItemName)
-					timer.Reset(1 * time.Minute)
-				}
-			}
+// collectorKind 返回处理itemKey的采集器类别，与collectItemValue的优先级一致
+func (s *Scheduler) collectorKind(itemKey string) string {
+	if s.commandCollector != nil && s.commandCollector.GetEnabledStatus() && s.commandCollector.HasCommand(itemKey) {
+		return CollectorKindCommand
+	}
+	if s.builtinKeyManager != nil {
+		if _, exists := s.builtinKeyManager.GetKey(itemKey); exists {
+			return CollectorKindBuiltin
 		}
-	}()
+	}
+	if serviceCollector := s.serviceCollectorFor(itemKey); serviceCollector != nil {
+		return serviceCollector.Name()
+	}
+	return CollectorKindSystem
 }
 
 // collectAndSendItem 采集并发送监控项数据
 func (s *Scheduler) collectAndSendItem(itemScheduler *ItemScheduler) {
-	ctx, cancel := context.WithTimeout(s.ctx, time.Duration(itemScheduler.Timeout)*time.Second)
+	timeout := time.Duration(itemScheduler.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = s.config.Agent.Timeout
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
 	defer cancel()
 
 	logger.Infof("开始采集监控项: %s (ID: %d)", itemScheduler.ItemName, itemScheduler.ItemID)
 
-	// 根据ItemKey采集数据
-	logger.Infof("正在采集数据: %s (Key: %s)", itemScheduler.ItemName, itemScheduler.ItemKey)
-	value, err := s.collectItemValue(ctx, itemScheduler.ItemKey)
+	value, collectedAt, keep, err := s.evaluateItem(ctx, itemScheduler, itemScheduler.preprocessor)
 	if err != nil {
 		logger.Errorf("采集监控项失败: %s, 错误: %v", itemScheduler.ItemName, err)
+		s.itemErrors.record(itemScheduler, err)
+		return
+	}
+	s.itemErrors.clear(itemScheduler.ItemID)
+	if !keep {
+		logger.Debugf("监控项 %s 本次值在预处理中被丢弃", itemScheduler.ItemName)
 		return
 	}
 
-	logger.Infof("采集到数据: %s = %v", itemScheduler.ItemName, value)
+	s.sendItemValue(ctx, itemScheduler, value, collectedAt)
+}
+
+// evaluateItem 采集监控项原始值，执行预处理并按声明的值类型转换；返回false表示本次值在预处理中被丢弃
+func (s *Scheduler) evaluateItem(ctx context.Context, itemScheduler *ItemScheduler, preprocessor *Preprocessor) (*client.TypedValue, time.Time, bool, error) {
+	// 根据ItemKey采集数据
+	logger.Infof("正在采集数据: %s (Key: %s)", itemScheduler.ItemName, itemScheduler.ItemKey)
+	rawValue, collectedAt, err := s.collectItemValue(ctx, itemScheduler.ItemKey)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	logger.Infof("采集到数据: %s = %v", itemScheduler.ItemName, rawValue)
+
+	// 执行预处理
+	rawValue, keep, err := preprocessor.Apply(rawValue, collectedAt)
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("预处理失败: %v", err)
+	}
+	if !keep {
+		return nil, collectedAt, false, nil
+	}
+
+	// 按监控项声明的值类型转换并校验
+	value, err := client.ConvertValue(rawValue, client.InfoType(itemScheduler.InfoType))
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("值类型转换失败 (类型: %s): %v", client.InfoType(itemScheduler.InfoType), err)
+	}
+	return value, collectedAt, true, nil
+}
 
-	// 发送数据
+// sendItemValue 发送监控项数据：优先使用数据中心，本地监控项模式下使用传输器
+func (s *Scheduler) sendItemValue(ctx context.Context, itemScheduler *ItemScheduler, value *client.TypedValue, collectedAt time.Time) {
+	var err error
 	if s.metricsSender != nil {
 		logger.Infof("正在发送数据: %s (ID: %d) = %v", itemScheduler.ItemName, itemScheduler.ItemID, value)
-		err = s.metricsSender.SendMetricImmediate(ctx, itemScheduler.ItemID, value)
+		err = s.metricsSender.SendMetricImmediateAt(ctx, itemScheduler.ItemID, value, collectedAt)
 		if err != nil {
 			logger.Errorf("发送监控项数据失败: %s, 错误: %v", itemScheduler.ItemName, err)
 		} else {
 			logger.Infof("✅ 发送监控项数据成功: %s (ID: %d) = %v", itemScheduler.ItemName, itemScheduler.ItemID, value)
 		}
+	} else if s.localItemMode() {
+		s.sendItemByTransport(ctx, itemScheduler, value, collectedAt)
 	} else {
 		logger.Warn("指标发送器为空，无法发送数据")
 	}
 }
 
-// collectItemValue 根据ItemKey采集指标值
-func (s *Scheduler) collectItemValue(ctx context.Context, itemKey string) (interface{}, error) {
-	// 按优先级顺序处理：命令映射 > 内置键 > 硬编码（向后兼容）
+// sendItemByTransport 没有数据中心时通过HTTP/gRPC传输器发送监控项数据
+func (s *Scheduler) sendItemByTransport(ctx context.Context, itemScheduler *ItemScheduler, value *client.TypedValue, collectedAt time.Time) {
+	data := map[string]interface{}{
+		"itemId":    itemScheduler.ItemID,
+		"itemName":  itemScheduler.ItemName,
+		"itemKey":   itemScheduler.ItemKey,
+		"infoType":  value.Type,
+		"value":     value,
+		"timestamp": collectedAt.UnixMilli(),
+	}
+
+	sent := false
+	if s.config.Transport.HTTP.Enabled {
+		if err := s.httpTransport.Send(ctx, data, "item", nil); err != nil {
+			logger.Errorf("发送监控项数据到HTTP失败: %s, 错误: %v", itemScheduler.ItemName, err)
+		} else {
+			sent = true
+		}
+	}
+	if s.config.Transport.GRPC.Enabled && s.grpcTransport.IsConnected() {
+		if err := s.grpcTransport.Send(ctx, data, "item", nil); err != nil {
+			logger.Errorf("发送监控项数据到gRPC失败: %s, 错误: %v", itemScheduler.ItemName, err)
+		} else {
+			sent = true
+		}
+	}
+
+	if sent {
+		logger.Infof("✅ 发送监控项数据成功: %s (ID: %d) = %v", itemScheduler.ItemName, itemScheduler.ItemID, value)
+	} else if !s.config.Transport.HTTP.Enabled && !s.config.Transport.GRPC.Enabled {
+		logger.Warnf("未启用任何传输器，监控项数据仅记录在日志中: %s = %v", itemScheduler.ItemName, value)
+	}
+}
+
+// collectItemValue 根据ItemKey采集指标值，同时返回采集时间
+func (s *Scheduler) collectItemValue(ctx context.Context, itemKey string) (interface{}, time.Time, error) {
+	value, collectedAt, _, err := s.collectItemValueFrom(ctx, itemKey)
+	return value, collectedAt, err
+}
+
+// collectItemValueFrom 采集监控项原始值，同时返回处理该key的采集器类别
+func (s *Scheduler) collectItemValueFrom(ctx context.Context, itemKey string) (interface{}, time.Time, string, error) {
+	// 按优先级顺序处理：命令映射 > 内置键 > 原生服务采集器 > 硬编码（向后兼容）
 
 	// 1. 首先检查命令执行采集器（最高优先级 - 用户自定义）
 	if s.commandCollector != nil && s.commandCollector.GetEnabledStatus() {
 		if s.commandCollector.HasCommand(itemKey) {
 			logger.Debugf("🎯 使用命令执行采集器处理: %s", itemKey)
-			commands := s.commandCollector.ListCommands()
-			if description, exists := commands[itemKey]; exists {
-				logger.Debugf("找到命令配置: %s - %s", itemKey, description)
-				// 返回一个占位值，实际值将由命令执行采集器单独发送
-				return fmt.Sprintf("由命令执行采集器处理: %s", itemKey), nil
+			value, collectedAt, err := s.commandCollector.ExecuteCommand(ctx, itemKey)
+			if err != nil {
+				return nil, time.Time{}, "", fmt.Errorf("命令执行失败: %w", err)
 			}
+			return value, collectedAt, CollectorKindCommand, nil
 		}
 	}
 
 	// 2. 然后检查内置键管理器（中等优先级 - 标准化处理）
 	if s.builtinKeyManager != nil {
-		if _, exists := s.builtinKeyManager.GetKey(itemKey); exists {
+		if key, exists := s.builtinKeyManager.GetKey(itemKey); exists {
 			logger.Debugf("🔧 使用内置键管理器处理: %s", itemKey)
 
+			// 带参数的键按参数直接采集
+			if key.ParamExtractor != nil && s.systemCollector != nil && s.systemCollector.IsEnabled() {
+				collectedAt := time.Now()
+				value, err := s.builtinKeyManager.ExtractParamValue(itemKey)
+				if err != nil {
+					return nil, time.Time{}, "", fmt.Errorf("采集内置键 %s 失败: %v", itemKey, err)
+				}
+				return value, collectedAt, CollectorKindBuiltin, nil
+			}
+
 			// 获取系统指标
-			if s.systemCollector != nil && s.systemCollector.IsEnabled() {
+			if key.ParamExtractor == nil && s.systemCollector != nil && s.systemCollector.IsEnabled() {
 				metrics, err := s.systemCollector.Collect(ctx)
 				if err != nil {
-					return nil, fmt.Errorf("采集系统指标失败: %v", err)
+					return nil, time.Time{}, "", fmt.Errorf("采集系统指标失败: %v", err)
 				}
 
 				// 使用内置键管理器提取值
@@ -751,43 +993,53 @@ func (s *Scheduler) collectItemValue(ctx context.Context, itemKey string) (inter
 					logger.Warnf("内置键管理器提取 %s 失败: %v，尝试其他方式", itemKey, err)
 				} else {
 					logger.Debugf("内置键管理器成功提取 %s = %v", itemKey, value)
-					return value, nil
+					return value, metrics.Timestamp, CollectorKindBuiltin, nil
 				}
 			}
 		}
 	}
 
-	// 3. 最后使用硬编码系统采集器（最低优先级 - 向后兼容）
+	// 3. 数据库等服务的原生采集器（mysql.* 等）
+	if serviceCollector := s.serviceCollectorFor(itemKey); serviceCollector != nil {
+		logger.Debugf("🗄️ 使用%s采集器处理: %s", serviceCollector.Name(), itemKey)
+		value, err := serviceCollector.Collect(ctx, itemKey)
+		if err != nil {
+			return nil, time.Time{}, "", fmt.Errorf("%s采集失败: %v", serviceCollector.Name(), err)
+		}
+		return value, time.Now(), serviceCollector.Name(), nil
+	}
+
+	// 4. 最后使用硬编码系统采集器（最低优先级 - 向后兼容）
 	if s.systemCollector != nil && s.systemCollector.IsEnabled() {
 		logger.Debugf("⚙️ 使用硬编码系统采集器（向后兼容）: %s", itemKey)
 		metrics, err := s.systemCollector.Collect(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("采集系统指标失败: %v", err)
+			return nil, time.Time{}, "", fmt.Errorf("采集系统指标失败: %v", err)
 		}
 
 		// 硬编码的常用监控项（向后兼容）
 		switch itemKey {
 		case "system.cpu.util":
 			logger.Debugf("硬编码处理 CPU 使用率")
-			return metrics.CPU.UsagePercent, nil
+			return metrics.CPU.UsagePercent, metrics.Timestamp, CollectorKindSystem, nil
 		case "system.cpu.num":
 			logger.Debugf("硬编码处理 CPU 核心数")
-			return metrics.CPU.Count, nil
+			return metrics.CPU.Count, metrics.Timestamp, CollectorKindSystem, nil
 		case "vm.memory.size[total]":
 			logger.Debugf("硬编码处理内存总量")
-			return metrics.Memory.Total, nil
+			return metrics.Memory.Total, metrics.Timestamp, CollectorKindSystem, nil
 		case "vm.memory.util":
 			logger.Debugf("硬编码处理内存使用率")
-			return metrics.Memory.UsagePercent, nil
+			return metrics.Memory.UsagePercent, metrics.Timestamp, CollectorKindSystem, nil
 		case "system.hostname":
 			logger.Debugf("硬编码处理主机名")
-			return metrics.Host.Hostname, nil
+			return metrics.Host.Hostname, metrics.Timestamp, CollectorKindSystem, nil
 		default:
-			return nil, fmt.Errorf("不支持的监控项: %s", itemKey)
+			return nil, time.Time{}, "", fmt.Errorf("不支持的监控项: %s", itemKey)
 		}
 	}
 
-	return nil, fmt.Errorf("所有采集器都未启用或未找到")
+	return nil, time.Time{}, "", fmt.Errorf("所有采集器都未启用或未找到")
 }
 
 // stopItemSchedulers 停止所有监控项调度器
@@ -801,9 +1053,15 @@ func (s *Scheduler) stopItemSchedulers() {
 			if scheduler.ticker != nil {
 				scheduler.ticker.Stop()
 			}
+			scheduler.running = false
 		}
 	}
 
+	// 移除集中调度中的排期，正在执行的采集会执行完毕
+	if s.dispatcher != nil {
+		s.dispatcher.Clear()
+	}
+
 	logger.Info("所有监控项调度器已停止")
 }
 
@@ -813,6 +1071,11 @@ func (s *Scheduler) onConfigUpdate(items []services.CollectItem) {
 		"item_count": len(items),
 	})
 
+	s.restartItemSchedulers()
+}
+
+// restartItemSchedulers 停止并按当前配置重新启动全部监控项调度器
+func (s *Scheduler) restartItemSchedulers() {
 	// 停止现有的监控项调度器
 	s.stopItemSchedulers()
 
@@ -825,6 +1088,11 @@ func (s *Scheduler) onConfigUpdate(items []services.CollectItem) {
 	if err := s.startItemSchedulers(); err != nil {
 		logger.Errorf("重新启动监控项调度器失败: %v", err)
 	}
+
+	// 已移除的监控项不再保留错误状态
+	s.mu.RLock()
+	s.itemErrors.prune(s.itemSchedulers)
+	s.mu.RUnlock()
 }
 
 // initAPIServices 初始化API服务
@@ -861,11 +1129,17 @@ func (s *Scheduler) initAPIServices() error {
 	configManagerConfig := &services.ConfigManagerConfig{
 		RefreshInterval: s.config.DeviceMonitor.ConfigRefreshInterval,
 		Enabled:         s.config.DeviceMonitor.Enabled,
+		CachePath:       s.config.DeviceMonitor.ConfigCachePath,
+		Watch:           s.config.DeviceMonitor.ConfigWatch,
+		WatchTimeout:    s.config.DeviceMonitor.ConfigWatchTimeout,
 	}
-	s.configManager = services.NewConfigManager(s.apiClient, logger.GetLogger(), configManagerConfig)
+	if !s.localItemMode() {
+		s.configManager = services.NewConfigManager(s.apiClient, logger.GetLogger(), configManagerConfig)
 
-	// 设置配置更新回调
-	s.configManager.SetConfigUpdateCallback(s.onConfigUpdate)
+		// 设置配置更新回调
+		s.configManager.SetConfigUpdateCallback(s.onConfigUpdate)
+		s.configManager.SetRegisterService(s.registerService)
+	}
 	
 	// 设置心跳服务的引用
 	if s.heartbeatService != nil {
@@ -882,18 +1156,52 @@ func (s *Scheduler) initAPIServices() error {
 	s.metricsSender = services.NewMetricsSender(s.apiClient, logger.GetLogger(), metricsSenderConfig)
 
 	// 初始化命令执行采集器
-	commandConfigPath := "configs/command_mapping.yaml"
-	commandCollector, err := collector.NewCommandCollector(commandConfigPath, logger.GetLogger(), s.apiClient, s.metricsSender)
+	s.initCommandCollector()
+
+	// 初始化远程任务服务
+	s.initTaskService()
+
+	logger.Info("API服务初始化完成")
+	return nil
+}
+
+// initCommandCollector 初始化命令执行采集器并输出命令映射的警告，未启用数据中心时API客户端和指标发送器为空
+func (s *Scheduler) initCommandCollector() {
+	if s.loadCommandCollector() {
+		s.logCommandProblems()
+		logger.Info("命令执行采集器初始化完成")
+	}
+}
+
+// loadCommandCollector 创建命令执行采集器，失败时跳过命令执行功能
+// 只创建不输出命令映射警告，供get/test等一次性子命令使用，警告由validate子命令报告
+func (s *Scheduler) loadCommandCollector() bool {
+	commandCollector, err := collector.NewCommandCollector(s.config.Agent.CommandMapping, logger.GetLogger(), s.apiClient)
 	if err != nil {
 		logger.Warnf("初始化命令执行采集器失败: %v，将跳过命令执行功能", err)
 		s.commandCollector = nil
-	} else {
-		s.commandCollector = commandCollector
-		logger.Info("命令执行采集器初始化完成")
+		return false
 	}
+	s.commandCollector = commandCollector
+	s.commandCollector.SetCredentials(s.credentials)
+	return true
+}
 
-	logger.Info("API服务初始化完成")
-	return nil
+// loadCredentials 打开凭据库并交给命令执行采集器和原生服务采集器，打开失败时引用凭据的命令和连接配置会采集失败
+func (s *Scheduler) loadCredentials(cfg *config.Config) {
+	vault, err := credential.Load(cfg.Credentials)
+	if err != nil {
+		logger.Warnf("加载凭据库失败: %v，引用凭据的命令将无法执行", err)
+	} else if vault != nil {
+		logger.Infof("凭据库加载完成: %s, 凭据数: %d", vault.Path(), len(vault.Names()))
+	}
+	s.credentials = vault
+	if s.commandCollector != nil {
+		s.commandCollector.SetCredentials(vault)
+	}
+	for _, serviceCollector := range s.serviceCollectors {
+		serviceCollector.SetCredentials(vault)
+	}
 }
 
 // startAPIServices 启动API服务
@@ -905,10 +1213,15 @@ func (s *Scheduler) startAPIServices() error {
 	// 注册agent
 	if s.registerService != nil {
 		if err := s.registerService.RegisterWithRetry(s.ctx, 3, 5*time.Second); err != nil {
-			logger.Errorf("Agent注册失败: %v", err)
-			return err
+			// 有离线配置缓存时继续启动，由配置管理器在数据中心恢复后重新注册
+			if s.configManager == nil || !s.configManager.HasCache() {
+				logger.Errorf("Agent注册失败: %v", err)
+				return err
+			}
+			logger.Warnf("Agent注册失败: %v，使用离线缓存配置继续运行", err)
+		} else {
+			logger.Info("Agent注册成功")
 		}
-		logger.Info("Agent注册成功")
 	}
 
 	// 启动心跳服务
@@ -935,6 +1248,14 @@ func (s *Scheduler) startAPIServices() error {
 		}
 	}
 
+	// 启动远程任务服务
+	if s.taskService != nil {
+		if err := s.taskService.Start(s.ctx); err != nil {
+			logger.Errorf("启动远程任务服务失败: %v", err)
+			return err
+		}
+	}
+
 	logger.Info("API服务启动完成")
 	return nil
 }
@@ -945,6 +1266,13 @@ func (s *Scheduler) stopAPIServices() {
 		return
 	}
 
+	// 停止远程任务服务
+	if s.taskService != nil {
+		if err := s.taskService.Stop(); err != nil {
+			logger.Errorf("停止远程任务服务失败: %v", err)
+		}
+	}
+
 	// 停止指标发送器
 	if s.metricsSender != nil {
 		if err := s.metricsSender.Stop(); err != nil {
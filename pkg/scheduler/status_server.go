@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"go-agent/pkg/logger"
+)
+
+// StatusPath 本地状态接口路径
+const StatusPath = "/status"
+
+// startStatusServer 启动本地状态接口，供 go-agent status 查询运行中的agent；监听失败只记录警告
+func (s *Scheduler) startStatusServer() {
+	addr := s.config.Agent.StatusListen
+	if addr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Warnf("本地状态接口监听 %s 失败: %v", addr, err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, s.handleStatus)
+	s.statusServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.statusServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warnf("本地状态接口异常退出: %v", err)
+		}
+	}()
+	logger.Infof("本地状态接口已启动: http://%s%s", listener.Addr(), StatusPath)
+}
+
+// stopStatusServer 关闭本地状态接口
+func (s *Scheduler) stopStatusServer() {
+	if s.statusServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.statusServer.Shutdown(ctx); err != nil {
+		logger.Warnf("关闭本地状态接口失败: %v", err)
+	}
+}
+
+// handleStatus 以JSON返回调度器状态
+func (s *Scheduler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s.GetStatus()); err != nil {
+		logger.Warnf("输出状态失败: %v", err)
+	}
+}
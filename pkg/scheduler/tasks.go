@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/logger"
+	"go-agent/pkg/services"
+)
+
+// initTaskService 初始化远程任务服务并注册任务处理函数
+// 远程采集与定时采集走同一条采集路径，只能执行当前分配给本agent的监控项
+func (s *Scheduler) initTaskService() {
+	tasksConfig := s.config.DeviceMonitor.Tasks
+	if !tasksConfig.Enabled {
+		logger.Info("远程任务未启用")
+		return
+	}
+
+	s.taskService = services.NewTaskService(s.apiClient, logger.GetLogger(), &services.TaskServiceConfig{
+		Enabled:        tasksConfig.Enabled,
+		PollInterval:   tasksConfig.PollInterval,
+		AllowedTypes:   tasksConfig.AllowedTypes,
+		Workers:        tasksConfig.Workers,
+		DefaultTimeout: tasksConfig.Timeout,
+	})
+	s.taskService.RegisterHandler(client.TaskTypeExecuteItem, s.handleExecuteItemTask)
+	s.taskService.RegisterHandler(client.TaskTypeRefreshConfig, s.handleRefreshConfigTask)
+	s.taskService.RegisterHandler(client.TaskTypeFlushMetrics, s.handleFlushMetricsTask)
+
+	if s.heartbeatService != nil {
+		s.heartbeatService.SetTaskService(s.taskService)
+	}
+}
+
+// handleExecuteItemTask 立即采集指定监控项并返回值，Send为true时同时上报
+// 只执行当前分配给本agent的监控项，不能借此执行命令映射中未配置为监控项的命令
+func (s *Scheduler) handleExecuteItemTask(ctx context.Context, task *client.AgentTask) (interface{}, error) {
+	itemScheduler := s.findItemScheduler(task.ItemID, task.ItemKey)
+	if itemScheduler == nil {
+		if task.ItemKey == "" {
+			return nil, fmt.Errorf("监控项 %d 未分配给本agent", task.ItemID)
+		}
+		return nil, fmt.Errorf("监控项 %s 未分配给本agent", task.ItemKey)
+	}
+
+	// 仅上报时使用监控项自身的预处理状态，否则不影响定时采集的变化量计算
+	preprocessor := itemScheduler.preprocessor
+	if !task.Send {
+		preprocessor = preprocessor.Fresh()
+	}
+
+	value, collectedAt, keep, err := s.evaluateItem(ctx, itemScheduler, preprocessor)
+	if err != nil {
+		return nil, err
+	}
+	if !keep {
+		return nil, fmt.Errorf("值在预处理中被丢弃，变化量类预处理需要连续两次采集")
+	}
+
+	if task.Send && itemScheduler.ItemID != 0 {
+		s.sendItemValue(ctx, itemScheduler, value, collectedAt)
+	}
+	return value, nil
+}
+
+// handleRefreshConfigTask 立即刷新采集配置
+func (s *Scheduler) handleRefreshConfigTask(ctx context.Context, task *client.AgentTask) (interface{}, error) {
+	if s.configManager == nil {
+		return nil, fmt.Errorf("监控项配置不是从数据中心获取，无法刷新")
+	}
+	s.configManager.RefreshConfig()
+	return nil, nil
+}
+
+// handleFlushMetricsTask 立即发送缓冲区中的指标
+func (s *Scheduler) handleFlushMetricsTask(ctx context.Context, task *client.AgentTask) (interface{}, error) {
+	if s.metricsSender == nil {
+		return nil, fmt.Errorf("指标发送器未初始化")
+	}
+	s.metricsSender.Flush()
+	return nil, nil
+}
+
+// findItemScheduler 按ID或key查找正在调度的监控项
+func (s *Scheduler) findItemScheduler(itemID int64, itemKey string) *ItemScheduler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if itemID != 0 {
+		if itemScheduler, exists := s.itemSchedulers[itemID]; exists {
+			return itemScheduler
+		}
+	}
+	if itemKey != "" {
+		for _, itemScheduler := range s.itemSchedulers {
+			if itemScheduler.ItemKey == itemKey {
+				return itemScheduler
+			}
+		}
+	}
+	return nil
+}
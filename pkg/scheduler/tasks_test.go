@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-agent/pkg/client"
+)
+
+// execute_item 只能执行已分配给本agent的监控项，命令映射中的其他key被拒绝
+func TestExecuteItemTaskRejectsUnassignedKey(t *testing.T) {
+	s := New()
+	s.itemSchedulers[1] = &ItemScheduler{ItemID: 1, ItemName: "CPU", ItemKey: "system.cpu.util"}
+
+	tests := []struct {
+		name string
+		task client.AgentTask
+	}{
+		{name: "未分配的key", task: client.AgentTask{Type: client.TaskTypeExecuteItem, ItemKey: "system.run[rm -rf /tmp/x]"}},
+		{name: "未分配的ID", task: client.AgentTask{Type: client.TaskTypeExecuteItem, ItemID: 2}},
+		{name: "未分配的ID和key", task: client.AgentTask{Type: client.TaskTypeExecuteItem, ItemID: 2, ItemKey: "system.hostname"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.handleExecuteItemTask(context.Background(), &tt.task)
+			if err == nil || !strings.Contains(err.Error(), "未分配") {
+				t.Errorf("handleExecuteItemTask error = %v, want unassigned item error", err)
+			}
+		})
+	}
+
+	if found := s.findItemScheduler(0, "system.cpu.util"); found == nil || found.ItemID != 1 {
+		t.Errorf("findItemScheduler by key = %v, want assigned item 1", found)
+	}
+}
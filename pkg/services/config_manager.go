@@ -2,7 +2,13 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -21,26 +27,52 @@ type CollectItem struct {
 	UpdateIntervalSeconds int                          `json:"updateIntervalSeconds"` // 推送间隔(秒)
 	Timeout               int                          `json:"timeout"`
 	Intervals             []*client.ItemCustomInterval `json:"intervals"` // 自定义时间间隔
+	Timezone              string                       `json:"timezone"`  // 自定义间隔所用时区
+	Preprocessing         []*client.PreprocessingStep  `json:"preprocessing"`
 }
 
 // ConfigManager 配置管理器
 type ConfigManager struct {
-	client         *client.DeviceMonitorClient
-	logger         *logrus.Logger
-	items          []CollectItem
-	mutex          sync.RWMutex
-	lastUpdate     time.Time
-	refreshChan    chan struct{}
-	stopChan       chan struct{}
-	wg             sync.WaitGroup
-	running        bool
-	onConfigUpdate func([]CollectItem) // 配置更新回调
+	client          *client.DeviceMonitorClient
+	logger          *logrus.Logger
+	items           []CollectItem
+	mutex           sync.RWMutex
+	lastUpdate      time.Time
+	refreshChan     chan struct{}
+	stopChan        chan struct{}
+	wg              sync.WaitGroup
+	running         bool
+	onConfigUpdate  func([]CollectItem) // 配置更新回调
+	cachePath       string              // 离线配置缓存文件路径，为空时不缓存
+	version         string              // 当前配置版本（配置内容哈希）
+	offline         bool                // 是否正在使用缓存配置运行
+	registerService *RegisterService    // 离线启动后恢复连接时用于注册
+	refreshInterval time.Duration       // 定时刷新间隔
+	etag            string              // 服务端下发的配置ETag，用于条件获取
+	watch           bool                // 是否使用长轮询等待配置变更
+	watchTimeout    time.Duration       // 单次长轮询等待时间
+	cancel          context.CancelFunc  // 取消长轮询等后台请求
 }
 
 // ConfigManagerConfig 配置管理器配置
 type ConfigManagerConfig struct {
 	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
 	Enabled         bool          `mapstructure:"enabled"`
+	CachePath       string        `mapstructure:"cache_path"`
+	Watch           bool          `mapstructure:"watch"`         // 长轮询等待服务端配置变更通知
+	WatchTimeout    time.Duration `mapstructure:"watch_timeout"` // 单次长轮询等待时间
+}
+
+// offlineRetryInterval 使用缓存配置运行时重新连接数据中心的间隔
+const offlineRetryInterval = 30 * time.Second
+
+// configCache 离线配置缓存文件内容
+type configCache struct {
+	Version string                      `json:"version"`
+	ETag    string                      `json:"etag,omitempty"`
+	AgentID string                      `json:"agentId"`
+	SavedAt time.Time                   `json:"savedAt"`
+	Data    []client.ConfigResponseData `json:"data"`
 }
 
 // NewConfigManager 创建配置管理器
@@ -49,17 +81,32 @@ func NewConfigManager(client *client.DeviceMonitorClient, logger *logrus.Logger,
 	if refreshInterval <= 0 {
 		refreshInterval = 5 * time.Minute // 默认5分钟刷新一次
 	}
+	watchTimeout := config.WatchTimeout
+	if watchTimeout <= 0 {
+		watchTimeout = 60 * time.Second
+	}
 
 	return &ConfigManager{
-		client:      client,
-		logger:      logger,
-		items:       make([]CollectItem, 0),
-		refreshChan: make(chan struct{}, 1),
-		stopChan:    make(chan struct{}),
-		running:     false,
+		client:          client,
+		logger:          logger,
+		items:           make([]CollectItem, 0),
+		refreshChan:     make(chan struct{}, 1),
+		stopChan:        make(chan struct{}),
+		running:         false,
+		cachePath:       config.CachePath,
+		refreshInterval: refreshInterval,
+		watch:           config.Watch,
+		watchTimeout:    watchTimeout,
 	}
 }
 
+// SetRegisterService 设置注册服务，离线启动后恢复连接时先重新注册
+func (cm *ConfigManager) SetRegisterService(registerService *RegisterService) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.registerService = registerService
+}
+
 // SetConfigUpdateCallback 设置配置更新回调
 func (cm *ConfigManager) SetConfigUpdateCallback(callback func([]CollectItem)) {
 	cm.mutex.Lock()
@@ -70,25 +117,40 @@ func (cm *ConfigManager) SetConfigUpdateCallback(callback func([]CollectItem)) {
 // Start 启动配置管理器
 func (cm *ConfigManager) Start(ctx context.Context) error {
 	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
-
 	if cm.running {
+		cm.mutex.Unlock()
 		return fmt.Errorf("配置管理器已在运行")
 	}
+	cm.mutex.Unlock()
 
-	// 初始加载配置
+	// 初始加载配置，数据中心不可用时使用上次成功获取的缓存配置
+	// loadConfig和loadCache内部会加锁，这里不能持有锁
 	if err := cm.loadConfig(ctx); err != nil {
 		cm.logger.Error("初始加载配置失败", map[string]interface{}{
 			"error": err.Error(),
 		})
-		return err
+		if cacheErr := cm.loadCache(); cacheErr != nil {
+			cm.logger.Warn("加载离线配置缓存失败", map[string]interface{}{
+				"error": cacheErr.Error(),
+			})
+			return err
+		}
 	}
 
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	ctx, cm.cancel = context.WithCancel(ctx)
 	cm.running = true
 	cm.wg.Add(1)
 
 	go cm.refreshLoop(ctx)
 
+	if cm.watch {
+		cm.wg.Add(1)
+		go cm.watchLoop(ctx)
+	}
+
 	cm.logger.Info("配置管理器已启动")
 	return nil
 }
@@ -96,15 +158,17 @@ func (cm *ConfigManager) Start(ctx context.Context) error {
 // Stop 停止配置管理器
 func (cm *ConfigManager) Stop() error {
 	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
-
 	if !cm.running {
+		cm.mutex.Unlock()
 		return nil
 	}
 
 	close(cm.stopChan)
+	cm.cancel()
 	cm.running = false
+	cm.mutex.Unlock()
 
+	// 刷新循环中的加载会获取锁，等待时不能持有锁
 	cm.wg.Wait()
 
 	cm.logger.Info("配置管理器已停止")
@@ -172,7 +236,11 @@ func (cm *ConfigManager) GetLastUpdateTime() time.Time {
 func (cm *ConfigManager) loadConfig(ctx context.Context) error {
 	cm.logger.Debug("开始加载采集配置")
 
-	resp, err := cm.client.GetConfig(ctx)
+	cm.mutex.RLock()
+	etag := cm.etag
+	cm.mutex.RUnlock()
+
+	resp, newETag, notModified, err := cm.client.GetConfigIfChanged(ctx, etag)
 	if err != nil {
 		cm.logger.Error("获取配置失败", map[string]interface{}{
 			"error": err.Error(),
@@ -180,6 +248,23 @@ func (cm *ConfigManager) loadConfig(ctx context.Context) error {
 		return fmt.Errorf("获取配置失败: %v", err)
 	}
 
+	// 配置未变化，无需下载和解析
+	if notModified {
+		cm.mutex.Lock()
+		wasOffline := cm.offline
+		cm.offline = false
+		cm.lastUpdate = time.Now()
+		cm.mutex.Unlock()
+
+		if wasOffline {
+			cm.logger.Info("已恢复与数据中心的连接", map[string]interface{}{
+				"changed": false,
+			})
+		}
+		cm.logger.Debug("采集配置未变化")
+		return nil
+	}
+
 	if resp.Code != 200 {
 		cm.logger.Error("获取配置响应异常", map[string]interface{}{
 			"code": resp.Code,
@@ -195,31 +280,41 @@ func (cm *ConfigManager) loadConfig(ctx context.Context) error {
 	}
 
 	// 转换响应数据为内部结构
-	items := make([]CollectItem, len(resp.Data))
-	for i, item := range resp.Data {
-		items[i] = CollectItem{
-			ItemID:                item.ItemID,
-			ItemName:              item.ItemName,
-			ItemKey:               item.ItemKey,
-			InfoType:              item.InfoType,
-			UpdateIntervalSeconds: item.UpdateIntervalSeconds,
-			Timeout:               item.Timeout,
-			Intervals:             item.Intervals,
-		}
-	}
+	items := toCollectItems(resp.Data)
+	version := configVersion(resp.Data)
 
 	// 更新配置
 	cm.mutex.Lock()
+	previous := cm.version
+	previousETag := cm.etag
+	wasOffline := cm.offline
 	cm.items = items
+	cm.version = version
+	cm.etag = newETag
+	cm.offline = false
 	cm.lastUpdate = time.Now()
 
-	// 调用配置更新回调
-	if cm.onConfigUpdate != nil {
+	// 配置内容变化时调用配置更新回调，首次加载由调用方直接读取
+	if cm.onConfigUpdate != nil && previous != "" && previous != version {
 		go cm.onConfigUpdate(items)
 	}
 
 	cm.mutex.Unlock()
 
+	if wasOffline {
+		cm.logger.Info("已恢复与数据中心的连接", map[string]interface{}{
+			"changed": previous != version,
+		})
+	}
+
+	if previous != version || previousETag != newETag {
+		if err := cm.saveCache(version, newETag, resp.Data); err != nil {
+			cm.logger.Warn("保存离线配置缓存失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	cm.logger.Info("配置加载成功", map[string]interface{}{
 		"item_count":  len(items),
 		"update_time": cm.lastUpdate,
@@ -242,9 +337,13 @@ func (cm *ConfigManager) loadConfig(ctx context.Context) error {
 func (cm *ConfigManager) refreshLoop(ctx context.Context) {
 	defer cm.wg.Done()
 
-	ticker := time.NewTicker(5 * time.Minute) // 每5分钟自动刷新
+	ticker := time.NewTicker(cm.refreshInterval)
 	defer ticker.Stop()
 
+	// 使用缓存配置运行时，定期尝试重新连接
+	retryTicker := time.NewTicker(offlineRetryInterval)
+	defer retryTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -265,6 +364,75 @@ func (cm *ConfigManager) refreshLoop(ctx context.Context) {
 					"error": err.Error(),
 				})
 			}
+		case <-retryTicker.C:
+			if !cm.IsOffline() {
+				continue
+			}
+			if err := cm.reconnect(ctx); err != nil {
+				cm.logger.Debug("重新连接数据中心失败，继续使用缓存配置", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// watchLoop 长轮询等待服务端配置变更通知，收到变更后立即刷新配置
+// 服务端不支持时退出，仅使用定时刷新
+func (cm *ConfigManager) watchLoop(ctx context.Context) {
+	defer cm.wg.Done()
+
+	backoff := time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cm.stopChan:
+			return
+		default:
+		}
+
+		// 优先使用服务端ETag作为版本，便于服务端直接比较
+		cm.mutex.RLock()
+		version := cm.etag
+		if version == "" {
+			version = cm.version
+		}
+		cm.mutex.RUnlock()
+
+		changed, err := cm.client.WatchConfig(ctx, version, cm.watchTimeout)
+		if err != nil {
+			if errors.Is(err, client.ErrWatchUnsupported) {
+				cm.logger.Info("数据中心不支持配置变更通知，仅使用定时刷新", map[string]interface{}{
+					"refresh_interval": cm.refreshInterval.String(),
+				})
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			cm.logger.Debug("等待配置变更通知失败", map[string]interface{}{
+				"error":   err.Error(),
+				"backoff": backoff.String(),
+			})
+			select {
+			case <-ctx.Done():
+				return
+			case <-cm.stopChan:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+			continue
+		}
+
+		backoff = time.Second
+		if changed {
+			cm.logger.Info("收到配置变更通知")
+			cm.RefreshConfig()
 		}
 	}
 }
@@ -276,6 +444,143 @@ func (cm *ConfigManager) IsRunning() bool {
 	return cm.running
 }
 
+// IsOffline 检查是否正在使用缓存配置运行
+func (cm *ConfigManager) IsOffline() bool {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.offline
+}
+
+// GetVersion 获取当前配置版本
+func (cm *ConfigManager) GetVersion() string {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.version
+}
+
+// HasCache 检查是否存在离线配置缓存
+func (cm *ConfigManager) HasCache() bool {
+	if cm.cachePath == "" {
+		return false
+	}
+	_, err := os.Stat(cm.cachePath)
+	return err == nil
+}
+
+// reconnect 离线运行时重新连接数据中心：未注册时先注册，再加载配置
+func (cm *ConfigManager) reconnect(ctx context.Context) error {
+	cm.mutex.RLock()
+	registerService := cm.registerService
+	cm.mutex.RUnlock()
+
+	if registerService != nil && !cm.client.IsAuthenticated() {
+		if err := registerService.Register(ctx); err != nil {
+			return err
+		}
+	}
+	return cm.loadConfig(ctx)
+}
+
+// loadCache 从离线缓存加载配置
+func (cm *ConfigManager) loadCache() error {
+	if cm.cachePath == "" {
+		return fmt.Errorf("未配置离线配置缓存")
+	}
+
+	data, err := os.ReadFile(cm.cachePath)
+	if err != nil {
+		return fmt.Errorf("读取缓存文件失败: %v", err)
+	}
+
+	var cache configCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return fmt.Errorf("解析缓存文件失败: %v", err)
+	}
+	if cache.Version != configVersion(cache.Data) {
+		return fmt.Errorf("缓存文件版本校验失败")
+	}
+
+	// 恢复缓存的agentID，保持调度偏移等依赖agentID的行为一致
+	if cm.client.GetAgentID() == "" && cache.AgentID != "" {
+		cm.client.SetAgentID(cache.AgentID)
+	}
+
+	cm.mutex.Lock()
+	cm.items = toCollectItems(cache.Data)
+	cm.version = cache.Version
+	cm.etag = cache.ETag
+	cm.offline = true
+	cm.lastUpdate = cache.SavedAt
+	cm.mutex.Unlock()
+
+	cm.logger.Warn("数据中心不可用，使用离线缓存配置运行", map[string]interface{}{
+		"item_count": len(cm.items),
+		"version":    cache.Version,
+		"saved_at":   cache.SavedAt,
+	})
+	return nil
+}
+
+// saveCache 保存配置到离线缓存，先写临时文件再重命名，避免写入中断损坏缓存
+func (cm *ConfigManager) saveCache(version, etag string, data []client.ConfigResponseData) error {
+	if cm.cachePath == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(configCache{
+		Version: version,
+		ETag:    etag,
+		AgentID: cm.client.GetAgentID(),
+		SavedAt: time.Now(),
+		Data:    data,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cm.cachePath), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+	tmpPath := cm.cachePath + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, cm.cachePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换缓存文件失败: %v", err)
+	}
+	return nil
+}
+
+// toCollectItems 转换配置响应数据为内部结构
+func toCollectItems(data []client.ConfigResponseData) []CollectItem {
+	items := make([]CollectItem, len(data))
+	for i, item := range data {
+		items[i] = CollectItem{
+			ItemID:                item.ItemID,
+			ItemName:              item.ItemName,
+			ItemKey:               item.ItemKey,
+			InfoType:              item.InfoType,
+			UpdateIntervalSeconds: item.UpdateIntervalSeconds,
+			Timeout:               item.Timeout,
+			Intervals:             item.Intervals,
+			Timezone:              item.Timezone,
+			Preprocessing:         item.Preprocessing,
+		}
+	}
+	return items
+}
+
+// configVersion 计算配置内容的版本哈希
+func configVersion(data []client.ConfigResponseData) string {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
 // GetItemCount 获取采集项数量
 func (cm *ConfigManager) GetItemCount() int {
 	cm.mutex.RLock()
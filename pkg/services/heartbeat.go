@@ -33,6 +33,7 @@ type HeartbeatService struct {
 	running          bool
 	registerService  *RegisterService      // 注册服务引用
 	configManager    *ConfigManager       // 配置管理器引用
+	taskService      *TaskService         // 远程任务服务引用
 	failureCount     int                  // 连续失败次数
 	lastFailureTime  time.Time           // 最后失败时间
 }
@@ -75,6 +76,13 @@ func (s *HeartbeatService) SetConfigManager(configManager *ConfigManager) {
 	s.configManager = configManager
 }
 
+// SetTaskService 设置远程任务服务引用，心跳响应中携带的任务交由其执行
+func (s *HeartbeatService) SetTaskService(taskService *TaskService) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.taskService = taskService
+}
+
 // Start 启动心跳服务
 func (s *HeartbeatService) Start(ctx context.Context) error {
 	s.mutex.Lock()
@@ -174,8 +182,20 @@ func (s *HeartbeatService) SendHeartbeat(ctx context.Context) error {
 			s.status = StatusOnline
 		}
 	}
+	taskService := s.taskService
 	s.mutex.Unlock()
 
+	// 执行随心跳下发的任务
+	if tasks := resp.Tasks(); len(tasks) > 0 {
+		if taskService != nil {
+			taskService.Submit(ctx, tasks)
+		} else {
+			s.logger.Warn("心跳响应携带任务，但远程任务服务未启用", map[string]interface{}{
+				"task_count": len(tasks),
+			})
+		}
+	}
+
 	return nil
 }
 
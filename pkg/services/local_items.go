@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"go-agent/pkg/client"
+	"go-agent/pkg/config"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// localItemsFile 本地监控项文件
+type localItemsFile struct {
+	Items []localItem `mapstructure:"items"`
+}
+
+// localItem 本地监控项文件中的监控项定义
+type localItem struct {
+	ID            int64               `mapstructure:"id"`
+	Name          string              `mapstructure:"name"`
+	Key           string              `mapstructure:"key"`
+	InfoType      string              `mapstructure:"info_type"` // float, character, log, unsigned, text 或对应数字
+	Interval      time.Duration       `mapstructure:"interval"`
+	Timeout       time.Duration       `mapstructure:"timeout"`
+	Timezone      string              `mapstructure:"timezone"`
+	Intervals     []localInterval     `mapstructure:"intervals"`
+	Preprocessing []localPreprocessor `mapstructure:"preprocessing"`
+}
+
+// localInterval 本地监控项文件中的自定义间隔
+// 指定type时使用expression；否则为星期+时间窗口形式
+type localInterval struct {
+	Type       string        `mapstructure:"type"`
+	Expression string        `mapstructure:"expression"`
+	Week       int           `mapstructure:"week"`
+	StartTime  string        `mapstructure:"start_time"`
+	EndTime    string        `mapstructure:"end_time"`
+	Interval   time.Duration `mapstructure:"interval"`
+}
+
+// localPreprocessor 本地监控项文件中的预处理步骤
+type localPreprocessor struct {
+	Type   string   `mapstructure:"type"`
+	Params []string `mapstructure:"params"`
+}
+
+// IntervalParser 检查自定义间隔能否被调度器解析，由调度器提供以避免循环依赖
+type IntervalParser func(interval *client.ItemCustomInterval) error
+
+// LoadLocalItems 从本地YAML文件加载监控项，格式与数据中心下发的监控项一致
+// 未指定id时按key生成稳定的监控项ID；文件存在问题时返回包含全部问题的错误
+func LoadLocalItems(path string, parseInterval IntervalParser) ([]CollectItem, error) {
+	items, problems, err := CheckLocalItems(path, parseInterval)
+	if err != nil {
+		return nil, err
+	}
+	if errs := config.Errors(problems); len(errs) > 0 {
+		return nil, fmt.Errorf("本地监控项文件有误: %v", &config.ValidationError{Problems: errs})
+	}
+	return items, nil
+}
+
+// CheckLocalItems 严格读取本地监控项文件，返回有效的监控项和全部问题（未知字段、解析失败、监控项无效、ID重复、自定义间隔无效）
+// parseInterval为nil时不检查自定义间隔
+func CheckLocalItems(path string, parseInterval IntervalParser) ([]CollectItem, []config.Problem, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("读取本地监控项文件失败: %v", err)
+	}
+
+	// 未知字段（如拼写错误的 intervall）作为问题报告，而不是被忽略
+	problems := &config.Problems{File: path}
+	var file localItemsFile
+	problems.AddDecodeError(v.Unmarshal(&file, func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = true
+	}))
+	if len(problems.List()) > 0 {
+		return nil, problems.List(), nil
+	}
+
+	items := make([]CollectItem, 0, len(file.Items))
+	ids := make(map[int64]string)
+	for i, def := range file.Items {
+		itemPath := fmt.Sprintf("items[%d]", i)
+		item, err := def.toCollectItem()
+		if err != nil {
+			problems.Add(itemPath, "%v", err)
+			continue
+		}
+		if other, exists := ids[item.ItemID]; exists {
+			problems.Add(itemPath+".id", "监控项 %s 与 %s 的ID重复: %d", item.ItemKey, other, item.ItemID)
+			continue
+		}
+		ids[item.ItemID] = item.ItemKey
+		if parseInterval != nil {
+			for j, interval := range item.Intervals {
+				if err := parseInterval(interval); err != nil {
+					problems.Add(fmt.Sprintf("items[%s].intervals[%d]", item.ItemKey, j), "%v", err)
+				}
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, problems.List(), nil
+}
+
+// toCollectItem 转换为调度器使用的采集项
+func (l localItem) toCollectItem() (CollectItem, error) {
+	key := strings.TrimSpace(l.Key)
+	if key == "" {
+		return CollectItem{}, fmt.Errorf("监控项key不能为空")
+	}
+	if l.Interval < 0 || l.Timeout < 0 {
+		return CollectItem{}, fmt.Errorf("监控项 %s 的间隔和超时不能为负数", key)
+	}
+	if l.Interval == 0 && len(l.Intervals) == 0 {
+		return CollectItem{}, fmt.Errorf("监控项 %s 未配置采集间隔", key)
+	}
+
+	infoType := client.InfoTypeFloat
+	if l.InfoType != "" {
+		parsed, err := client.ParseInfoType(l.InfoType)
+		if err != nil {
+			return CollectItem{}, fmt.Errorf("监控项 %s: %v", key, err)
+		}
+		infoType = parsed
+	}
+
+	if l.Timezone != "" {
+		if _, err := time.LoadLocation(l.Timezone); err != nil {
+			return CollectItem{}, fmt.Errorf("监控项 %s 的时区无效: %v", key, err)
+		}
+	}
+
+	itemID := l.ID
+	if itemID == 0 {
+		itemID = LocalItemID(key)
+	}
+	name := l.Name
+	if name == "" {
+		name = key
+	}
+
+	item := CollectItem{
+		ItemID:                itemID,
+		ItemName:              name,
+		ItemKey:               key,
+		InfoType:              int(infoType),
+		UpdateIntervalSeconds: durationSeconds(l.Interval),
+		Timeout:               durationSeconds(l.Timeout),
+		Timezone:              l.Timezone,
+	}
+
+	for _, interval := range l.Intervals {
+		item.Intervals = append(item.Intervals, &client.ItemCustomInterval{
+			ItemID:          itemID,
+			IntervalSeconds: durationSeconds(interval.Interval),
+			Week:            interval.Week,
+			StartTime:       interval.StartTime,
+			EndTime:         interval.EndTime,
+			Type:            interval.Type,
+			Expression:      interval.Expression,
+		})
+	}
+
+	for _, step := range l.Preprocessing {
+		if step.Type == "" {
+			return CollectItem{}, fmt.Errorf("监控项 %s 的预处理步骤类型不能为空", key)
+		}
+		item.Preprocessing = append(item.Preprocessing, &client.PreprocessingStep{
+			Type:   step.Type,
+			Params: step.Params,
+		})
+	}
+
+	return item, nil
+}
+
+// LocalItemID 根据监控项key生成稳定的正整数ID
+func LocalItemID(key string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int64(h.Sum32()) + 1
+}
+
+// durationSeconds 将时长换算为秒，不足1秒的部分向上取整
+func durationSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
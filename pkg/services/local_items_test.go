@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-agent/pkg/client"
+)
+
+// writeItemsFile 在临时目录中创建本地监控项文件
+func writeItemsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "items.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckLocalItemsReportsUnknownKeys(t *testing.T) {
+	path := writeItemsFile(t, `
+itmes: []
+items:
+  - key: "system.cpu.util"
+    intervall: "60s"
+    interval: "60s"
+    intervals:
+      - type: flexible
+        expresion: "10s/1-5,09:00-18:00"
+`)
+
+	_, problems, err := CheckLocalItems(path, nil)
+	if err != nil {
+		t.Fatalf("CheckLocalItems error: %v", err)
+	}
+	var report []string
+	for _, problem := range problems {
+		report = append(report, problem.String())
+	}
+	joined := strings.Join(report, "\n")
+	for _, want := range []string{"itmes", "items[0]: has invalid keys: intervall", "items[0].intervals[0]: has invalid keys: expresion"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("problems = %q, want %q", joined, want)
+		}
+	}
+
+	if _, err := LoadLocalItems(path, nil); err == nil || !strings.Contains(err.Error(), "intervall") {
+		t.Errorf("LoadLocalItems error = %v, want unknown key error", err)
+	}
+}
+
+func TestCheckLocalItemsReportsAllInvalidItems(t *testing.T) {
+	path := writeItemsFile(t, `
+items:
+  - id: 1
+    key: "a"
+    interval: "30s"
+  - key: "b"
+  - id: 1
+    key: "c"
+    interval: "30s"
+  - key: "d"
+    interval: "1m"
+    info_type: text
+`)
+
+	items, problems, err := CheckLocalItems(path, nil)
+	if err != nil {
+		t.Fatalf("CheckLocalItems error: %v", err)
+	}
+	if len(items) != 2 || items[0].ItemKey != "a" || items[1].ItemKey != "d" {
+		t.Errorf("items = %+v, want a and d", items)
+	}
+	if len(problems) != 2 || problems[0].Key != "items[1]" || problems[1].Key != "items[2].id" {
+		t.Errorf("problems = %+v, want items[1] and items[2].id", problems)
+	}
+}
+
+func TestCheckLocalItemsReportsInvalidIntervals(t *testing.T) {
+	path := writeItemsFile(t, `
+items:
+  - key: "a"
+    interval: "1m"
+    intervals:
+      - type: flexible
+        expression: "10s/1-5,09:00-18:00"
+      - type: hourly
+        expression: "h9"
+  - key: "b"
+    interval: "1m"
+`)
+	// 调度器提供的解析器位于scheduler包，这里只按类型判断
+	parseInterval := func(interval *client.ItemCustomInterval) error {
+		if interval.Type != "flexible" {
+			return fmt.Errorf("不支持的自定义间隔类型: %s", interval.Type)
+		}
+		return nil
+	}
+
+	items, problems, err := CheckLocalItems(path, parseInterval)
+	if err != nil {
+		t.Fatalf("CheckLocalItems error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("items = %+v, want a and b", items)
+	}
+	if len(problems) != 1 || problems[0].Key != "items[a].intervals[1]" || !strings.Contains(problems[0].Message, "hourly") {
+		t.Errorf("problems = %+v, want items[a].intervals[1]", problems)
+	}
+
+	if _, err := LoadLocalItems(path, parseInterval); err == nil || !strings.Contains(err.Error(), "intervals[1]") {
+		t.Errorf("LoadLocalItems error = %v, want invalid interval error", err)
+	}
+}
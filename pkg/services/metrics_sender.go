@@ -114,9 +114,14 @@ func (ms *MetricsSender) Stop() error {
 
 // SendMetric 发送单个指标
 func (ms *MetricsSender) SendMetric(itemID int64, value interface{}, metadata map[string]interface{}) error {
+	return ms.SendMetricAt(itemID, value, time.Now(), metadata)
+}
+
+// SendMetricAt 发送单个指标，使用指定的采集时间
+func (ms *MetricsSender) SendMetricAt(itemID int64, value interface{}, timestamp time.Time, metadata map[string]interface{}) error {
 	metric := MetricData{
 		ItemID:    itemID,
-		Timestamp: time.Now(),
+		Timestamp: timestamp,
 		Value:     value,
 		Metadata:  metadata,
 	}
@@ -133,6 +138,11 @@ func (ms *MetricsSender) AddMetric(metric MetricData) error {
 		return fmt.Errorf("指标发送器未运行")
 	}
 
+	// 未指定采集时间时以入队时间为准
+	if metric.Timestamp.IsZero() {
+		metric.Timestamp = time.Now()
+	}
+
 	ms.buffer = append(ms.buffer, metric)
 
 	ms.logger.Debug("添加指标到缓冲区", map[string]interface{}{
@@ -152,15 +162,21 @@ func (ms *MetricsSender) AddMetric(metric MetricData) error {
 
 // SendMetricImmediate 立即发送指标（不通过缓冲区）
 func (ms *MetricsSender) SendMetricImmediate(ctx context.Context, itemID int64, value interface{}) error {
+	return ms.SendMetricImmediateAt(ctx, itemID, value, time.Now())
+}
+
+// SendMetricImmediateAt 立即发送指标（不通过缓冲区），使用指定的采集时间
+func (ms *MetricsSender) SendMetricImmediateAt(ctx context.Context, itemID int64, value interface{}, timestamp time.Time) error {
 	// 处理数组类型的值，只取第一个元素
 	processedValue := ms.processValue(value)
 
-	resp, err := ms.client.SendSingleMetric(ctx, itemID, processedValue)
+	resp, err := ms.client.SendMetricWithTimestamp(ctx, itemID, processedValue, timestamp)
 	if err != nil {
 		ms.logger.Error("立即发送指标失败", map[string]interface{}{
-			"item_id": itemID,
-			"value":   processedValue,
-			"error":   err.Error(),
+			"item_id":   itemID,
+			"value":     processedValue,
+			"timestamp": timestamp.UnixMilli(),
+			"error":     err.Error(),
 		})
 		return err
 	}
@@ -183,6 +199,53 @@ func (ms *MetricsSender) SendMetricImmediate(ctx context.Context, itemID int64,
 	return nil
 }
 
+// SendHistoricalMetrics 批量发送历史指标（保留各自的采集时间，不通过缓冲区）
+func (ms *MetricsSender) SendHistoricalMetrics(ctx context.Context, metrics []MetricData) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	requests := make([]client.MetricsRequest, 0, len(metrics))
+	for _, metric := range metrics {
+		if metric.Timestamp.IsZero() {
+			return fmt.Errorf("历史指标缺少采集时间: itemId=%d", metric.ItemID)
+		}
+		request := client.MetricsRequest{
+			ItemID:    metric.ItemID,
+			Timestamp: metric.Timestamp.UnixMilli(),
+			Value:     ms.processValue(metric.Value),
+		}
+		if typed, ok := request.Value.(*client.TypedValue); ok {
+			infoType := typed.Type
+			request.InfoType = &infoType
+		}
+		requests = append(requests, request)
+	}
+
+	resp, err := ms.client.SendHistoricalMetrics(ctx, requests)
+	if err != nil {
+		ms.logger.Error("发送历史指标失败", map[string]interface{}{
+			"metric_count": len(requests),
+			"error":        err.Error(),
+		})
+		return err
+	}
+
+	if resp.Code != 200 {
+		ms.logger.Error("历史指标发送响应异常", map[string]interface{}{
+			"code": resp.Code,
+			"msg":  resp.Msg,
+		})
+		return fmt.Errorf("历史指标发送响应异常: %s", resp.Msg)
+	}
+
+	ms.logger.Debug("历史指标发送成功", map[string]interface{}{
+		"metric_count": len(requests),
+	})
+
+	return nil
+}
+
 // Flush 手动刷新缓冲区
 func (ms *MetricsSender) Flush() {
 	ms.triggerFlush()
@@ -244,7 +307,7 @@ func (ms *MetricsSender) flushBuffer(ctx context.Context) {
 	failureCount := 0
 
 	for _, metric := range metrics {
-		err := ms.SendMetricImmediate(ctx, metric.ItemID, metric.Value)
+		err := ms.SendMetricImmediateAt(ctx, metric.ItemID, metric.Value, metric.Timestamp)
 		if err != nil {
 			failureCount++
 			ms.logger.Error("发送指标失败", map[string]interface{}{
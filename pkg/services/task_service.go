@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-agent/pkg/client"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TaskHandler 任务处理函数，返回的值作为任务结果上报
+type TaskHandler func(ctx context.Context, task *client.AgentTask) (interface{}, error)
+
+// TaskServiceConfig 任务服务配置
+type TaskServiceConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`   // 任务轮询间隔，为0时仅接收随心跳下发的任务
+	AllowedTypes   []string      `mapstructure:"allowed_types"`   // 允许执行的任务类型
+	Workers        int           `mapstructure:"workers"`         // 任务执行协程数
+	QueueSize      int           `mapstructure:"queue_size"`      // 待执行任务队列长度
+	DefaultTimeout time.Duration `mapstructure:"default_timeout"` // 任务未指定超时时的默认超时
+}
+
+// taskSeenTTL 已接收任务ID的保留时间，用于过滤重复下发
+const taskSeenTTL = time.Hour
+
+// TaskService 远程任务服务：接收数据中心下发的任务，执行后上报结果
+type TaskService struct {
+	client   *client.DeviceMonitorClient
+	logger   *logrus.Logger
+	config   TaskServiceConfig
+	handlers map[string]TaskHandler
+	allowed  map[string]bool
+	seen     map[string]time.Time
+	queue    chan client.AgentTask
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	mutex    sync.RWMutex
+	running  bool
+}
+
+// NewTaskService 创建远程任务服务
+func NewTaskService(deviceClient *client.DeviceMonitorClient, logger *logrus.Logger, config *TaskServiceConfig) *TaskService {
+	cfg := *config
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 16
+	}
+	if cfg.DefaultTimeout <= 0 {
+		cfg.DefaultTimeout = 30 * time.Second
+	}
+
+	allowed := make(map[string]bool)
+	for _, taskType := range cfg.AllowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(taskType))] = true
+	}
+
+	return &TaskService{
+		client:   deviceClient,
+		logger:   logger,
+		config:   cfg,
+		handlers: make(map[string]TaskHandler),
+		allowed:  allowed,
+		seen:     make(map[string]time.Time),
+		queue:    make(chan client.AgentTask, cfg.QueueSize),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// RegisterHandler 注册任务类型的处理函数
+func (s *TaskService) RegisterHandler(taskType string, handler TaskHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[taskType] = handler
+}
+
+// Start 启动任务服务
+func (s *TaskService) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.running {
+		return fmt.Errorf("任务服务已在运行")
+	}
+
+	s.running = true
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker(ctx)
+	}
+	if s.config.PollInterval > 0 {
+		s.wg.Add(1)
+		go s.pollLoop(ctx)
+	}
+
+	s.logger.Info("远程任务服务已启动", map[string]interface{}{
+		"poll_interval": s.config.PollInterval.String(),
+		"allowed_types": s.config.AllowedTypes,
+	})
+	return nil
+}
+
+// Stop 停止任务服务
+func (s *TaskService) Stop() error {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return nil
+	}
+	close(s.stopChan)
+	s.running = false
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+
+	s.logger.Info("远程任务服务已停止")
+	return nil
+}
+
+// Submit 提交数据中心下发的任务，重复的任务ID会被忽略，队列已满时上报拒绝
+func (s *TaskService) Submit(ctx context.Context, tasks []client.AgentTask) {
+	for _, task := range tasks {
+		if !s.markSeen(task.TaskID) {
+			s.logger.Debug("忽略重复下发的任务", map[string]interface{}{
+				"task_id": task.TaskID,
+			})
+			continue
+		}
+
+		select {
+		case s.queue <- task:
+			s.logger.Info("收到远程任务", map[string]interface{}{
+				"task_id": task.TaskID,
+				"type":    task.Type,
+			})
+		default:
+			s.report(ctx, &task, client.TaskStatusRejected, nil, fmt.Errorf("任务队列已满"), time.Now())
+		}
+	}
+}
+
+// markSeen 记录任务ID，已记录过时返回false
+func (s *TaskService) markSeen(taskID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for id, at := range s.seen {
+		if now.Sub(at) > taskSeenTTL {
+			delete(s.seen, id)
+		}
+	}
+
+	if taskID == "" {
+		return true
+	}
+	if _, exists := s.seen[taskID]; exists {
+		return false
+	}
+	s.seen[taskID] = now
+	return true
+}
+
+// pollLoop 定期拉取任务，服务端不支持轮询时退出，仅接收随心跳下发的任务
+func (s *TaskService) pollLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			tasks, err := s.client.PollTasks(ctx)
+			if err != nil {
+				if errors.Is(err, client.ErrTaskPollUnsupported) {
+					s.logger.Info("数据中心不支持任务轮询，仅接收随心跳下发的任务")
+					return
+				}
+				s.logger.Debug("拉取任务失败", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if len(tasks) > 0 {
+				s.Submit(ctx, tasks)
+			}
+		}
+	}
+}
+
+// worker 任务执行协程
+func (s *TaskService) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case task := <-s.queue:
+			s.execute(ctx, &task)
+		}
+	}
+}
+
+// execute 校验任务类型后执行并上报结果
+func (s *TaskService) execute(ctx context.Context, task *client.AgentTask) {
+	start := time.Now()
+	taskType := strings.ToLower(strings.TrimSpace(task.Type))
+
+	s.mutex.RLock()
+	handler, exists := s.handlers[taskType]
+	allowed := s.allowed[taskType]
+	s.mutex.RUnlock()
+
+	if !allowed {
+		s.logger.Warn("拒绝执行未允许的任务类型", map[string]interface{}{
+			"task_id": task.TaskID,
+			"type":    task.Type,
+		})
+		s.report(ctx, task, client.TaskStatusRejected, nil, fmt.Errorf("任务类型未允许: %s", task.Type), start)
+		return
+	}
+	if !exists {
+		s.report(ctx, task, client.TaskStatusRejected, nil, fmt.Errorf("不支持的任务类型: %s", task.Type), start)
+		return
+	}
+
+	timeout := s.config.DefaultTimeout
+	if task.Timeout > 0 {
+		timeout = time.Duration(task.Timeout) * time.Second
+	}
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	value, err := s.runHandler(taskCtx, handler, task)
+	if err != nil {
+		s.logger.Warn("远程任务执行失败", map[string]interface{}{
+			"task_id": task.TaskID,
+			"type":    task.Type,
+			"error":   err.Error(),
+		})
+		s.report(ctx, task, client.TaskStatusFailed, nil, err, start)
+		return
+	}
+
+	s.logger.Info("远程任务执行成功", map[string]interface{}{
+		"task_id":  task.TaskID,
+		"type":     task.Type,
+		"duration": time.Since(start).String(),
+	})
+	s.report(ctx, task, client.TaskStatusSuccess, value, nil, start)
+}
+
+// runHandler 执行处理函数，处理函数panic时作为失败返回
+func (s *TaskService) runHandler(ctx context.Context, handler TaskHandler, task *client.AgentTask) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务执行出现panic: %v", r)
+		}
+	}()
+	return handler(ctx, task)
+}
+
+// report 上报任务结果
+func (s *TaskService) report(ctx context.Context, task *client.AgentTask, status string, value interface{}, taskErr error, start time.Time) {
+	now := time.Now()
+	result := &client.TaskResult{
+		TaskID:     task.TaskID,
+		Status:     status,
+		Value:      value,
+		Timestamp:  now.UnixMilli(),
+		DurationMs: now.Sub(start).Milliseconds(),
+	}
+	if tv, ok := value.(*client.TypedValue); ok {
+		infoType := tv.Type
+		result.InfoType = &infoType
+	}
+	if taskErr != nil {
+		result.Error = taskErr.Error()
+	}
+
+	if err := s.client.ReportTaskResult(ctx, result); err != nil {
+		s.logger.Error("上报任务结果失败", map[string]interface{}{
+			"task_id": task.TaskID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// IsRunning 检查服务是否在运行
+func (s *TaskService) IsRunning() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.running
+}
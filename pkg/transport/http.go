@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -16,6 +17,7 @@ type HTTPTransport struct {
 	method  string
 	headers map[string]string
 	client  *http.Client
+	mu      sync.RWMutex // 保护headers，配置热加载时会替换
 }
 
 // TransportData 传输数据结构
@@ -78,9 +80,7 @@ func (t *HTTPTransport) Send(ctx context.Context, data interface{}, dataType str
 	req.Header.Set("User-Agent", "go-agent/1.0")
 
 	// 设置自定义头部
-	for key, value := range t.headers {
-		req.Header.Set(key, value)
-	}
+	t.applyHeaders(req)
 
 	// 发送请求
 	resp, err := t.client.Do(req)
@@ -134,9 +134,7 @@ func (t *HTTPTransport) SendBatch(ctx context.Context, dataList []interface{}, d
 	req.Header.Set("User-Agent", "go-agent/1.0")
 
 	// 设置自定义头部
-	for key, value := range t.headers {
-		req.Header.Set(key, value)
-	}
+	t.applyHeaders(req)
 
 	// 发送请求
 	resp, err := t.client.Do(req)
@@ -179,13 +177,31 @@ func (t *HTTPTransport) SendWithRetry(ctx context.Context, data interface{}, dat
 	return fmt.Errorf("重试%d次后仍然失败，最后错误: %v", maxRetries, lastErr)
 }
 
+// applyHeaders 将自定义头部写入请求
+func (t *HTTPTransport) applyHeaders(req *http.Request) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+}
+
 // SetHeaders 设置HTTP头部
 func (t *HTTPTransport) SetHeaders(headers map[string]string) {
-	t.headers = headers
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.headers = copied
 }
 
 // AddHeader 添加单个HTTP头部
 func (t *HTTPTransport) AddHeader(key, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	if t.headers == nil {
 		t.headers = make(map[string]string)
 	}
@@ -194,6 +210,8 @@ func (t *HTTPTransport) AddHeader(key, value string) {
 
 // RemoveHeader 移除HTTP头部
 func (t *HTTPTransport) RemoveHeader(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	delete(t.headers, key)
 }
 
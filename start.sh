@@ -82,7 +82,7 @@ if [ ! -f "$EXECUTABLE" ]; then
         fi
     else
         print_error "未找到构建脚本 build.sh"
-        print_info "请手动运行: go build -o go-agent cmd/agent/main.go"
+        print_info "请手动运行: go build -o go-agent ./cmd/agent"
         exit 1
     fi
 fi